@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+	"github.com/tgascoigne/ragekit/resource/clip"
+)
+
+/* exportClip samples each bone track of a clip at the animation frame rate */
+func exportClip(c *clip.Clip) *export.Animation {
+	name := c.Name
+	if name == "" {
+		name = c.Hash.String()
+	}
+
+	result := export.NewAnimation(name)
+	result.Duration = c.Duration()
+
+	tracks := make(map[clip.BoneId]*export.AnimationTrack)
+
+	var offset float32
+	for _, ca := range c.Animations {
+		anim := ca.Animation
+		frameTime := anim.FrameTime()
+		if result.FrameTime == 0 {
+			result.FrameTime = frameTime
+		}
+
+		rate := ca.Rate
+		if rate == 0 {
+			rate = 1
+		}
+
+		for bone, id := range anim.BoneIds {
+			target, ok := exportTarget(id.Track)
+			if !ok {
+				continue
+			}
+
+			track, ok := tracks[id]
+			if !ok {
+				track = &export.AnimationTrack{
+					Bone:   id.Name(),
+					Tag:    id.Tag,
+					Target: target,
+				}
+				if id.Track == clip.TrackMoverTranslation || id.Track == clip.TrackMoverRotation {
+					track.Bone = "mover"
+				}
+				tracks[id] = track
+				result.AddTrack(track)
+			}
+
+			for frame := 0; frame < int(anim.Header.Frames); frame++ {
+				t := float32(frame) * frameTime
+				if t < ca.StartTime || (ca.EndTime > ca.StartTime && t > ca.EndTime) {
+					continue
+				}
+
+				value, ok := anim.Sample(bone, frame)
+				if !ok {
+					continue
+				}
+
+				track.Times = append(track.Times, offset+(t-ca.StartTime)/rate)
+				track.Values = append(track.Values, mathgl.Vec4f(value))
+			}
+		}
+
+		offset += (ca.EndTime - ca.StartTime) / rate
+	}
+
+	return result
+}
+
+func exportTarget(track clip.Track) (export.TrackTarget, bool) {
+	switch track {
+	case clip.TrackBoneTranslation, clip.TrackMoverTranslation:
+		return export.TargetTranslation, true
+	case clip.TrackBoneRotation, clip.TrackMoverRotation:
+		return export.TargetRotation, true
+	case clip.TrackBoneScale:
+		return export.TargetScale, true
+	}
+	return 0, false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/bvh"
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/gltf"
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/clip"
+)
+
+func main() {
+	var outputBvh = flag.Bool("bvh", false, "Output to BVH instead of glTF")
+	var clipName = flag.String("clip", "", "Only export the named clip")
+	var list = flag.Bool("list", false, "List the clips in each dictionary instead of exporting")
+	flag.Parse()
+
+	log.SetFlags(0)
+	resource.SetArch(resource.ArchPC)
+	jenkins.ReadIndexFromEnv()
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: rage-anim-export [-bvh] [-clip name] [-list] file.ycd...")
+	}
+
+	var exportFunc func(*export.Animation) error
+	if *outputBvh {
+		exportFunc = bvh.ExportAnimation
+	} else {
+		exportFunc = gltf.ExportAnimation
+	}
+
+	exported := 0
+	for _, inFile := range flag.Args() {
+		dict := unpackDictionary(inFile)
+
+		baseName := filepath.Base(inFile)
+		baseName = strings.TrimSuffix(baseName, filepath.Ext(baseName))
+
+		for _, c := range dict.Clips {
+			if *list {
+				fmt.Printf("%v: %v (%v animations, %.3fs)\n", baseName, clipTitle(c), len(c.Animations), c.Duration())
+				continue
+			}
+
+			if *clipName != "" && c != dict.Clip(*clipName) {
+				continue
+			}
+
+			anim := exportClip(c)
+			anim.Name = fmt.Sprintf("%v_%v", baseName, sanitize(clipTitle(c)))
+			if err := exportFunc(anim); err != nil {
+				log.Println(err)
+				continue
+			}
+			fmt.Printf("\n")
+			exported++
+		}
+	}
+
+	if !*list {
+		log.Printf("Exported %v clips\n", exported)
+	}
+}
+
+func unpackDictionary(inFile string) *clip.Dictionary {
+	data, err := ioutil.ReadFile(inFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, filepath.Base(inFile), uint32(len(data))); err != nil {
+		log.Fatal(err)
+	}
+
+	dict := new(clip.Dictionary)
+	if err = dict.Unpack(res); err != nil {
+		log.Fatal(err)
+	}
+	return dict
+}
+
+func clipTitle(c *clip.Clip) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Hash.Hex()
+}
+
+func sanitize(name string) string {
+	name = strings.TrimPrefix(name, "pack:/")
+	name = strings.TrimSuffix(name, ".clip")
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name)
+}
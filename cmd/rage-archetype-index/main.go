@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/item"
+	"github.com/tgascoigne/ragekit/resource/item/archetype"
+)
+
+var (
+	indexFile = flag.String("index", os.Getenv(archetype.IndexFileEnv), "The archetype index file")
+	build     = flag.Bool("build", false, "Build the index from the given ytyp files, directories and packages")
+	defines   = flag.Bool("defines", false, "List the archetypes defined by the given ytyp files")
+	jsonOut   = flag.Bool("json", false, "Print query results as json")
+)
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	if *indexFile == "" {
+		log.Fatalf("No index file given, use -index or set %v", archetype.IndexFileEnv)
+	}
+
+	jenkins.ReadIndexFromEnv()
+
+	if *build {
+		doBuild(flag.Args())
+		return
+	}
+
+	idx, err := archetype.Load(*indexFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, arg := range flag.Args() {
+		if *defines {
+			for _, entry := range idx.Defines(arg) {
+				printEntry(entry)
+			}
+			continue
+		}
+
+		entry, ok := idx.Find(arg)
+		if !ok {
+			entry, ok = idx.Lookup(jenkins.Parse(arg))
+		}
+
+		if !ok {
+			log.Printf("%v: not found\n", arg)
+			continue
+		}
+		printEntry(entry)
+	}
+}
+
+func printEntry(entry *archetype.Entry) {
+	if *jsonOut {
+		data, err := json.MarshalIndent(struct {
+			*archetype.Entry
+			Dependencies []archetype.Dependency
+		}{entry, entry.Dependencies()}, "", "\t")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("%v (%v)\n", entry.Name, entry.Type)
+	fmt.Printf("\tdefined in %v\n", entry.File)
+	fmt.Printf("\tlod distance %v, flags 0x%x\n", entry.LodDist, entry.Flags)
+	fmt.Printf("\tbounds %v - %v, sphere %v r=%v\n", entry.BbMin, entry.BbMax, entry.BsCentre, entry.BsRadius)
+	for _, dep := range entry.Dependencies() {
+		fmt.Printf("\tdepends on %v.%v\n", dep.Name, dep.Kind)
+	}
+}
+
+func doBuild(paths []string) {
+	/* Item definitions and packages are only found in PC resources */
+	resource.SetArch(resource.ArchPC)
+
+	idx := archetype.NewIndex()
+	for _, p := range paths {
+		filepath.Walk(p, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() {
+				return nil
+			}
+
+			switch strings.ToLower(filepath.Ext(file)) {
+			case ".ytyp":
+				data, err := ioutil.ReadFile(file)
+				if err != nil {
+					log.Print(err)
+					return nil
+				}
+				addDefinition(idx, file, data)
+
+			case ".rpf":
+				data, err := ioutil.ReadFile(file)
+				if err != nil {
+					log.Print(err)
+					return nil
+				}
+				addPackage(idx, file, data)
+			}
+			return nil
+		})
+	}
+
+	log.Printf("Writing %v archetypes to %v\n", len(idx.Entries), *indexFile)
+	if err := idx.Save(*indexFile); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func addDefinition(idx *archetype.Index, file string, data []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("Unable to read %v: %v\n", file, err)
+		}
+	}()
+
+	def, err := item.Read(file, data)
+	if err != nil {
+		log.Printf("Unable to read %v: %v\n", file, err)
+		return
+	}
+
+	if err := idx.AddDefinition(file, def); err != nil {
+		log.Printf("Unable to read %v: %v\n", file, err)
+	}
+}
+
+/* addPackage indexes the ytyps in a package. Files inside packages are named by their path within it */
+func addPackage(idx *archetype.Index, file string, data []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("Unable to read %v: %v\n", file, err)
+		}
+	}()
+
+	pkg := new(resource.Package)
+	if err := pkg.Unpack(data, path.Base(file), uint32(len(data))); err != nil {
+		log.Printf("Unable to read %v: %v\n", file, err)
+		return
+	}
+
+	var walk func(node resource.PackageNode, dir string)
+	walk = func(node resource.PackageNode, dir string) {
+		switch node := node.(type) {
+		case resource.PackageDirectory:
+			for _, child := range node.Children(pkg) {
+				walk(child, path.Join(dir, node.Name(pkg)))
+			}
+
+		case resource.PackageFile:
+			name := path.Join(dir, node.Name(pkg))
+			switch strings.ToLower(path.Ext(name)) {
+			case ".ytyp":
+				addDefinition(idx, name, node.Data(pkg))
+			case ".rpf":
+				addPackage(idx, name, node.Data(pkg))
+			}
+		}
+	}
+
+	walk(pkg.Root(), file)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) != 3 {
+		log.Fatal("usage: rage-asm <asm> <ysc>")
+	}
+
+	in_file, out_file := os.Args[1], os.Args[2]
+	log.Printf("Assembling %v\n", in_file)
+
+	fd, err := os.Open(in_file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fd.Close()
+
+	/* Only PC scripts can be assembled */
+	resource.SetArch(resource.ArchPC)
+
+	name := strings.TrimSuffix(path.Base(out_file), path.Ext(out_file))
+	data, err := script.Assemble(name, fd)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(out_file, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,12 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
-	"strings"
 
 	"github.com/tgascoigne/ragekit/resource"
 	"github.com/tgascoigne/ragekit/resource/script"
@@ -17,25 +17,30 @@ func main() {
 	var data []byte
 	var err error
 
+	var cfgFormat = flag.String("cfg", "", "Output the control flow graph of each function instead, as dot or json")
+	var globalsHeader = flag.String("globals", "", "Name globals using a header written by rage-globals")
+	flag.Parse()
+
 	log.SetFlags(0)
 
+	if flag.NArg() < 1 {
+		log.Fatal("usage: rage-decompile [-cfg dot|json] [-globals header] <ysc|xsc>")
+	}
+
 	/* Read the file */
-	in_file := os.Args[1]
-	log.Printf("Decompiling %v\n", os.Args[1])
+	in_file := flag.Arg(0)
+	log.Printf("Decompiling %v\n", in_file)
 
 	if data, err = ioutil.ReadFile(in_file); err != nil {
 		log.Fatal(err)
 	}
 
 	/* Set the architecture */
-	switch {
-	case strings.Contains(in_file, "xsc"):
-		resource.SetArch(resource.Arch360)
-	case strings.Contains(in_file, "ysc"):
-		resource.SetArch(resource.ArchPC)
-	default:
+	format, ok := resource.FormatByExtension(in_file)
+	if !ok || format.Name != "script" {
 		panic(fmt.Sprintf("unknown architecture, path: %v", in_file))
 	}
+	resource.SetArch(format.Arch)
 
 	/* Unpack the container */
 	res := new(resource.Container)
@@ -58,7 +63,28 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if *cfgFormat != "" {
+		if err = script.WriteFlowGraphs(os.Stdout, script.FlowGraphs(code), *cfgFormat); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	decompileMachine := script.NewMachine(outScript, code)
+	if *globalsHeader != "" {
+		fd, err := os.Open(*globalsHeader)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		names, err := script.ReadGlobalHeader(fd)
+		fd.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		decompileMachine.NameGlobals(names)
+	}
+
 	file := decompileMachine.Decompile()
 	fmt.Println(file.CString())
 
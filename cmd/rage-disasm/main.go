@@ -1,12 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
-	"strings"
 
 	"github.com/tgascoigne/ragekit/resource"
 	"github.com/tgascoigne/ragekit/resource/script"
@@ -17,25 +17,29 @@ func main() {
 	var data []byte
 	var err error
 
+	var cfgFormat = flag.String("cfg", "", "Output the control flow graph of each function instead, as dot or json")
+	flag.Parse()
+
 	log.SetFlags(0)
 
+	if flag.NArg() < 1 {
+		log.Fatal("usage: rage-disasm [-cfg dot|json] <ysc|xsc> [output]")
+	}
+
 	/* Read the file */
-	in_file := os.Args[1]
-	log.Printf("Disassembling %v\n", os.Args[1])
+	in_file := flag.Arg(0)
+	log.Printf("Disassembling %v\n", in_file)
 
 	if data, err = ioutil.ReadFile(in_file); err != nil {
 		log.Fatal(err)
 	}
 
 	/* Set the architecture */
-	switch {
-	case strings.Contains(in_file, "xsc"):
-		resource.SetArch(resource.Arch360)
-	case strings.Contains(in_file, "ysc"):
-		resource.SetArch(resource.ArchPC)
-	default:
+	format, ok := resource.FormatByExtension(in_file)
+	if !ok || format.Name != "script" {
 		panic(fmt.Sprintf("unknown architecture, path: %v", in_file))
 	}
+	resource.SetArch(format.Arch)
 
 	/* Unpack the container */
 	res := new(resource.Container)
@@ -49,26 +53,37 @@ func main() {
 		log.Printf("Unable to load hash dictionary (%v). Lookups will be unavailable\n", err)
 	}
 
-	var emitFunc script.EmitFunc
-
-	if len(os.Args) > 2 {
-		outFile, err := os.Create(os.Args[2])
-		if err != nil {
+	out := os.Stdout
+	if flag.NArg() > 1 {
+		if out, err = os.Create(flag.Arg(1)); err != nil {
 			log.Fatal(err)
 		}
 
-		defer outFile.Close()
+		defer out.Close()
+	}
 
-		emitFunc = func(istr script.Instruction) {
-			outFile.WriteString(fmt.Sprintf("%v\n", istr.String()))
-		}
-	} else {
-		emitFunc = func(istr script.Instruction) {
-			fmt.Println(istr.String())
-		}
+	/* The directives aren't known until the script is unpacked, so hold on to the code until then */
+	code := make([]script.Instruction, 0)
+	emitFunc := func(istr script.Instruction) {
+		code = append(code, istr)
 	}
 
 	if err = outScript.Unpack(res, emitFunc); err != nil {
 		log.Fatal(err)
 	}
+
+	if *cfgFormat != "" {
+		if err = script.WriteFlowGraphs(out, script.FlowGraphs(code), *cfgFormat); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	for _, directive := range outScript.Directives() {
+		fmt.Fprintln(out, directive)
+	}
+
+	for _, istr := range code {
+		fmt.Fprintln(out, istr.String())
+	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	_ "github.com/tgascoigne/ragekit/resource/bounds"
+	_ "github.com/tgascoigne/ragekit/resource/clip"
+	_ "github.com/tgascoigne/ragekit/resource/dictionary"
+	_ "github.com/tgascoigne/ragekit/resource/drawable"
+	_ "github.com/tgascoigne/ragekit/resource/expression"
+	_ "github.com/tgascoigne/ragekit/resource/frag"
+	_ "github.com/tgascoigne/ragekit/resource/item"
+	_ "github.com/tgascoigne/ragekit/resource/script"
+	_ "github.com/tgascoigne/ragekit/resource/texture"
+	_ "github.com/tgascoigne/ragekit/resource/vehiclerecord"
+)
+
+var (
+	outputYaml = flag.Bool("yaml", false, "Output YAML instead of JSON")
+	outFile    = flag.String("o", "", "Output file, or - for stdout. Defaults to the input name with a .json or .yaml extension")
+	as         = flag.String("as", "", "Treat the input as a file with the given extension, such as .ydd")
+)
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	if flag.NArg() == 0 {
+		log.Fatal("Usage: rage-dump [-yaml] [-as ext] [-o out] <resource>...")
+	}
+
+	if *outFile != "" && *outFile != "-" && flag.NArg() > 1 {
+		log.Fatal("-o can only be used with a single input file")
+	}
+
+	jenkins.ReadIndexFromEnv()
+
+	for _, file := range flag.Args() {
+		if err := dump(file); err != nil {
+			log.Printf("Unable to dump %v: %v\n", file, err)
+		}
+	}
+}
+
+func dump(file string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	name := file
+	if *as != "" {
+		name = strings.TrimSuffix(file, filepath.Ext(file)) + *as
+	}
+
+	value, _, err := resource.Read(name, data)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(value, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if *outputYaml {
+		if out, err = jsonToYAML(out); err != nil {
+			return err
+		}
+	} else {
+		out = append(out, '\n')
+	}
+
+	return write(file, out)
+}
+
+func write(file string, data []byte) error {
+	out := *outFile
+	switch out {
+	case "-":
+		_, err := os.Stdout.Write(data)
+		return err
+
+	case "":
+		out = file + ".json"
+		if *outputYaml {
+			out = file + ".yaml"
+		}
+	}
+
+	log.Printf("Writing %v\n", out)
+	return ioutil.WriteFile(out, data, 0644)
+}
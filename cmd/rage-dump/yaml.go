@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// The dump is first rendered as JSON so that the MarshalJSON methods of the resource types apply,
+// and then converted into block style YAML. Objects keep their key order
+
+type yamlNode struct {
+	Scalar string
+	Keys   []string
+	Values []*yamlNode
+	IsMap  bool
+	IsList bool
+}
+
+var (
+	plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	/* Keys such as true or null would be read back as other types if left unquoted */
+	reservedKey = regexp.MustCompile(`^(?i:true|false|yes|no|on|off|null|y|n)$`)
+)
+
+func jsonToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	node, err := parseYAMLNode(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if node.inline() {
+		buf.WriteString(node.Scalar + "\n")
+	} else {
+		for _, line := range node.lines() {
+			buf.WriteString(line + "\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func parseYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		node := &yamlNode{IsMap: tok == '{', IsList: tok == '['}
+		for dec.More() {
+			if node.IsMap {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Keys = append(node.Keys, fmt.Sprint(key))
+			}
+
+			value, err := parseYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Values = append(node.Values, value)
+		}
+
+		/* Consume the closing delimiter */
+		_, err := dec.Token()
+		return node, err
+
+	case string:
+		quoted, _ := json.Marshal(tok)
+		return &yamlNode{Scalar: string(quoted)}, nil
+
+	case nil:
+		return &yamlNode{Scalar: "null"}, nil
+
+	default:
+		return &yamlNode{Scalar: fmt.Sprint(tok)}, nil
+	}
+}
+
+/* inline returns true if the node is written on the same line as its key */
+func (n *yamlNode) inline() bool {
+	switch {
+	case n.IsMap && len(n.Values) == 0:
+		n.Scalar = "{}"
+	case n.IsList && len(n.Values) == 0:
+		n.Scalar = "[]"
+	case n.IsMap || n.IsList:
+		return false
+	}
+	return true
+}
+
+/* lines renders a map or list node as block style lines, without any leading indentation */
+func (n *yamlNode) lines() []string {
+	lines := make([]string, 0)
+	for i, value := range n.Values {
+		var prefix string
+		if n.IsMap {
+			key := n.Keys[i]
+			if !plainKey.MatchString(key) || reservedKey.MatchString(key) {
+				quoted, _ := json.Marshal(key)
+				key = string(quoted)
+			}
+			prefix = key + ":"
+		} else {
+			prefix = "-"
+		}
+
+		if value.inline() {
+			lines = append(lines, prefix+" "+value.Scalar)
+			continue
+		}
+
+		child := value.lines()
+		switch {
+		case n.IsList && value.IsMap:
+			/* The first key of a map in a list shares the line with its dash */
+			lines = append(lines, "- "+child[0])
+			child = child[1:]
+		default:
+			lines = append(lines, prefix)
+		}
+
+		for _, line := range child {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return lines
+}
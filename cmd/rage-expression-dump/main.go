@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/expression"
+)
+
+func main() {
+	var disasm = flag.Bool("disasm", false, "Print a listing of each expression stream instead of JSON")
+	var eval = flag.Bool("eval", false, "Evaluate each expression against the rest pose and print the resulting tracks")
+	flag.Parse()
+
+	log.SetFlags(0)
+	resource.SetArch(resource.ArchPC)
+	jenkins.ReadIndexFromEnv()
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: rage-expression-dump [-disasm] [-eval] file.yed...")
+	}
+
+	for _, inFile := range flag.Args() {
+		dict := unpackDictionary(inFile)
+
+		switch {
+		case *disasm:
+			for _, expr := range dict.Expressions {
+				fmt.Printf("expression %v %v\n", expr.Hash, expr.Name)
+				for _, stream := range expr.Streams {
+					fmt.Printf("stream %v:\n%v\n", stream.Header.Hash, stream.Disassemble())
+				}
+			}
+		case *eval:
+			for _, expr := range dict.Expressions {
+				pose := make(expression.Pose)
+				if err := expr.Evaluate(pose); err != nil {
+					log.Printf("%v: %v\n", expr.Hash, err)
+				}
+
+				fmt.Printf("expression %v %v\n", expr.Hash, expr.Name)
+				for track, value := range pose {
+					fmt.Printf("\t%v %v: %v\n", track.Name(), track.Track, value)
+				}
+			}
+		default:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "\t")
+			if err := enc.Encode(dict); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+func unpackDictionary(inFile string) *expression.Dictionary {
+	data, err := ioutil.ReadFile(inFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, filepath.Base(inFile), uint32(len(data))); err != nil {
+		log.Fatal(err)
+	}
+
+	dict := new(expression.Dictionary)
+	if err = dict.Unpack(res); err != nil {
+		log.Fatal(err)
+	}
+	return dict
+}
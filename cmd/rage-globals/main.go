@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+var (
+	jsonOut   = flag.String("json", "", "Write the globals table as json to this file")
+	headerOut = flag.String("header", "", "Write a C header declaring each global to this file")
+)
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: rage-globals [-json file] [-header file] <ysc|xsc|dir>...")
+	}
+
+	table := script.NewGlobalTable()
+	for _, p := range flag.Args() {
+		filepath.Walk(p, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() {
+				return nil
+			}
+
+			if format, ok := resource.FormatByExtension(file); !ok || format.Name != "script" {
+				return nil
+			}
+
+			if err := scanScript(table, file); err != nil {
+				log.Printf("%v: %v\n", file, err)
+			}
+			return nil
+		})
+	}
+
+	log.Printf("Found %v globals in %v scripts\n", len(table.Globals), len(table.Scripts))
+
+	if *jsonOut == "" && *headerOut == "" {
+		if err := table.WriteJSON(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *jsonOut != "" {
+		writeFile(*jsonOut, table.WriteJSON)
+	}
+
+	if *headerOut != "" {
+		writeFile(*headerOut, table.WriteHeader)
+	}
+}
+
+func scanScript(table *script.GlobalTable, file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	format, _ := resource.FormatByExtension(file)
+	resource.SetArch(format.Arch)
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, path.Base(file), uint32(len(data))); err != nil {
+		return err
+	}
+
+	code := make([]script.Instruction, 0)
+	outScript := script.NewScript(path.Base(file), uint32(len(data)))
+	if err = outScript.Unpack(res, func(istr script.Instruction) {
+		code = append(code, istr)
+	}); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(path.Base(file), path.Ext(file))
+	table.Scan(name, code)
+	return nil
+}
+
+func writeFile(file string, write func(w io.Writer) error) {
+	out, err := os.Create(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+
+	if err = write(out); err != nil {
+		log.Fatal(err)
+	}
+}
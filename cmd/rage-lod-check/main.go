@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/dae"
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/gltf"
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/item"
+	"github.com/tgascoigne/ragekit/resource/item/lod"
+)
+
+var (
+	printTree  = flag.Bool("tree", false, "Print the LOD hierarchy")
+	extents    = flag.String("extents", "", "Export the map extents as boxes to the given basename")
+	outputGltf = flag.Bool("gltf", false, "Export extents to glTF instead of DAE")
+)
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	if flag.NArg() == 0 {
+		log.Fatal("Usage: rage-lod-check [-tree] [-extents name [-gltf]] <ymap|dir>...")
+	}
+
+	jenkins.ReadIndexFromEnv()
+
+	/* Maps are only found in PC resources */
+	resource.SetArch(resource.ArchPC)
+
+	tree := lod.NewTree()
+	for _, arg := range flag.Args() {
+		filepath.Walk(arg, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() || strings.ToLower(filepath.Ext(file)) != ".ymap" {
+				return nil
+			}
+
+			if err := addMap(tree, file); err != nil {
+				log.Printf("Unable to read %v: %v\n", file, err)
+			}
+			return nil
+		})
+	}
+
+	problems := tree.Link()
+	problems = append(problems, tree.Validate()...)
+
+	if *printTree {
+		tree.Walk(func(node *lod.Node, depth int) {
+			fmt.Printf("%v%v\n", strings.Repeat("  ", depth), node)
+		})
+	}
+
+	counts := make(map[lod.ProblemKind]int)
+	for _, problem := range problems {
+		fmt.Println(problem)
+		counts[problem.Kind]++
+	}
+
+	log.Printf("%v maps, %v entities, %v problems\n", len(tree.Maps), len(tree.Nodes()), len(problems))
+	for kind := lod.ProblemInvalidParent; kind <= lod.ProblemExtents; kind++ {
+		if counts[kind] > 0 {
+			log.Printf("\t%v: %v\n", kind, counts[kind])
+		}
+	}
+
+	if *extents != "" {
+		var err error
+		if *outputGltf {
+			err = gltf.ExportScene(tree.Export(*extents))
+		} else {
+			err = dae.ExportScene(tree.Export(*extents))
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func addMap(tree *lod.Tree, file string) error {
+	def, err := item.Open(file)
+	if err != nil {
+		return err
+	}
+
+	return tree.AddMap(file, def)
+}
@@ -6,7 +6,6 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
@@ -16,6 +15,8 @@ import (
 )
 
 var batch = flag.Bool("batch", false, "batch conversion")
+var xmlOut = flag.Bool("xml", false, "export CodeWalker compatible xml instead of json")
+var template = flag.String("template", "", "ymap/ytyp (or json dump) providing the schema when importing xml")
 
 func main() {
 	flag.Parse()
@@ -35,10 +36,16 @@ func main() {
 			}
 
 			basename := filepath.Base(path)
-			outpath := uniquePath(out_file, basename, "json")
+			ext := "json"
+			if *xmlOut {
+				ext = "xml"
+			}
+			outpath := uniquePath(out_file, basename, ext)
 			doExport(path, outpath)
 			return nil
 		})
+	} else if strings.HasSuffix(in_file, ".json") || strings.HasSuffix(in_file, ".xml") {
+		doImport(in_file, out_file)
 	} else {
 		doExport(in_file, out_file)
 	}
@@ -54,9 +61,6 @@ func uniquePath(dir, base, ext string) string {
 }
 
 func doExport(in_file, out_file string) {
-	var data []byte
-	var err error
-
 	if *batch {
 		defer func() {
 			if err := recover(); err != nil {
@@ -67,30 +71,88 @@ func doExport(in_file, out_file string) {
 
 	log.Printf("Exporting %v\n", in_file)
 
-	if data, err = ioutil.ReadFile(in_file); err != nil {
-		log.Fatal(err)
-	}
-
 	/* Set the architecture */
 	resource.SetArch(resource.ArchPC)
 
-	/* Unpack the container */
-	res := new(resource.Container)
-	if err = res.Unpack(data, path.Base(in_file), uint32(len(data))); err != nil {
+	ytyp, err := item.Open(in_file)
+	if err != nil {
 		log.Print(err)
 		return
 	}
 
-	/* Unpack the map at 0x10 */
-	ytyp := item.NewDefinition(in_file)
+	if *xmlOut {
+		err = exportXML(ytyp, out_file)
+	} else {
+		err = ytyp.Dump(out_file)
+	}
 
-	if err = ytyp.Unpack(res); err != nil {
+	if err != nil {
 		log.Print(err)
 		return
 	}
+}
 
-	if err = ytyp.Dump(out_file); err != nil {
-		log.Print(err)
-		return
+func exportXML(def *item.ItemDefinition, out_file string) error {
+	fd, err := os.Create(out_file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	log.Printf("Writing %v\n", out_file)
+	return def.ExportXML(fd)
+}
+
+func doImport(in_file, out_file string) {
+	log.Printf("Importing %v\n", in_file)
+
+	/* Set the architecture */
+	resource.SetArch(resource.ArchPC)
+
+	var def *item.ItemDefinition
+	var err error
+
+	if strings.HasSuffix(in_file, ".xml") {
+		def, err = importXML(in_file)
+	} else {
+		def, err = item.Load(in_file)
+	}
+
+	if err != nil {
+		log.Fatal(err)
 	}
+
+	data, err := def.Pack()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Writing %v\n", out_file)
+	if err = ioutil.WriteFile(out_file, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func importXML(in_file string) (*item.ItemDefinition, error) {
+	if *template == "" {
+		return nil, fmt.Errorf("importing xml requires a -template to provide the schema")
+	}
+
+	resource.SetArch(resource.ArchPC)
+	def, err := item.Open(*template)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := os.Open(in_file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	if err = def.ImportXML(fd); err != nil {
+		return nil, err
+	}
+
+	return def, nil
 }
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"github.com/Jragonmiris/mathgl"
+)
+
+type TrackTarget int
+
+const (
+	TargetTranslation TrackTarget = iota
+	TargetRotation
+	TargetScale
+)
+
+// AnimationTrack is a sampled transform of a single bone.
+// Rotations are stored as XYZW quaternions, translations and scales in XYZ
+type AnimationTrack struct {
+	Bone   string
+	Tag    uint16
+	Target TrackTarget
+	Times  []float32
+	Values []mathgl.Vec4f
+}
+
+type Animation struct {
+	Name      string
+	Duration  float32
+	FrameTime float32
+	Tracks    []*AnimationTrack
+}
+
+func NewAnimation(name string) *Animation {
+	return &Animation{
+		Name:   name,
+		Tracks: make([]*AnimationTrack, 0),
+	}
+}
+
+func (anim *Animation) AddTrack(track *AnimationTrack) {
+	anim.Tracks = append(anim.Tracks, track)
+}
+
+/* Bones returns the names of each animated bone, in the order they first appear */
+func (anim *Animation) Bones() []string {
+	seen := make(map[string]bool)
+	bones := make([]string, 0)
+	for _, track := range anim.Tracks {
+		if !seen[track.Bone] {
+			seen[track.Bone] = true
+			bones = append(bones, track.Bone)
+		}
+	}
+	return bones
+}
+
+/* Track returns the track animating target on bone, or nil */
+func (anim *Animation) Track(bone string, target TrackTarget) *AnimationTrack {
+	for _, track := range anim.Tracks {
+		if track.Bone == bone && track.Target == target {
+			return track
+		}
+	}
+	return nil
+}
+
+/* Frames returns the number of samples in the longest track */
+func (anim *Animation) Frames() int {
+	frames := 0
+	for _, track := range anim.Tracks {
+		if len(track.Values) > frames {
+			frames = len(track.Values)
+		}
+	}
+	return frames
+}
@@ -0,0 +1,91 @@
+package bvh
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+)
+
+// ExportAnimation writes the animation as a flat BVH hierarchy, with each animated bone parented to the root.
+// BVH files carry no rest pose, so the channels hold the bone's local transform directly
+func ExportAnimation(anim *export.Animation) error {
+	fmt.Printf("Exporting %v.bvh", anim.Name)
+
+	file, err := os.Create(fmt.Sprintf("%v.bvh", anim.Name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	defer out.Flush()
+
+	bones := anim.Bones()
+
+	fmt.Fprintf(out, "HIERARCHY\n")
+	fmt.Fprintf(out, "ROOT root\n{\n")
+	fmt.Fprintf(out, "\tOFFSET 0.0 0.0 0.0\n")
+	fmt.Fprintf(out, "\tCHANNELS 0\n")
+	for _, bone := range bones {
+		fmt.Fprintf(out, "\tJOINT %v\n\t{\n", bone)
+		fmt.Fprintf(out, "\t\tOFFSET 0.0 0.0 0.0\n")
+		fmt.Fprintf(out, "\t\tCHANNELS 6 Xposition Yposition Zposition Zrotation Xrotation Yrotation\n")
+		fmt.Fprintf(out, "\t\tEnd Site\n\t\t{\n\t\t\tOFFSET 0.0 0.0 0.0\n\t\t}\n")
+		fmt.Fprintf(out, "\t}\n")
+	}
+	fmt.Fprintf(out, "}\n")
+
+	frames := anim.Frames()
+	fmt.Fprintf(out, "MOTION\n")
+	fmt.Fprintf(out, "Frames: %v\n", frames)
+	fmt.Fprintf(out, "Frame Time: %v\n", anim.FrameTime)
+
+	for frame := 0; frame < frames; frame++ {
+		for i, bone := range bones {
+			if i > 0 {
+				fmt.Fprintf(out, " ")
+			}
+
+			pos := sample(anim.Track(bone, export.TargetTranslation), frame, mathgl.Vec4f{})
+			rot := sample(anim.Track(bone, export.TargetRotation), frame, mathgl.Vec4f{0, 0, 0, 1})
+			z, x, y := quatToEulerZXY(rot)
+			fmt.Fprintf(out, "%f %f %f %f %f %f", pos[0], pos[1], pos[2], z, x, y)
+		}
+		fmt.Fprintf(out, "\n")
+	}
+
+	return nil
+}
+
+func sample(track *export.AnimationTrack, frame int, def mathgl.Vec4f) mathgl.Vec4f {
+	if track == nil || len(track.Values) == 0 {
+		return def
+	}
+	if frame >= len(track.Values) {
+		frame = len(track.Values) - 1
+	}
+	return track.Values[frame]
+}
+
+/* quatToEulerZXY decomposes an XYZW quaternion into ZXY euler angles in degrees */
+func quatToEulerZXY(q mathgl.Vec4f) (float64, float64, float64) {
+	x, y, z, w := float64(q[0]), float64(q[1]), float64(q[2]), float64(q[3])
+
+	r01 := 2 * (x*y - z*w)
+	r11 := 1 - 2*(x*x+z*z)
+	r20 := 2 * (x*z - y*w)
+	r21 := 2 * (y*z + x*w)
+	r22 := 1 - 2*(x*x+y*y)
+
+	rx := math.Asin(math.Max(-1, math.Min(1, r21)))
+	ry := math.Atan2(-r20, r22)
+	rz := math.Atan2(-r01, r11)
+
+	deg := 180 / math.Pi
+	return rz * deg, rx * deg, ry * deg
+}
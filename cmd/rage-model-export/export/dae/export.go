@@ -38,9 +38,8 @@ func addChild(node *xmlx.Node, name string, attribs Attribs, contents string) *x
 	return child
 }
 
-func Export(object export.Exportable) error {
-	outFile := fmt.Sprintf("%v.dae", object.GetName())
-	ctx := Context{
+func newContext(name string) *Context {
+	ctx := &Context{
 		Object:   xmlx.New(),
 		imageIds: make(map[string]string),
 	}
@@ -62,17 +61,24 @@ func Export(object export.Exportable) error {
 
 	ctx.libScenes = addChild(collada, "library_visual_scenes", nil, "")
 	ctx.scene = addChild(ctx.libScenes, "visual_scene", Attribs{"id": "Scene",
-		"name": object.GetName()}, "")
+		"name": name}, "")
 
 	scenes := addChild(collada, "scene", nil, "")
 	_ = addChild(scenes, "instance_visual_scene", Attribs{"url": "#Scene"}, "")
 
+	return ctx
+}
+
+func Export(object export.Exportable) error {
+	outFile := fmt.Sprintf("%v.dae", object.GetName())
+	ctx := newContext(object.GetName())
+
 	for _, model := range object.GetModels() {
 		var err error
-		if err = ExportMaterials(&ctx, model); err != nil {
+		if err = ExportMaterials(ctx, model); err != nil {
 			return err
 		}
-		if err = ExportGeometries(&ctx, model); err != nil {
+		if err = ExportGeometries(ctx, model); err != nil {
 			return err
 		}
 	}
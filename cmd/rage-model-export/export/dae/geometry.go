@@ -8,15 +8,53 @@ import (
 	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
 )
 
+/* geometryRef is a geometry written to the library, along with the material it should be bound to */
+type geometryRef struct {
+	geometry       *xmlx.Node
+	materialInstId string
+	materialId     string
+}
+
 func ExportGeometries(ctx *Context, model *export.Model) error {
-	root := ctx.libGeometries
 	modelName := ctx.Unique(model.Name)
-	materialIds := model.Extra.([]string)
 	sceneNode := addChild(ctx.scene, "node", Attribs{
 		"id":   modelName,
 		"name": modelName,
 	}, "")
 
+	geometries, err := writeGeometries(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	instanceGeometries(sceneNode, geometries)
+	return nil
+}
+
+/* instanceGeometries adds an instance of each geometry to a scene node */
+func instanceGeometries(sceneNode *xmlx.Node, geometries []geometryRef) {
+	for _, geom := range geometries {
+		ref := fmt.Sprintf("#%v", geom.geometry.As("", "id"))
+
+		// <instance_geometry>
+		geomInst := addChild(sceneNode, "instance_geometry", Attribs{"url": ref}, "")
+		if geom.materialId == "" {
+			continue
+		}
+
+		bindMaterial := addChild(geomInst, "bind_material", nil, "")
+		technique := addChild(bindMaterial, "technique_common", nil, "")
+		materialInst := addChild(technique, "instance_material", Attribs{"symbol": geom.materialInstId, "target": fmt.Sprintf("#%v", geom.materialId)}, "")
+		_ = addChild(materialInst, "bind_vertex_input", Attribs{"semantic": "UV0", "input_semantic": "TEXCOORD"}, "")
+	}
+}
+
+/* writeGeometries adds each mesh of the model to the geometry library */
+func writeGeometries(ctx *Context, model *export.Model) ([]geometryRef, error) {
+	root := ctx.libGeometries
+	materialIds := model.Extra.([]string)
+	geometries := make([]geometryRef, 0)
+
 	nodeId := func(n *xmlx.Node) string {
 		return n.As("", "id")
 	}
@@ -111,25 +149,51 @@ func ExportGeometries(ctx *Context, model *export.Model) error {
 		vertices := addChild(mesh, "vertices", Attribs{"id": subType(meshName, "vertices")}, "")
 		_ = addChild(vertices, "input", Attribs{"semantic": "POSITION", "source": ref(posSource)}, "")
 
-		// <triangles>
+		// <linestrips>
+		if len(objMesh.Lines) > 0 {
+			linestrips := addChild(mesh, "linestrips", Attribs{"count": len(objMesh.Lines)}, "")
+			_ = addChild(linestrips, "input", Attribs{"offset": 0, "semantic": "VERTEX",
+				"source": ref(vertices)}, "")
+			for _, line := range objMesh.Lines {
+				var lineBuf bytes.Buffer
+				for _, idx := range line {
+					lineBuf.WriteString(fmt.Sprintf("%v ", idx))
+				}
+				_ = addChild(linestrips, "p", nil, lineBuf.String())
+			}
+		}
+
+		addTriangles := func(attribs Attribs) {
+			// <triangles>
+			triangles := addChild(mesh, "triangles", attribs, "")
+			_ = addChild(triangles, "input", Attribs{"offset": 0, "semantic": "VERTEX",
+				"source": ref(vertices)}, "")
+			_ = addChild(triangles, "input", Attribs{"offset": 1, "semantic": "TEXCOORD",
+				"source": ref(uvSource)}, "")
+			_ = addChild(triangles, "input", Attribs{"offset": 2, "semantic": "COLOR",
+				"source": ref(colourSource)}, "")
+			_ = addChild(triangles, "p", nil, faceBuf.String())
+		}
+
+		if objMesh.Material == -1 {
+			if len(objMesh.Faces) > 0 {
+				addTriangles(Attribs{"count": len(objMesh.Faces)})
+			}
+
+			geometries = append(geometries, geometryRef{geometry: geometry})
+			continue
+		}
+
 		materialId := materialIds[objMesh.Material]
 		materialInstId := subType(meshName, "material")
-		triangles := addChild(mesh, "triangles", Attribs{"count": len(objMesh.Faces), "material": materialInstId}, "")
-		_ = addChild(triangles, "input", Attribs{"offset": 0, "semantic": "VERTEX",
-			"source": ref(vertices)}, "")
-		_ = addChild(triangles, "input", Attribs{"offset": 1, "semantic": "TEXCOORD",
-			"source": ref(uvSource)}, "")
-		_ = addChild(triangles, "input", Attribs{"offset": 2, "semantic": "COLOR",
-			"source": ref(colourSource)}, "")
-		_ = addChild(triangles, "p", nil, faceBuf.String())
+		addTriangles(Attribs{"count": len(objMesh.Faces), "material": materialInstId})
 
-		// <instance_geometry>
-		geomInst := addChild(sceneNode, "instance_geometry", Attribs{"url": ref(geometry)}, "")
-		bindMaterial := addChild(geomInst, "bind_material", nil, "")
-		technique := addChild(bindMaterial, "technique_common", nil, "")
-		materialInst := addChild(technique, "instance_material", Attribs{"symbol": materialInstId, "target": fmt.Sprintf("#%v", materialId)}, "")
-		_ = addChild(materialInst, "bind_vertex_input", Attribs{"semantic": "UV0", "input_semantic": "TEXCOORD"}, "")
+		geometries = append(geometries, geometryRef{
+			geometry:       geometry,
+			materialInstId: materialInstId,
+			materialId:     materialId,
+		})
 	}
 
-	return nil
+	return geometries, nil
 }
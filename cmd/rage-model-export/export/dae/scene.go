@@ -0,0 +1,51 @@
+package dae
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+)
+
+/* ExportScene writes each model of the scene to the geometry library once, and instances it at each placement */
+func ExportScene(scene *export.Scene) error {
+	outFile := fmt.Sprintf("%v.dae", scene.Name)
+	ctx := newContext(scene.Name)
+
+	geometries := make([][]geometryRef, len(scene.Models))
+	for i, model := range scene.Models {
+		var err error
+		if err = ExportMaterials(ctx, model); err != nil {
+			return err
+		}
+		if geometries[i], err = writeGeometries(ctx, model); err != nil {
+			return err
+		}
+	}
+
+	for _, inst := range scene.Instances {
+		nodeName := ctx.Unique(inst.Name)
+		sceneNode := addChild(ctx.scene, "node", Attribs{
+			"id":   nodeName,
+			"name": nodeName,
+		}, "")
+
+		/* Collada matrices are row major */
+		var matrixBuf bytes.Buffer
+		matrix := inst.Matrix()
+		for row := 0; row < 4; row++ {
+			for col := 0; col < 4; col++ {
+				matrixBuf.WriteString(fmt.Sprintf("%v ", matrix[col*4+row]))
+			}
+		}
+
+		_ = addChild(sceneNode, "matrix", Attribs{"sid": "transform"}, matrixBuf.String())
+		instanceGeometries(sceneNode, geometries[inst.Model])
+	}
+
+	if err := ctx.Object.SaveFile(outFile); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package gltf
+
+import (
+	"fmt"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+)
+
+/* ExportAnimation writes the animation to a glTF file, with each animated bone as a child of a root node */
+func ExportAnimation(anim *export.Animation) error {
+	doc := NewDocument()
+	doc.AddAnimation(anim)
+	return doc.Save(fmt.Sprintf("%v.gltf", anim.Name))
+}
+
+/* AddAnimation adds the animation, creating a node for each bone */
+func (doc *Document) AddAnimation(anim *export.Animation) {
+	root := doc.AddRootNode(Node{Name: anim.Name})
+
+	nodes := make(map[string]int)
+	for _, bone := range anim.Bones() {
+		nodes[bone] = doc.AddNode(Node{Name: bone})
+		doc.Nodes[root].Children = append(doc.Nodes[root].Children, nodes[bone])
+	}
+
+	result := Animation{
+		Name:     anim.Name,
+		Samplers: make([]AnimationSampler, 0),
+		Channels: make([]AnimationChannel, 0),
+	}
+
+	for _, track := range anim.Tracks {
+		if len(track.Times) == 0 {
+			continue
+		}
+
+		var path, typ string
+		var components int
+		switch track.Target {
+		case export.TargetTranslation:
+			path, typ, components = "translation", "VEC3", 3
+		case export.TargetRotation:
+			path, typ, components = "rotation", "VEC4", 4
+		case export.TargetScale:
+			path, typ, components = "scale", "VEC3", 3
+		default:
+			continue
+		}
+
+		values := make([]float32, 0, len(track.Values)*components)
+		for _, v := range track.Values {
+			values = append(values, v[:components]...)
+		}
+
+		input := doc.AddFloats(track.Times, "SCALAR", 1, 0)
+		output := doc.AddFloats(values, typ, components, 0)
+
+		result.Samplers = append(result.Samplers, AnimationSampler{
+			Input:         input,
+			Output:        output,
+			Interpolation: "LINEAR",
+		})
+		result.Channels = append(result.Channels, AnimationChannel{
+			Sampler: len(result.Samplers) - 1,
+			Target: AnimationTarget{
+				Node: nodes[track.Bone],
+				Path: path,
+			},
+		})
+	}
+
+	if len(result.Channels) > 0 {
+		doc.Animations = append(doc.Animations, result)
+	}
+}
@@ -0,0 +1,205 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	componentUnsignedShort = 5123
+	componentFloat         = 5126
+
+	targetArrayBuffer        = 34962
+	targetElementArrayBuffer = 34963
+
+	modeLineStrip = 3
+	modeTriangles = 4
+)
+
+type Asset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type Scene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes"`
+}
+
+type Node struct {
+	Name        string      `json:"name,omitempty"`
+	Children    []int       `json:"children,omitempty"`
+	Mesh        *int        `json:"mesh,omitempty"`
+	Translation []float32   `json:"translation,omitempty"`
+	Rotation    []float32   `json:"rotation,omitempty"`
+	Scale       []float32   `json:"scale,omitempty"`
+	Matrix      []float32   `json:"matrix,omitempty"`
+	Extras      interface{} `json:"extras,omitempty"`
+}
+
+type Buffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri"`
+}
+
+type BufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type Accessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type AnimationSampler struct {
+	Input         int    `json:"input"`
+	Output        int    `json:"output"`
+	Interpolation string `json:"interpolation"`
+}
+
+type AnimationTarget struct {
+	Node int    `json:"node"`
+	Path string `json:"path"`
+}
+
+type AnimationChannel struct {
+	Sampler int             `json:"sampler"`
+	Target  AnimationTarget `json:"target"`
+}
+
+type Animation struct {
+	Name     string             `json:"name,omitempty"`
+	Samplers []AnimationSampler `json:"samplers"`
+	Channels []AnimationChannel `json:"channels"`
+}
+
+/* Document is a glTF 2.0 asset, with all binary data embedded in a single buffer */
+type Document struct {
+	Asset       Asset        `json:"asset"`
+	Scene       int          `json:"scene"`
+	Scenes      []Scene      `json:"scenes"`
+	Nodes       []Node       `json:"nodes"`
+	Meshes      []Mesh       `json:"meshes,omitempty"`
+	Materials   []Material   `json:"materials,omitempty"`
+	Textures    []Texture    `json:"textures,omitempty"`
+	Images      []Image      `json:"images,omitempty"`
+	Animations  []Animation  `json:"animations,omitempty"`
+	Accessors   []Accessor   `json:"accessors,omitempty"`
+	BufferViews []BufferView `json:"bufferViews,omitempty"`
+	Buffers     []Buffer     `json:"buffers,omitempty"`
+
+	data   bytes.Buffer
+	images map[string]int
+}
+
+func NewDocument() *Document {
+	return &Document{
+		Asset: Asset{
+			Version:   "2.0",
+			Generator: "ragekit",
+		},
+		Scenes: []Scene{{Nodes: []int{}}},
+		Nodes:  make([]Node, 0),
+		images: make(map[string]int),
+	}
+}
+
+/* AddNode appends a node and returns its index */
+func (doc *Document) AddNode(node Node) int {
+	doc.Nodes = append(doc.Nodes, node)
+	return len(doc.Nodes) - 1
+}
+
+/* AddRootNode appends a node to the default scene and returns its index */
+func (doc *Document) AddRootNode(node Node) int {
+	idx := doc.AddNode(node)
+	doc.Scenes[0].Nodes = append(doc.Scenes[0].Nodes, idx)
+	return idx
+}
+
+func (doc *Document) addBufferView(data interface{}, target int) int {
+	/* Accessor offsets must be aligned to their component size */
+	for doc.data.Len()%4 != 0 {
+		doc.data.WriteByte(0)
+	}
+
+	offset := doc.data.Len()
+	binary.Write(&doc.data, binary.LittleEndian, data)
+
+	doc.BufferViews = append(doc.BufferViews, BufferView{
+		Buffer:     0,
+		ByteOffset: offset,
+		ByteLength: doc.data.Len() - offset,
+		Target:     target,
+	})
+	return len(doc.BufferViews) - 1
+}
+
+/* AddFloats stores a float accessor of the given type (SCALAR, VEC3, ...) and returns its index */
+func (doc *Document) AddFloats(values []float32, typ string, components int, target int) int {
+	view := doc.addBufferView(values, target)
+
+	min := make([]float32, components)
+	max := make([]float32, components)
+	for i, v := range values {
+		c := i % components
+		if i < components || v < min[c] {
+			min[c] = v
+		}
+		if i < components || v > max[c] {
+			max[c] = v
+		}
+	}
+
+	doc.Accessors = append(doc.Accessors, Accessor{
+		BufferView:    view,
+		ComponentType: componentFloat,
+		Count:         len(values) / components,
+		Type:          typ,
+		Min:           min,
+		Max:           max,
+	})
+	return len(doc.Accessors) - 1
+}
+
+/* AddIndices stores an unsigned short index accessor and returns its index */
+func (doc *Document) AddIndices(values []uint16) int {
+	view := doc.addBufferView(values, targetElementArrayBuffer)
+
+	doc.Accessors = append(doc.Accessors, Accessor{
+		BufferView:    view,
+		ComponentType: componentUnsignedShort,
+		Count:         len(values),
+		Type:          "SCALAR",
+	})
+	return len(doc.Accessors) - 1
+}
+
+func (doc *Document) Save(fileName string) error {
+	fmt.Printf("Exporting %v", fileName)
+
+	if doc.data.Len() > 0 {
+		doc.Buffers = []Buffer{{
+			ByteLength: doc.data.Len(),
+			URI:        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(doc.data.Bytes()),
+		}}
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fileName, data, 0644)
+}
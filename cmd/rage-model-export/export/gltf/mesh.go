@@ -0,0 +1,120 @@
+package gltf
+
+import (
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+)
+
+type Primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       int            `json:"mode"`
+}
+
+type Mesh struct {
+	Name       string      `json:"name,omitempty"`
+	Primitives []Primitive `json:"primitives"`
+}
+
+type TextureInfo struct {
+	Index int `json:"index"`
+}
+
+type PbrMetallicRoughness struct {
+	BaseColorTexture *TextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float32      `json:"metallicFactor"`
+}
+
+type Material struct {
+	Name                 string               `json:"name,omitempty"`
+	PbrMetallicRoughness PbrMetallicRoughness `json:"pbrMetallicRoughness"`
+}
+
+type Texture struct {
+	Source int `json:"source"`
+}
+
+type Image struct {
+	URI string `json:"uri"`
+}
+
+/* addImage returns the texture for an image path, adding it if it doesn't exist */
+func (doc *Document) addImage(path string) int {
+	if idx, ok := doc.images[path]; ok {
+		return idx
+	}
+
+	doc.Images = append(doc.Images, Image{URI: path})
+	doc.Textures = append(doc.Textures, Texture{Source: len(doc.Images) - 1})
+	doc.images[path] = len(doc.Textures) - 1
+	return doc.images[path]
+}
+
+/* AddModel adds each mesh of the model as a primitive of a single glTF mesh, and returns its index */
+func (doc *Document) AddModel(model *export.Model) int {
+	materials := make([]int, len(model.Materials))
+	for i, material := range model.Materials {
+		result := Material{Name: material.DiffBitmap}
+		if material.DiffBitmap != "" {
+			result.PbrMetallicRoughness.BaseColorTexture = &TextureInfo{Index: doc.addImage(material.DiffBitmap)}
+		}
+
+		doc.Materials = append(doc.Materials, result)
+		materials[i] = len(doc.Materials) - 1
+	}
+
+	result := Mesh{
+		Name:       model.Name,
+		Primitives: make([]Primitive, 0),
+	}
+
+	for _, mesh := range model.Meshes {
+		if len(mesh.Vertices) == 0 {
+			continue
+		}
+
+		positions := make([]float32, 0, len(mesh.Vertices)*3)
+		uvs := make([]float32, 0, len(mesh.Vertices)*2)
+		for _, vert := range mesh.Vertices {
+			positions = append(positions, vert.Pos[0], vert.Pos[1], vert.Pos[2])
+			uvs = append(uvs, vert.UV[0], vert.UV[1])
+		}
+
+		attributes := map[string]int{
+			"POSITION":   doc.AddFloats(positions, "VEC3", 3, targetArrayBuffer),
+			"TEXCOORD_0": doc.AddFloats(uvs, "VEC2", 2, targetArrayBuffer),
+		}
+
+		if len(mesh.Faces) > 0 {
+			indices := make([]uint16, 0, len(mesh.Faces)*3)
+			for _, face := range mesh.Faces {
+				indices = append(indices, face.A, face.B, face.C)
+			}
+
+			primitive := Primitive{
+				Attributes: attributes,
+				Mode:       modeTriangles,
+			}
+
+			idx := doc.AddIndices(indices)
+			primitive.Indices = &idx
+
+			if mesh.Material >= 0 && mesh.Material < len(materials) {
+				primitive.Material = &materials[mesh.Material]
+			}
+			result.Primitives = append(result.Primitives, primitive)
+		}
+
+		for _, line := range mesh.Lines {
+			idx := doc.AddIndices(line)
+			result.Primitives = append(result.Primitives, Primitive{
+				Attributes: attributes,
+				Indices:    &idx,
+				Mode:       modeLineStrip,
+			})
+		}
+	}
+
+	doc.Meshes = append(doc.Meshes, result)
+	return len(doc.Meshes) - 1
+}
@@ -0,0 +1,35 @@
+package gltf
+
+import (
+	"fmt"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+)
+
+/* ExportScene writes each model of the scene as a mesh, referenced by a node at each placement */
+func ExportScene(scene *export.Scene) error {
+	doc := NewDocument()
+	doc.AddScene(scene)
+	return doc.Save(fmt.Sprintf("%v.gltf", scene.Name))
+}
+
+/* AddScene adds the models of the scene, and a root node for each instance */
+func (doc *Document) AddScene(scene *export.Scene) {
+	doc.Scenes[0].Name = scene.Name
+
+	meshes := make([]int, len(scene.Models))
+	for i, model := range scene.Models {
+		meshes[i] = doc.AddModel(model)
+	}
+
+	for _, inst := range scene.Instances {
+		rotation := inst.Rotation.Normalize()
+		doc.AddRootNode(Node{
+			Name:        inst.Name,
+			Mesh:        &meshes[inst.Model],
+			Translation: []float32{inst.Position[0], inst.Position[1], inst.Position[2]},
+			Rotation:    []float32{rotation.V[0], rotation.V[1], rotation.V[2], rotation.W},
+			Scale:       []float32{inst.Scale[0], inst.Scale[1], inst.Scale[2]},
+		})
+	}
+}
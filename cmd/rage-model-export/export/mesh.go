@@ -14,10 +14,14 @@ type Vertex struct {
 	Colour uint32
 }
 
+/* Polyline is a connected strip of vertex indices */
+type Polyline []uint16
+
 type Mesh struct {
 	Format   VertexFormat
 	Vertices []Vertex
 	Faces    []types.Tri
+	Lines    []Polyline
 	Material int
 }
 
@@ -60,3 +64,36 @@ func (mesh *Mesh) AddFace(face types.Tri) {
 	}
 	mesh.Faces = append(mesh.Faces, face)
 }
+
+func (mesh *Mesh) AddPolyline(line Polyline) {
+	for _, idx := range line {
+		if int(idx) >= len(mesh.Vertices) {
+			panic(fmt.Sprintf("invalid vert reference: %v", line))
+		}
+	}
+	mesh.Lines = append(mesh.Lines, line)
+}
+
+/* AddBoxOutline adds the edges of an axis aligned box as polylines */
+func (mesh *Mesh) AddBoxOutline(min, max mathgl.Vec3f) {
+	base := mesh.Rel(0)
+	for i := 0; i < 8; i++ {
+		corner := min
+		if i&1 != 0 {
+			corner[0] = max[0]
+		}
+		if i&2 != 0 {
+			corner[1] = max[1]
+		}
+		if i&4 != 0 {
+			corner[2] = max[2]
+		}
+		mesh.AddVert4f(mathgl.Vec4f{corner[0], corner[1], corner[2], 1})
+	}
+
+	mesh.AddPolyline(Polyline{base, base + 1, base + 3, base + 2, base})
+	mesh.AddPolyline(Polyline{base + 4, base + 5, base + 7, base + 6, base + 4})
+	for i := uint16(0); i < 4; i++ {
+		mesh.AddPolyline(Polyline{base + i, base + i + 4})
+	}
+}
@@ -42,6 +42,14 @@ func ExportModel(ctx *Context, model *export.Model) error {
 			a, b, c := -int(numVerts-int(face.A)), -int(numVerts-int(face.B)), -int(numVerts-int(face.C))
 			fmt.Fprintf(ctx.ObjFile, "f %v/%v %v/%v %v/%v\n", a, a, b, b, c, c)
 		}
+
+		for _, line := range mesh.Lines {
+			fmt.Fprintf(ctx.ObjFile, "l")
+			for _, idx := range line {
+				fmt.Fprintf(ctx.ObjFile, " %v", -int(numVerts-int(idx)))
+			}
+			fmt.Fprintf(ctx.ObjFile, "\n")
+		}
 	}
 	return nil
 }
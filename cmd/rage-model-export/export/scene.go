@@ -0,0 +1,64 @@
+package export
+
+import (
+	"github.com/Jragonmiris/mathgl"
+)
+
+/* Instance places a model in a scene */
+type Instance struct {
+	Name     string
+	Model    int
+	Position mathgl.Vec3f
+	Rotation mathgl.Quatf
+	Scale    mathgl.Vec3f
+}
+
+/* Matrix returns the transform of the instance, applying scale, then rotation, then translation */
+func (inst *Instance) Matrix() mathgl.Mat4f {
+	translate := mathgl.Translate3D(inst.Position[0], inst.Position[1], inst.Position[2])
+	rotate := inst.Rotation.Normalize().Mat4()
+	scale := mathgl.Scale3D(inst.Scale[0], inst.Scale[1], inst.Scale[2])
+	return translate.Mul4(rotate).Mul4(scale)
+}
+
+/* Scene is a set of models, each of which may be placed any number of times */
+type Scene struct {
+	Name      string
+	Models    []*Model
+	Instances []*Instance
+
+	modelIndex map[*Model]int
+}
+
+func NewScene(name string) *Scene {
+	return &Scene{
+		Name:       name,
+		Models:     make([]*Model, 0),
+		Instances:  make([]*Instance, 0),
+		modelIndex: make(map[*Model]int),
+	}
+}
+
+/* AddModel adds a model to the scene if it isn't already present, and returns its index */
+func (scene *Scene) AddModel(model *Model) int {
+	if idx, ok := scene.modelIndex[model]; ok {
+		return idx
+	}
+
+	scene.Models = append(scene.Models, model)
+	scene.modelIndex[model] = len(scene.Models) - 1
+	return len(scene.Models) - 1
+}
+
+/* AddInstance places a model in the scene */
+func (scene *Scene) AddInstance(inst *Instance) {
+	scene.Instances = append(scene.Instances, inst)
+}
+
+func (scene *Scene) GetName() string {
+	return scene.Name
+}
+
+func (scene *Scene) GetModels() []*Model {
+	return scene.Models
+}
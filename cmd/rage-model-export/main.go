@@ -16,10 +16,11 @@ import (
 	"github.com/tgascoigne/ragekit/resource/dictionary"
 	"github.com/tgascoigne/ragekit/resource/drawable"
 	"github.com/tgascoigne/ragekit/resource/frag"
+	"github.com/tgascoigne/ragekit/resource/vehiclerecord"
 )
 
 var (
-	SupportedExtensions = []string{".xdr", ".xdd", ".xft", ".xbn"}
+	SupportedExtensions = []string{".xdr", ".xdd", ".xft", ".xbn", ".yvr"}
 )
 
 func main() {
@@ -117,53 +118,35 @@ func locateInputFiles(path string) []string {
 }
 
 func processModel(inFile string, object *export.ModelGroup) {
-	var data []byte
-	var err error
-
-	baseName := filepath.Base(inFile)
-
-	/* Read the file */
-	if data, err = ioutil.ReadFile(inFile); err != nil {
-		panic(err)
-	}
-
-	/* Unpack the container */
-	res := new(resource.Container)
-	if err = res.Unpack(data, baseName, uint32(len(data))); err != nil {
+	/* Unpack the resource */
+	value, _, err := resource.Open(inFile)
+	if err != nil {
 		panic(err)
 	}
 
+	baseName := filepath.Base(inFile)
 	baseName = baseName[:strings.LastIndex(baseName, ".")]
 
-	switch {
-	case strings.Contains(filepath.Ext(inFile), "dr"):
-		object.Merge(unpackDrawable(res))
-	case strings.Contains(filepath.Ext(inFile), "dd"):
-		object.Merge(unpackDrawableDictionary(res, baseName))
-	case strings.Contains(filepath.Ext(inFile), "ft"):
-		object.Merge(unpackFragType(res, baseName))
-	case strings.Contains(filepath.Ext(inFile), "bn"):
-		object.Merge(unpackBoundsNodes(res, baseName))
+	switch value := value.(type) {
+	case *drawable.Drawable:
+		object.Merge(value.Model)
+	case *dictionary.Dictionary:
+		object.Merge(exportDrawableDictionary(value, baseName))
+	case *frag.FragType:
+		/* Drawables inside frag files dont seem to be named properly. */
+		value.Drawable.Model.Name = baseName
+		object.Merge(value.Drawable.Model)
+	case *bounds.Nodes:
+		value.Model.Name = baseName
+		object.Merge(value.Model)
+	case *vehiclerecord.Record:
+		object.Merge(value.Export(baseName))
+	default:
+		panic(fmt.Sprintf("%v is not a model", inFile))
 	}
 }
 
-func unpackDrawable(res *resource.Container) export.Exportable {
-	drawable := new(drawable.Drawable)
-	if err := drawable.Unpack(res); err != nil {
-		panic(err)
-	}
-
-	return drawable.Model
-}
-
-func unpackDrawableDictionary(res *resource.Container, title string) export.Exportable {
-	/* Unpack the dictionary */
-	dictionary := new(dictionary.Dictionary)
-	if err := dictionary.Unpack(res); err != nil {
-		panic(err)
-
-	}
-
+func exportDrawableDictionary(dictionary *dictionary.Dictionary, title string) export.Exportable {
 	group := export.NewModelGroup()
 	group.Name = title
 	for _, drawable := range dictionary.Drawables {
@@ -177,29 +160,3 @@ func unpackDrawableDictionary(res *resource.Container, title string) export.Expo
 	}
 	return group
 }
-
-func unpackFragType(res *resource.Container, title string) export.Exportable {
-	/* Unpack the frag type */
-	frag := new(frag.FragType)
-	if err := frag.Unpack(res); err != nil {
-		panic(err)
-	}
-
-	/* Drawables inside frag files dont seem to be named properly. */
-	frag.Drawable.Model.Name = title
-
-	return frag.Drawable.Model
-}
-
-func unpackBoundsNodes(res *resource.Container, title string) export.Exportable {
-	/* Unpack the nodes */
-	nodes := new(bounds.Nodes)
-
-	if err := nodes.Unpack(res); err != nil {
-		panic(err)
-	}
-
-	nodes.Model.Name = title
-
-	return nodes.Model
-}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/dae"
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/gltf"
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/dictionary"
+	"github.com/tgascoigne/ragekit/resource/drawable"
+	"github.com/tgascoigne/ragekit/resource/frag"
+	"github.com/tgascoigne/ragekit/resource/item"
+	"github.com/tgascoigne/ragekit/resource/item/archetype"
+	"github.com/tgascoigne/ragekit/resource/item/mlo"
+)
+
+/* pathList is a flag which may be given more than once */
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathList) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+var (
+	searchDirs  pathList
+	searchRpfs  pathList
+	sceneName   = flag.String("name", "scene", "The basename of the output file")
+	outputGltf  = flag.Bool("gltf", false, "Output to glTF instead of DAE")
+	archetypes  = flag.String("archetypes", "", "An archetype index to resolve drawable dictionaries with")
+	interior    = flag.String("interior", "", "Export the named interior from the given ytyps instead of the maps")
+	entitySets  = flag.String("entitysets", "", "A comma separated list of interior entity sets to include")
+	modelExts   = []string{".ydr", ".ydd", ".yft"}
+	packageExts = ".rpf"
+)
+
+/* modelSource is a model file on disk or inside a package */
+type modelSource struct {
+	Name string
+	Ext  string
+	Load func() ([]byte, error)
+}
+
+type sceneBuilder struct {
+	scene *export.Scene
+
+	/* Model files by the hash of their base name */
+	sources map[jenkins.Jenkins32]*modelSource
+
+	/* Drawable dictionary of each archetype, read from ytyps or the archetype index */
+	dictionaries map[jenkins.Jenkins32]jenkins.Jenkins32
+
+	/* Interiors defined by the ytyps */
+	interiors map[jenkins.Jenkins32]*mlo.Interior
+
+	models       map[jenkins.Jenkins32]*export.Model
+	dictModels   map[jenkins.Jenkins32]map[jenkins.Jenkins32]*export.Model
+	missingNames map[jenkins.Jenkins32]bool
+}
+
+func main() {
+	flag.Var(&searchDirs, "path", "A directory to search for models (may be repeated)")
+	flag.Var(&searchRpfs, "rpf", "A package to search for models (may be repeated)")
+	flag.Parse()
+
+	log.SetFlags(0)
+
+	if flag.NArg() == 0 {
+		log.Fatal("Usage: rage-scene-export [-gltf] [-name scene] [-archetypes index] [-interior name [-entitysets a,b]] [-path dir]... [-rpf file]... <ymap|ytyp>...")
+	}
+
+	jenkins.ReadIndexFromEnv()
+
+	/* Maps and packages are only found in PC resources */
+	resource.SetArch(resource.ArchPC)
+
+	builder := &sceneBuilder{
+		scene:        export.NewScene(*sceneName),
+		sources:      make(map[jenkins.Jenkins32]*modelSource),
+		dictionaries: make(map[jenkins.Jenkins32]jenkins.Jenkins32),
+		interiors:    make(map[jenkins.Jenkins32]*mlo.Interior),
+		models:       make(map[jenkins.Jenkins32]*export.Model),
+		dictModels:   make(map[jenkins.Jenkins32]map[jenkins.Jenkins32]*export.Model),
+		missingNames: make(map[jenkins.Jenkins32]bool),
+	}
+
+	for _, dir := range searchDirs {
+		builder.indexDirectory(dir)
+	}
+
+	for _, rpf := range searchRpfs {
+		data, err := ioutil.ReadFile(rpf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		builder.indexPackage(data, path.Base(rpf))
+	}
+
+	log.Printf("Found %v models\n", len(builder.sources))
+
+	if *archetypes != "" {
+		idx, err := archetype.Load(*archetypes)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for name, entry := range idx.Entries {
+			if entry.DrawableDictionary != 0 {
+				builder.dictionaries[name] = entry.DrawableDictionary
+			}
+		}
+	}
+
+	/* Read the archetypes first, so that the maps can be resolved against them */
+	maps := make([]string, 0)
+	for _, file := range flag.Args() {
+		switch filepath.Ext(file) {
+		case ".ytyp":
+			builder.addArchetypes(file)
+		case ".ymap":
+			maps = append(maps, file)
+		default:
+			log.Printf("Ignoring %v\n", file)
+		}
+	}
+
+	if *interior != "" {
+		builder.exportInterior(*interior)
+	} else {
+		for _, file := range maps {
+			builder.addMap(file)
+		}
+	}
+
+	log.Printf("Placed %v instances of %v models, %v archetypes unresolved\n",
+		len(builder.scene.Instances), len(builder.scene.Models), len(builder.missingNames))
+
+	var err error
+	if *outputGltf {
+		err = gltf.ExportScene(builder.scene)
+	} else {
+		err = dae.ExportScene(builder.scene)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func isModel(name string) (string, string, bool) {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range modelExts {
+		if ext == e {
+			return strings.TrimSuffix(name, filepath.Ext(name)), ext, true
+		}
+	}
+	return "", "", false
+}
+
+func (b *sceneBuilder) addSource(source *modelSource) {
+	hash := archetype.HashName(source.Name)
+	if _, ok := b.sources[hash]; !ok {
+		b.sources[hash] = source
+	}
+}
+
+func (b *sceneBuilder) indexDirectory(dir string) {
+	filepath.Walk(dir, func(file string, f os.FileInfo, err error) error {
+		if err != nil || f.IsDir() {
+			return nil
+		}
+
+		if strings.ToLower(filepath.Ext(file)) == packageExts {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				log.Print(err)
+				return nil
+			}
+			b.indexPackage(data, f.Name())
+			return nil
+		}
+
+		if name, ext, ok := isModel(f.Name()); ok {
+			b.addSource(&modelSource{
+				Name: name,
+				Ext:  ext,
+				Load: func() ([]byte, error) {
+					return ioutil.ReadFile(file)
+				},
+			})
+		}
+		return nil
+	})
+}
+
+func (b *sceneBuilder) indexPackage(data []byte, name string) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("Unable to read %v: %v\n", name, err)
+		}
+	}()
+
+	pkg := new(resource.Package)
+	if err := pkg.Unpack(data, name, uint32(len(data))); err != nil {
+		log.Printf("Unable to read %v: %v\n", name, err)
+		return
+	}
+
+	var walk func(node resource.PackageNode)
+	walk = func(node resource.PackageNode) {
+		switch node := node.(type) {
+		case resource.PackageDirectory:
+			for _, child := range node.Children(pkg) {
+				walk(child)
+			}
+
+		case resource.PackageFile:
+			fileName := node.Name(pkg)
+			if strings.ToLower(filepath.Ext(fileName)) == packageExts {
+				b.indexPackage(node.Data(pkg), fileName)
+				return
+			}
+
+			if name, ext, ok := isModel(fileName); ok {
+				b.addSource(&modelSource{
+					Name: name,
+					Ext:  ext,
+					Load: func() ([]byte, error) {
+						return node.Data(pkg), nil
+					},
+				})
+			}
+		}
+	}
+
+	walk(pkg.Root())
+}
+
+func (b *sceneBuilder) addArchetypes(file string) {
+	def, err := item.Open(file)
+	if err != nil {
+		log.Printf("Unable to read %v: %v\n", file, err)
+		return
+	}
+
+	defs, err := def.Archetypes()
+	if err != nil {
+		log.Printf("Unable to read %v: %v\n", file, err)
+		return
+	}
+
+	for _, archetypeDef := range defs {
+		if archetypeDef.DrawableDictionary != 0 {
+			b.dictionaries[archetypeDef.Name] = archetypeDef.DrawableDictionary
+		}
+	}
+
+	interiors, err := mlo.Interiors(def)
+	if err != nil {
+		log.Printf("Unable to read interiors of %v: %v\n", file, err)
+		return
+	}
+
+	for _, i := range interiors {
+		b.interiors[i.Def.Name] = i
+	}
+}
+
+func (b *sceneBuilder) exportInterior(name string) {
+	interior, ok := b.interiors[archetype.HashName(name)]
+	if !ok {
+		log.Fatalf("Interior %v not found\n", name)
+	}
+
+	sets := make([]jenkins.Jenkins32, 0)
+	if *entitySets != "" {
+		for _, set := range strings.Split(*entitySets, ",") {
+			sets = append(sets, jenkins.Parse(strings.ToLower(set)))
+		}
+	}
+
+	b.scene = interior.Export(b.scene.Name, b.resolve, sets)
+}
+
+func (b *sceneBuilder) addMap(file string) {
+	log.Printf("Placing %v\n", file)
+
+	def, err := item.Open(file)
+	if err != nil {
+		log.Printf("Unable to read %v: %v\n", file, err)
+		return
+	}
+
+	entities, err := def.Entities()
+	if err != nil {
+		log.Printf("Unable to read %v: %v\n", file, err)
+		return
+	}
+
+	for _, entity := range entities {
+		model := b.resolve(entity.ArchetypeName)
+		if model == nil {
+			continue
+		}
+
+		scaleXY, scaleZ := entity.ScaleXY, entity.ScaleZ
+		if scaleXY == 0 {
+			scaleXY = 1
+		}
+		if scaleZ == 0 {
+			scaleZ = 1
+		}
+
+		/* Entity rotations are stored inverted */
+		rotation := mathgl.Quatf{
+			W: entity.Rotation[3],
+			V: mathgl.Vec3f{entity.Rotation[0], entity.Rotation[1], entity.Rotation[2]},
+		}.Conjugate()
+
+		b.scene.AddInstance(&export.Instance{
+			Name:     model.Name,
+			Model:    b.scene.AddModel(model),
+			Position: entity.Position,
+			Rotation: rotation,
+			Scale:    mathgl.Vec3f{scaleXY, scaleXY, scaleZ},
+		})
+	}
+}
+
+/* resolve returns the model for an archetype, or nil if it can't be found */
+func (b *sceneBuilder) resolve(archetype jenkins.Jenkins32) *export.Model {
+	if model, ok := b.models[archetype]; ok {
+		return model
+	}
+
+	var model *export.Model
+	if source, ok := b.sources[archetype]; ok && source.Ext != ".ydd" {
+		model = b.load(source, nil)
+	} else if dict, ok := b.dictionaries[archetype]; ok {
+		if _, ok := b.dictModels[dict]; !ok {
+			b.dictModels[dict] = make(map[jenkins.Jenkins32]*export.Model)
+			if source, ok := b.sources[dict]; ok && source.Ext == ".ydd" {
+				b.load(source, b.dictModels[dict])
+			}
+		}
+		model = b.dictModels[dict][archetype]
+	}
+
+	if model == nil && !b.missingNames[archetype] {
+		log.Printf("Unable to resolve %v\n", archetype)
+		b.missingNames[archetype] = true
+	}
+
+	b.models[archetype] = model
+	return model
+}
+
+/* load unpacks a model file. Drawables from dictionaries are added to dict by name */
+func (b *sceneBuilder) load(source *modelSource, dict map[jenkins.Jenkins32]*export.Model) (model *export.Model) {
+	fileName := fmt.Sprintf("%v%v", source.Name, source.Ext)
+
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("Unable to convert %v: %v\n", fileName, err)
+			model = nil
+		}
+	}()
+
+	data, err := source.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, fileName, uint32(len(data))); err != nil {
+		panic(err)
+	}
+
+	switch source.Ext {
+	case ".ydr":
+		drawable := new(drawable.Drawable)
+		if err := drawable.Unpack(res); err != nil {
+			panic(err)
+		}
+
+		drawable.Model.Name = source.Name
+		return drawable.Model
+
+	case ".yft":
+		frag := new(frag.FragType)
+		if err := frag.Unpack(res); err != nil {
+			panic(err)
+		}
+
+		frag.Drawable.Model.Name = source.Name
+		return frag.Drawable.Model
+
+	case ".ydd":
+		dictionary := new(dictionary.Dictionary)
+		if err := dictionary.Unpack(res); err != nil {
+			panic(err)
+		}
+
+		for _, drawable := range dictionary.Drawables {
+			drawable.Model.Name = drawable.Title
+			dict[archetype.HashName(drawable.Title)] = drawable.Model
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+var (
+	natives     = flag.String("natives", "./natives.json", "Native function database")
+	translation = flag.String("translation", "./native_translation.dat", "Native hash translation tables or crossmaps, separated by commas")
+	csvTable    = flag.String("csv", "", "Write this table as csv instead of json ("+strings.Join(script.InfoTables, ", ")+")")
+)
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: rage-script-info [-natives file] [-translation file] [-csv table] <ysc|xsc|dir>...")
+	}
+
+	db, err := loadNatives(*natives, *translation)
+	if err != nil {
+		log.Printf("Unable to load hash dictionary (%v). Some natives may be unnamed\n", err)
+	}
+
+	infos := make([]*script.ScriptInfo, 0)
+	for _, p := range flag.Args() {
+		filepath.Walk(p, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() {
+				return nil
+			}
+
+			if format, ok := resource.FormatByExtension(file); !ok || format.Name != "script" {
+				return nil
+			}
+
+			info, err := scriptInfo(db, file)
+			if err != nil {
+				log.Printf("%v: %v\n", file, err)
+				return nil
+			}
+
+			infos = append(infos, info)
+			return nil
+		})
+	}
+
+	if *csvTable != "" {
+		err = script.WriteScriptInfoCSV(os.Stdout, infos, *csvTable)
+	} else {
+		err = script.WriteScriptInfoJSON(os.Stdout, infos)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadNatives(natives, translation string) (*script.NativeDB, error) {
+	db, err := script.LoadNatives(natives)
+	if err != nil {
+		return nil, err
+	}
+
+	/* The natives can still be looked up by their current hashes without the translations */
+	return db, db.LoadTranslations(strings.Split(translation, ",")...)
+}
+
+func scriptInfo(db *script.NativeDB, file string) (*script.ScriptInfo, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	format, _ := resource.FormatByExtension(file)
+	resource.SetArch(format.Arch)
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, path.Base(file), uint32(len(data))); err != nil {
+		return nil, err
+	}
+
+	code := make([]script.Instruction, 0)
+	outScript := script.NewScript(path.Base(file), uint32(len(data)))
+	outScript.HashTable = db
+	if err = outScript.Unpack(res, func(istr script.Instruction) {
+		code = append(code, istr)
+	}); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(path.Base(file), path.Ext(file))
+	return script.NewScriptInfo(name, outScript, code), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/vehiclerecord"
+)
+
+func main() {
+	var outputJson = flag.Bool("json", false, "Output JSON instead of CSV")
+	flag.Parse()
+
+	log.SetFlags(0)
+	resource.SetArch(resource.ArchPC)
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: rage-vehicle-record [-json] file.yvr...")
+	}
+
+	for _, inFile := range flag.Args() {
+		rec := unpackRecord(inFile)
+
+		var err error
+		if *outputJson {
+			err = writeJson(rec)
+		} else {
+			err = writeCsv(rec)
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func unpackRecord(inFile string) *vehiclerecord.Record {
+	data, err := ioutil.ReadFile(inFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, filepath.Base(inFile), uint32(len(data))); err != nil {
+		log.Fatal(err)
+	}
+
+	rec := new(vehiclerecord.Record)
+	if err = rec.Unpack(res); err != nil {
+		log.Fatal(err)
+	}
+	return rec
+}
+
+func writeJson(rec *vehiclerecord.Record) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	return enc.Encode(rec.Samples)
+}
+
+func writeCsv(rec *vehiclerecord.Record) error {
+	out := csv.NewWriter(os.Stdout)
+	out.Write([]string{
+		"time",
+		"pos_x", "pos_y", "pos_z",
+		"vel_x", "vel_y", "vel_z",
+		"fwd_x", "fwd_y", "fwd_z",
+		"up_x", "up_y", "up_z",
+		"steering", "gas", "brake", "handbrake",
+	})
+
+	f := func(v float32) string {
+		return fmt.Sprintf("%v", v)
+	}
+
+	for _, s := range rec.Samples {
+		handbrake := "0"
+		if s.Handbrake {
+			handbrake = "1"
+		}
+
+		out.Write([]string{
+			f(s.Time),
+			f(s.Position[0]), f(s.Position[1]), f(s.Position[2]),
+			f(s.Velocity[0]), f(s.Velocity[1]), f(s.Velocity[2]),
+			f(s.Forward[0]), f(s.Forward[1]), f(s.Forward[2]),
+			f(s.Top[0]), f(s.Top[1]), f(s.Top[2]),
+			f(s.Steering), f(s.Gas), f(s.Brake), handbrake,
+		})
+	}
+
+	out.Flush()
+	return out.Error()
+}
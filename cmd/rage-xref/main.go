@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+var (
+	natives     = flag.String("natives", "./natives.json", "Native function database")
+	translation = flag.String("translation", "./native_translation.dat", "Native hash translation tables or crossmaps, separated by commas")
+
+	callers    = flag.String("callers", "", "List the callers of a function, as name or script:name")
+	nativeUses = flag.String("native", "", "List the callers of a native, by name or hash")
+	stringUses = flag.String("string", "", "List the uses of a string")
+	staticUses = flag.String("static", "", "List the uses of a static, as index or script:index")
+
+	dotOut     = flag.String("dot", "", "Write the call graph as dot to this file")
+	dotNatives = flag.Bool("dot-natives", false, "Include calls to natives in the call graph")
+	jsonOut    = flag.Bool("json", false, "Write query results as json")
+)
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: rage-xref [-callers fn] [-native name] [-string str] [-static n] [-dot file] <ysc|xsc|dir>...")
+	}
+
+	db, err := loadNatives(*natives, *translation)
+	if err != nil {
+		log.Printf("Unable to load hash dictionary (%v). Some natives may be listed by hash\n", err)
+	}
+
+	index := script.NewXrefIndex(db)
+	for _, p := range flag.Args() {
+		filepath.Walk(p, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() {
+				return nil
+			}
+
+			if format, ok := resource.FormatByExtension(file); !ok || format.Name != "script" {
+				return nil
+			}
+
+			if err := scanScript(index, file); err != nil {
+				log.Printf("%v: %v\n", file, err)
+			}
+			return nil
+		})
+	}
+
+	log.Printf("Indexed %v functions in %v scripts\n", len(index.Functions), len(index.Scripts))
+
+	write := script.WriteXrefs
+	if *jsonOut {
+		write = script.WriteXrefsJSON
+	}
+
+	if *callers != "" {
+		query(write, "callers of "+*callers, index.CallersOf(*callers))
+	}
+
+	if *nativeUses != "" {
+		query(write, "callers of native "+*nativeUses, index.NativeCallers(*nativeUses))
+	}
+
+	if *stringUses != "" {
+		query(write, "uses of string "+strconv.Quote(*stringUses), index.StringUses(*stringUses))
+	}
+
+	if *staticUses != "" {
+		scriptName, static := "", *staticUses
+		if i := strings.LastIndex(static, ":"); i != -1 {
+			scriptName, static = static[:i], static[i+1:]
+		}
+
+		n, err := strconv.Atoi(static)
+		if err != nil {
+			log.Fatalf("invalid static: %v", *staticUses)
+		}
+		query(write, "uses of static "+*staticUses, index.StaticUses(scriptName, n))
+	}
+
+	if *dotOut != "" {
+		out, err := os.Create(*dotOut)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+
+		if err = index.WriteCallGraphDOT(out, *dotNatives); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func query(write func(io.Writer, []script.Xref) error, desc string, refs []script.Xref) {
+	log.Printf("%v: %v found\n", desc, len(refs))
+	if err := write(os.Stdout, refs); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadNatives(natives, translation string) (*script.NativeDB, error) {
+	db, err := script.LoadNatives(natives)
+	if err != nil {
+		return nil, err
+	}
+
+	/* The natives can still be looked up by their current hashes without the translations */
+	return db, db.LoadTranslations(strings.Split(translation, ",")...)
+}
+
+func scanScript(index *script.XrefIndex, file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	format, _ := resource.FormatByExtension(file)
+	resource.SetArch(format.Arch)
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, path.Base(file), uint32(len(data))); err != nil {
+		return err
+	}
+
+	code := make([]script.Instruction, 0)
+	outScript := script.NewScript(path.Base(file), uint32(len(data)))
+	outScript.HashTable = index.Natives
+	if err = outScript.Unpack(res, func(istr script.Instruction) {
+		code = append(code, istr)
+	}); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(path.Base(file), path.Ext(file))
+	index.Scan(name, outScript, code)
+	return nil
+}
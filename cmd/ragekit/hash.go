@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+)
+
+var hashCommand = &command{
+	Name:    "hash",
+	Usage:   "[-exhaustive] [-index] [-repr uint|int|hex|none] [string...]",
+	Summary: "Compute the jenkins hash of strings given as arguments or on stdin",
+	Flags:   hashFlags,
+	Run:     hashRun,
+}
+
+var (
+	hashFlags      = flag.NewFlagSet("hash", flag.ContinueOnError)
+	hashExhaustive = hashFlags.Bool("exhaustive", false, "exhaustive hash (hash all substrings)")
+	hashIndexForm  = hashFlags.Bool("index", false, "output in jenkindex form")
+	hashRepr       = hashFlags.String("repr", "uint", "output representation (uint, int, hex, none)")
+)
+
+func formatHash(j jenkins.Jenkins32) (string, error) {
+	switch *hashRepr {
+	case "uint":
+		return fmt.Sprintf("%v", j.Uint32()), nil
+	case "int":
+		return fmt.Sprintf("%v", j.Int32()), nil
+	case "hex":
+		return j.Hex(), nil
+	}
+	return "", fmt.Errorf("unknown representation: %v", *hashRepr)
+}
+
+func hashString(s string) error {
+	if *hashRepr == "none" {
+		fmt.Println(s)
+		return nil
+	}
+
+	hashFunc := jenkins.New()
+	hashFunc.UpdateArray([]uint8(s))
+
+	formatted, err := formatHash(hashFunc.HashJenkins32())
+	if err != nil {
+		return err
+	}
+
+	if *hashIndexForm {
+		fmt.Printf("%v:%v\n", formatted, s)
+	} else {
+		fmt.Println(formatted)
+	}
+	return nil
+}
+
+func hashSubstrings(s string) error {
+	if err := hashString(s); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(s); i++ {
+		for j := i + 1; j <= len(s); j++ {
+			if err := hashString(s[i:j]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hashRun(cmd *command, args []string) error {
+	doHash := hashString
+	if *hashExhaustive {
+		doHash = hashSubstrings
+	}
+
+	return eachInput(args, doHash)
+}
+
+/* eachInput calls fn for each argument, or each line of stdin if there are none */
+func eachInput(args []string, fn func(string) error) error {
+	if len(args) > 0 {
+		for _, arg := range args {
+			if err := fn(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+)
+
+var indexCommand = &command{
+	Name: "index",
+	Subcommands: []*command{
+		{
+			Name:    "build",
+			Usage:   "[string...]",
+			Summary: "Build a sorted hash index from strings given as arguments or on stdin",
+			Run:     indexBuild,
+		},
+		{
+			Name:    "sort",
+			Usage:   "< index",
+			Summary: "Sort a hash index read from stdin",
+			Run:     indexSort,
+		},
+		{
+			Name:    "lookup",
+			Usage:   "<hash>...",
+			Summary: "Look up the names of hashes in the hash index",
+			Run:     indexLookup,
+		},
+	},
+}
+
+func indexBuild(cmd *command, args []string) error {
+	seen := make(map[string]bool)
+	index := make([]string, 0)
+
+	err := eachInput(args, func(s string) error {
+		if seen[s] {
+			return nil
+		}
+		seen[s] = true
+
+		hash := jenkins.New()
+		hash.UpdateArray([]uint8(s))
+		index = append(index, fmt.Sprintf("%v:%v", hash.Hash(), s))
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sort.Stable(jenkins.IndexByHash(index))
+	for _, s := range index {
+		fmt.Println(s)
+	}
+	return nil
+}
+
+func indexSort(cmd *command, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	jenkins.ReadIndex(os.Stdin)
+	sort.Sort(jenkins.IndexByHash(jenkins.Index))
+
+	for _, s := range jenkins.Index {
+		fmt.Println(s)
+	}
+	return nil
+}
+
+func indexLookup(cmd *command, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	if os.Getenv(jenkins.IndexFileEnv) == "" {
+		return fmt.Errorf("no hash index given (use -hashes or $%v)", jenkins.IndexFileEnv)
+	}
+
+	if err := jenkins.ReadIndexFromEnv(); err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		hash, err := parseHash(arg)
+		if err != nil {
+			return err
+		}
+
+		if entry := jenkins.Lookup(hash); entry != "" {
+			fmt.Println(entry)
+		} else {
+			fmt.Printf("%v: not found\n", hash.Uint32())
+		}
+	}
+	return nil
+}
+
+/* parseHash accepts a hash as an unsigned, signed or 0x prefixed hex integer */
+func parseHash(s string) (jenkins.Jenkins32, error) {
+	if strings.HasPrefix(s, "0x") {
+		value, err := strconv.ParseUint(s[2:], 16, 32)
+		return jenkins.Jenkins32(value), err
+	}
+
+	if strings.HasPrefix(s, "-") {
+		value, err := strconv.ParseInt(s, 10, 32)
+		return jenkins.Jenkins32(uint32(value)), err
+	}
+
+	value, err := strconv.ParseUint(s, 10, 32)
+	return jenkins.Jenkins32(value), err
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/crypto"
+)
+
+// Exit codes shared by every subcommand
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+/* errUsage is returned by a command when its arguments are invalid */
+var errUsage = errors.New("invalid usage")
+
+/* command is a subcommand, or a group of them */
+type command struct {
+	Name    string
+	Usage   string
+	Summary string
+	Run     func(cmd *command, args []string) error
+
+	Flags       *flag.FlagSet
+	Subcommands []*command
+}
+
+var (
+	archName  = flag.String("arch", "", "Force the architecture of resources (pc, 360 or ps3). By default it's determined by the file extension")
+	keyDir    = flag.String("keys", "", "Directory of key files to use instead of the embedded keys (overrides $"+crypto.KeyDirEnv+")")
+	hashIndex = flag.String("hashes", "", "Jenkins hash index used to name hashes (overrides $"+jenkins.IndexFileEnv+")")
+	verbose   = flag.Bool("v", false, "Verbose output")
+)
+
+var commands = []*command{
+	pkgCommand,
+	modelCommand,
+	mapCommand,
+	scriptCommand,
+	hashCommand,
+	indexCommand,
+}
+
+func main() {
+	log.SetFlags(0)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ragekit [global flags] <command> [flags] [args]\n\nGlobal flags:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nCommands:\n")
+		printCommands(commands, "")
+	}
+
+	flag.Parse()
+	os.Exit(run(flag.Args()))
+}
+
+func run(args []string) int {
+	if *keyDir != "" {
+		os.Setenv(crypto.KeyDirEnv, *keyDir)
+	}
+
+	if *hashIndex != "" {
+		os.Setenv(jenkins.IndexFileEnv, *hashIndex)
+	}
+
+	resource.Verbose = *verbose
+
+	if *archName != "" {
+		if _, err := forcedArch(); err != nil {
+			log.Print(err)
+			return exitUsage
+		}
+	}
+
+	cmds := commands
+	path := "ragekit"
+	for {
+		if len(args) == 0 {
+			flag.Usage()
+			return exitUsage
+		}
+
+		cmd := findCommand(cmds, args[0])
+		if cmd == nil {
+			log.Printf("%v: unknown command %q\n", path, args[0])
+			return exitUsage
+		}
+
+		path = path + " " + cmd.Name
+		args = args[1:]
+
+		if len(cmd.Subcommands) == 0 {
+			return runCommand(cmd, path, args)
+		}
+
+		if len(args) == 0 {
+			fmt.Fprintf(os.Stderr, "Usage: %v <command>\n\nCommands:\n", path)
+			printCommands(cmd.Subcommands, "")
+			return exitUsage
+		}
+		cmds = cmd.Subcommands
+	}
+}
+
+func runCommand(cmd *command, path string, args []string) int {
+	flags := cmd.Flags
+	if flags == nil {
+		flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	}
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %v %v\n\n%v\n", path, cmd.Usage, cmd.Summary)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if err := call(cmd, flags.Args()); err != nil {
+		if err == errUsage {
+			flags.Usage()
+			return exitUsage
+		}
+
+		log.Printf("%v: %v\n", path, err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+// call runs a command. Resources panic on malformed data or keys which can't be loaded, while
+// decryption and deflate failures are only logged so partly readable containers can be salvaged.
+// A panic is reported as an error rather than a crash
+func call(cmd *command, args []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return cmd.Run(cmd, args)
+}
+
+func findCommand(cmds []*command, name string) *command {
+	for _, cmd := range cmds {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func printCommands(cmds []*command, prefix string) {
+	sorted := append([]*command(nil), cmds...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	for _, cmd := range sorted {
+		name := strings.TrimSpace(prefix + " " + cmd.Name)
+		if len(cmd.Subcommands) != 0 {
+			printCommands(cmd.Subcommands, name)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  %-20v %v\n", name, cmd.Summary)
+	}
+}
+
+/* forcedArch returns the architecture given with -arch */
+func forcedArch() (resource.Arch, error) {
+	switch strings.ToLower(*archName) {
+	case "pc":
+		return resource.ArchPC, nil
+	case "360", "xbox360":
+		return resource.Arch360, nil
+	case "ps3":
+		return resource.ArchPS3, nil
+	}
+	return 0, fmt.Errorf("unknown architecture %q", *archName)
+}
+
+/* readResource unpacks a resource, using the -arch override if it's set */
+func readResource(name string, data []byte) (interface{}, *resource.Format, error) {
+	if *archName == "" {
+		return resource.Read(name, data)
+	}
+
+	arch, err := forcedArch()
+	if err != nil {
+		return nil, nil, err
+	}
+	return resource.ReadArch(name, data, arch)
+}
+
+/* setArch sets the architecture for formats which aren't unpacked through the registry */
+func setArch(def resource.Arch) {
+	if arch, err := forcedArch(); err == nil {
+		resource.SetArch(arch)
+		return
+	}
+	resource.SetArch(def)
+}
+
+/* readHashIndex loads the hash index, if one is configured */
+func readHashIndex() {
+	if os.Getenv(jenkins.IndexFileEnv) != "" {
+		jenkins.ReadIndexFromEnv()
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/item"
+)
+
+var mapCommand = &command{
+	Name: "map",
+	Subcommands: []*command{
+		{
+			Name:    "export",
+			Usage:   "[-xml] <ymap|ytyp> <output>",
+			Summary: "Export a map or archetype definitions to JSON or CodeWalker XML",
+			Flags:   mapExportFlags,
+			Run:     mapExport,
+		},
+		{
+			Name:    "import",
+			Usage:   "[-template file] <json|xml> <ymap|ytyp>",
+			Summary: "Build a map or archetype definitions from JSON or CodeWalker XML",
+			Flags:   mapImportFlags,
+			Run:     mapImport,
+		},
+	},
+}
+
+var (
+	mapExportFlags = flag.NewFlagSet("export", flag.ContinueOnError)
+	mapXML         = mapExportFlags.Bool("xml", false, "Export CodeWalker compatible XML instead of JSON")
+
+	mapImportFlags = flag.NewFlagSet("import", flag.ContinueOnError)
+	mapTemplate    = mapImportFlags.String("template", "", "ymap/ytyp (or JSON dump) providing the schema when importing XML")
+)
+
+func openDefinition(file string) (*item.ItemDefinition, error) {
+	/* Item definitions are only found in PC resources */
+	setArch(resource.ArchPC)
+	return item.Open(file)
+}
+
+func mapExport(cmd *command, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	readHashIndex()
+
+	def, err := openDefinition(args[0])
+	if err != nil {
+		return err
+	}
+
+	if !*mapXML {
+		return def.Dump(args[1])
+	}
+
+	fd, err := os.Create(args[1])
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	log.Printf("Writing %v\n", args[1])
+	return def.ExportXML(fd)
+}
+
+func mapImport(cmd *command, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	readHashIndex()
+
+	var def *item.ItemDefinition
+	var err error
+
+	if strings.ToLower(filepath.Ext(args[0])) == ".xml" {
+		if *mapTemplate == "" {
+			return errUsage
+		}
+
+		if def, err = openDefinition(*mapTemplate); err != nil {
+			return err
+		}
+
+		fd, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+
+		if err = def.ImportXML(fd); err != nil {
+			return err
+		}
+	} else if def, err = openDefinition(args[0]); err != nil {
+		return err
+	}
+
+	data, err := def.Pack()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Writing %v\n", args[1])
+	return ioutil.WriteFile(args[1], data, 0644)
+}
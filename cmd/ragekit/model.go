@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/dae"
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export/obj"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/bounds"
+	"github.com/tgascoigne/ragekit/resource/dictionary"
+	"github.com/tgascoigne/ragekit/resource/drawable"
+	"github.com/tgascoigne/ragekit/resource/frag"
+	"github.com/tgascoigne/ragekit/resource/vehiclerecord"
+)
+
+var modelCommand = &command{
+	Name: "model",
+	Subcommands: []*command{
+		{
+			Name:    "export",
+			Usage:   "[-merge name] [-flip] [-obj] <model>...",
+			Summary: "Export drawables, dictionaries, fragments, bounds and vehicle recordings to DAE or OBJ",
+			Flags:   modelExportFlags,
+			Run:     modelExport,
+		},
+	},
+}
+
+var (
+	modelExportFlags = flag.NewFlagSet("export", flag.ContinueOnError)
+	modelMerge       = modelExportFlags.String("merge", "", "The basename of a file to merge all output to")
+	modelObj         = modelExportFlags.Bool("obj", false, "Output to OBJ instead of DAE")
+)
+
+func init() {
+	modelExportFlags.BoolVar(&export.FlipYZ, "flip", false, "Flip the Z and Y axes")
+}
+
+func modelExport(cmd *command, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	exportFunc := dae.Export
+	if *modelObj {
+		exportFunc = obj.Export
+	}
+
+	merged := export.NewModelGroup()
+	merged.Name = *modelMerge
+
+	failed := 0
+	for _, file := range args {
+		log.Printf("Converting %v\n", file)
+
+		model, err := unpackModel(file)
+		if err != nil {
+			log.Printf("Unable to convert %v: %v\n", file, err)
+			failed++
+			continue
+		}
+
+		if *modelMerge != "" {
+			merged.Merge(model)
+			continue
+		}
+
+		group := export.NewModelGroup()
+		group.Merge(model)
+		if err := exportFunc(group); err != nil {
+			log.Printf("Unable to export %v: %v\n", file, err)
+			failed++
+		}
+	}
+
+	if *modelMerge != "" {
+		if err := exportFunc(merged); err != nil {
+			return err
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%v of %v models failed", failed, len(args))
+	}
+	return nil
+}
+
+func unpackModel(file string) (export.Exportable, error) {
+	value, _, err := openResource(file)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+	switch value := value.(type) {
+	case *drawable.Drawable:
+		return value.Model, nil
+
+	case *dictionary.Dictionary:
+		group := export.NewModelGroup()
+		group.Name = baseName
+		for _, d := range value.Drawables {
+			if idx := strings.LastIndex(d.Title, "."); idx != -1 {
+				d.Title = d.Title[:idx]
+			}
+			d.Model.Name = d.Title
+			group.Add(d.Model)
+		}
+		return group, nil
+
+	case *frag.FragType:
+		/* Drawables inside frag files dont seem to be named properly. */
+		value.Drawable.Model.Name = baseName
+		return value.Drawable.Model, nil
+
+	case *bounds.Nodes:
+		value.Model.Name = baseName
+		return value.Model, nil
+
+	case *vehiclerecord.Record:
+		return value.Export(baseName), nil
+	}
+
+	return nil, fmt.Errorf("%v is not a model", file)
+}
+
+/* openResource reads and unpacks a resource file through the format registry */
+func openResource(file string) (interface{}, *resource.Format, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return readResource(file, data)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+)
+
+var pkgCommand = &command{
+	Name: "pkg",
+	Subcommands: []*command{
+		{
+			Name:    "ls",
+			Usage:   "<rpf>",
+			Summary: "List the contents of a package",
+			Run:     pkgList,
+		},
+		{
+			Name:    "extract",
+			Usage:   "[-recursive] <rpf> <output directory>",
+			Summary: "Extract the contents of a package",
+			Flags:   pkgExtractFlags,
+			Run:     pkgExtract,
+		},
+		{
+			Name:    "pack",
+			Usage:   "<directory> <rpf>",
+			Summary: "Build an unencrypted package from a directory",
+			Run:     pkgPack,
+		},
+	},
+}
+
+var (
+	pkgExtractFlags = flag.NewFlagSet("extract", flag.ContinueOnError)
+	pkgRecursive    = pkgExtractFlags.Bool("recursive", false, "Recursively extract nested packages")
+)
+
+func openPackage(file string) (*resource.Package, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	/* Packages are only found on PC */
+	setArch(resource.ArchPC)
+
+	pkg := new(resource.Package)
+	if err := pkg.Unpack(data, path.Base(file), uint32(len(data))); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+/* walkPackage visits each file of a package with its path inside the package */
+func walkPackage(pkg *resource.Package, visit func(name string, file resource.PackageFile) error) error {
+	var walk func(node resource.PackageNode, dir string) error
+	walk = func(node resource.PackageNode, dir string) error {
+		switch node := node.(type) {
+		case resource.PackageDirectory:
+			for _, child := range node.Children(pkg) {
+				if err := walk(child, path.Join(dir, node.Name(pkg))); err != nil {
+					return err
+				}
+			}
+			return nil
+
+		case resource.PackageFile:
+			return visit(path.Join(dir, node.Name(pkg)), node)
+		}
+		return fmt.Errorf("unknown node type: %T", node)
+	}
+
+	return walk(pkg.Root(), "")
+}
+
+func pkgList(cmd *command, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	pkg, err := openPackage(args[0])
+	if err != nil {
+		return err
+	}
+
+	return walkPackage(pkg, func(name string, file resource.PackageFile) error {
+		switch entry := file.(type) {
+		case *resource.PackageBlobEntry:
+			fmt.Printf("%10v  %v\n", entry.Size, name)
+		case *resource.PackageResourceEntry:
+			fmt.Printf("%10v  %v\n", entry.Size(pkg), name)
+		default:
+			fmt.Printf("%10v  %v\n", "?", name)
+		}
+		return nil
+	})
+}
+
+func pkgExtract(cmd *command, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	return extractPackage(args[0], args[1])
+}
+
+func extractPackage(file, outDir string) error {
+	log.Printf("Unpacking %v to %v\n", file, outDir)
+
+	pkg, err := openPackage(file)
+	if err != nil {
+		return err
+	}
+
+	return walkPackage(pkg, func(name string, entry resource.PackageFile) error {
+		outPath := filepath.Join(outDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(outPath), 0777); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(outPath, entry.Data(pkg), 0666); err != nil {
+			return err
+		}
+
+		if *pkgRecursive && strings.ToLower(filepath.Ext(outPath)) == ".rpf" {
+			return extractPackage(outPath, strings.TrimSuffix(outPath, filepath.Ext(outPath))+"_rpf")
+		}
+		return nil
+	})
+}
+
+func pkgPack(cmd *command, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	inDir, outFile := args[0], args[1]
+	builder := resource.NewPackageBuilder()
+
+	err := filepath.Walk(inDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(inDir, file)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if *verbose {
+			log.Printf("Adding %v\n", rel)
+		}
+		return builder.Add(filepath.ToSlash(rel), data)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	data, err := builder.Pack()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Writing %v\n", outFile)
+	return ioutil.WriteFile(outFile, data, 0666)
+}
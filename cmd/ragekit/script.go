@@ -0,0 +1,449 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+var scriptCommand = &command{
+	Name: "script",
+	Subcommands: []*command{
+		{
+			Name:    "disasm",
+			Usage:   "[-natives file] [-translation file] [-cfg dot|json] <ysc|xsc> [output]",
+			Summary: "Disassemble a script",
+			Flags:   scriptDisasmFlags,
+			Run:     scriptDisasm,
+		},
+		{
+			Name:    "asm",
+			Usage:   "<asm> <ysc>",
+			Summary: "Assemble a script from its disassembly",
+			Run:     scriptAssemble,
+		},
+		{
+			Name:    "decompile",
+			Usage:   "[-natives file] [-translation file] [-cfg dot|json] [-globals header] <ysc|xsc> [output]",
+			Summary: "Decompile a script to C",
+			Flags:   scriptDecompileFlags,
+			Run:     scriptDecompile,
+		},
+		{
+			Name:    "globals",
+			Usage:   "[-json file] [-header file] <ysc|xsc|dir>...",
+			Summary: "Find the globals used by a set of scripts",
+			Flags:   scriptGlobalsFlags,
+			Run:     scriptGlobals,
+		},
+		{
+			Name:    "xref",
+			Usage:   "[-callers fn] [-native name] [-string str] [-static n] [-dot file] <ysc|xsc|dir>...",
+			Summary: "Find cross references between the functions of a set of scripts",
+			Flags:   scriptXrefFlags,
+			Run:     scriptXref,
+		},
+		{
+			Name:    "info",
+			Usage:   "[-natives file] [-translation file] [-csv table] <ysc|xsc|dir>...",
+			Summary: "Export the strings, statics, natives and functions of a set of scripts",
+			Flags:   scriptInfoFlags,
+			Run:     scriptInfo,
+		},
+	},
+}
+
+var (
+	scriptDisasmFlags    = flag.NewFlagSet("disasm", flag.ContinueOnError)
+	scriptDecompileFlags = flag.NewFlagSet("decompile", flag.ContinueOnError)
+	scriptGlobalsFlags   = flag.NewFlagSet("globals", flag.ContinueOnError)
+	scriptXrefFlags      = flag.NewFlagSet("xref", flag.ContinueOnError)
+	scriptInfoFlags      = flag.NewFlagSet("info", flag.ContinueOnError)
+
+	scriptNatives     string
+	scriptTranslation string
+	scriptCFG         string
+
+	scriptGlobalsHeader = scriptDecompileFlags.String("globals", "", "Name globals using a header written by script globals")
+	scriptGlobalsJSON   = scriptGlobalsFlags.String("json", "", "Write the globals table as json to this file")
+	scriptGlobalsOut    = scriptGlobalsFlags.String("header", "", "Write a C header declaring each global to this file")
+
+	scriptXrefCallers    = scriptXrefFlags.String("callers", "", "List the callers of a function, as name or script:name")
+	scriptXrefNative     = scriptXrefFlags.String("native", "", "List the callers of a native, by name or hash")
+	scriptXrefString     = scriptXrefFlags.String("string", "", "List the uses of a string")
+	scriptXrefStatic     = scriptXrefFlags.String("static", "", "List the uses of a static, as index or script:index")
+	scriptXrefDOT        = scriptXrefFlags.String("dot", "", "Write the call graph as dot to this file")
+	scriptXrefDOTNatives = scriptXrefFlags.Bool("dot-natives", false, "Include calls to natives in the call graph")
+	scriptXrefJSON       = scriptXrefFlags.Bool("json", false, "Write query results as json")
+
+	scriptInfoCSV = scriptInfoFlags.String("csv", "", "Write this table as csv instead of json ("+strings.Join(script.InfoTables, ", ")+")")
+)
+
+func init() {
+	for _, flags := range []*flag.FlagSet{scriptDisasmFlags, scriptDecompileFlags, scriptXrefFlags, scriptInfoFlags} {
+		flags.StringVar(&scriptNatives, "natives", "./natives.json", "Native function database")
+		flags.StringVar(&scriptTranslation, "translation", "./native_translation.dat", "Native hash translation tables or crossmaps, separated by commas")
+	}
+
+	for _, flags := range []*flag.FlagSet{scriptDisasmFlags, scriptDecompileFlags} {
+		flags.StringVar(&scriptCFG, "cfg", "", "Output the control flow graph of each function instead, as dot or json")
+	}
+}
+
+/* unpackScript unpacks a script, passing each instruction to emit. The native DB is loaded if natives is set */
+func unpackScript(file string, natives bool, emit script.EmitFunc) (*script.Script, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	format, ok := resource.FormatByExtension(file)
+	if *archName != "" {
+		setArch(resource.ArchPC)
+	} else if ok && format.Name == "script" {
+		resource.SetArch(format.Arch)
+	} else {
+		return nil, fmt.Errorf("unknown architecture, path: %v (use -arch)", file)
+	}
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, path.Base(file), uint32(len(data))); err != nil {
+		return nil, err
+	}
+
+	outScript := script.NewScript(path.Base(file), uint32(len(data)))
+	if natives {
+		if err = outScript.LoadNativeDB(scriptNatives, strings.Split(scriptTranslation, ",")...); err != nil {
+			log.Printf("Unable to load hash dictionary (%v). Lookups will be unavailable\n", err)
+		}
+	}
+
+	if err = outScript.Unpack(res, emit); err != nil {
+		return nil, err
+	}
+	return outScript, nil
+}
+
+/* scriptOutput returns the optional output file argument, or stdout */
+func scriptOutput(args []string) (io.WriteCloser, error) {
+	if len(args) > 1 {
+		return os.Create(args[1])
+	}
+	return os.Stdout, nil
+}
+
+func scriptDisasm(cmd *command, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errUsage
+	}
+
+	out, err := scriptOutput(args)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	log.Printf("Disassembling %v\n", args[0])
+	code := make([]script.Instruction, 0)
+	outScript, err := unpackScript(args[0], true, func(istr script.Instruction) {
+		code = append(code, istr)
+	})
+	if err != nil {
+		return err
+	}
+
+	if scriptCFG != "" {
+		return script.WriteFlowGraphs(out, script.FlowGraphs(code), scriptCFG)
+	}
+
+	for _, directive := range outScript.Directives() {
+		fmt.Fprintln(out, directive)
+	}
+
+	for _, istr := range code {
+		fmt.Fprintln(out, istr.String())
+	}
+	return nil
+}
+
+func scriptAssemble(cmd *command, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	fd, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	/* Only PC scripts can be assembled */
+	resource.SetArch(resource.ArchPC)
+
+	log.Printf("Assembling %v\n", args[0])
+	name := strings.TrimSuffix(path.Base(args[1]), path.Ext(args[1]))
+	data, err := script.Assemble(name, fd)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(args[1], data, 0644)
+}
+
+func scriptDecompile(cmd *command, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errUsage
+	}
+
+	out, err := scriptOutput(args)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	log.Printf("Decompiling %v\n", args[0])
+	code := make([]script.Instruction, 0)
+	outScript, err := unpackScript(args[0], true, func(istr script.Instruction) {
+		code = append(code, istr)
+	})
+	if err != nil {
+		return err
+	}
+
+	if scriptCFG != "" {
+		return script.WriteFlowGraphs(out, script.FlowGraphs(code), scriptCFG)
+	}
+
+	machine := script.NewMachine(outScript, code)
+	if *scriptGlobalsHeader != "" {
+		fd, err := os.Open(*scriptGlobalsHeader)
+		if err != nil {
+			return err
+		}
+
+		names, err := script.ReadGlobalHeader(fd)
+		fd.Close()
+		if err != nil {
+			return err
+		}
+		machine.NameGlobals(names)
+	}
+
+	file := machine.Decompile()
+	_, err = fmt.Fprintln(out, file.CString())
+	return err
+}
+
+func scriptGlobals(cmd *command, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	table := script.NewGlobalTable()
+	for _, p := range args {
+		err := filepath.Walk(p, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() {
+				return err
+			}
+
+			if format, ok := resource.FormatByExtension(file); !ok || format.Name != "script" {
+				return nil
+			}
+
+			code := make([]script.Instruction, 0)
+			if _, err := unpackScript(file, false, func(istr script.Instruction) {
+				code = append(code, istr)
+			}); err != nil {
+				log.Printf("%v: %v\n", file, err)
+				return nil
+			}
+
+			table.Scan(strings.TrimSuffix(path.Base(file), path.Ext(file)), code)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Found %v globals in %v scripts\n", len(table.Globals), len(table.Scripts))
+
+	if *scriptGlobalsJSON == "" && *scriptGlobalsOut == "" {
+		return table.WriteJSON(os.Stdout)
+	}
+
+	if *scriptGlobalsJSON != "" {
+		if err := writeWith(*scriptGlobalsJSON, table.WriteJSON); err != nil {
+			return err
+		}
+	}
+
+	if *scriptGlobalsOut != "" {
+		return writeWith(*scriptGlobalsOut, table.WriteHeader)
+	}
+	return nil
+}
+
+/* writeWith creates a file and writes it with write */
+func scriptXref(cmd *command, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	index := script.NewXrefIndex(loadNativeDB())
+	for _, p := range args {
+		err := filepath.Walk(p, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() {
+				return err
+			}
+
+			if format, ok := resource.FormatByExtension(file); !ok || format.Name != "script" {
+				return nil
+			}
+
+			code := make([]script.Instruction, 0)
+			outScript, err := unpackScript(file, false, func(istr script.Instruction) {
+				code = append(code, istr)
+			})
+			if err != nil {
+				log.Printf("%v: %v\n", file, err)
+				return nil
+			}
+
+			index.Scan(strings.TrimSuffix(path.Base(file), path.Ext(file)), outScript, code)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Indexed %v functions in %v scripts\n", len(index.Functions), len(index.Scripts))
+
+	write := script.WriteXrefs
+	if *scriptXrefJSON {
+		write = script.WriteXrefsJSON
+	}
+
+	if *scriptXrefCallers != "" {
+		if err := xrefQuery(write, "callers of "+*scriptXrefCallers, index.CallersOf(*scriptXrefCallers)); err != nil {
+			return err
+		}
+	}
+
+	if *scriptXrefNative != "" {
+		if err := xrefQuery(write, "callers of native "+*scriptXrefNative, index.NativeCallers(*scriptXrefNative)); err != nil {
+			return err
+		}
+	}
+
+	if *scriptXrefString != "" {
+		if err := xrefQuery(write, "uses of string "+strconv.Quote(*scriptXrefString), index.StringUses(*scriptXrefString)); err != nil {
+			return err
+		}
+	}
+
+	if *scriptXrefStatic != "" {
+		scriptName, static := "", *scriptXrefStatic
+		if i := strings.LastIndex(static, ":"); i != -1 {
+			scriptName, static = static[:i], static[i+1:]
+		}
+
+		n, err := strconv.Atoi(static)
+		if err != nil {
+			return fmt.Errorf("invalid static: %v", *scriptXrefStatic)
+		}
+
+		if err = xrefQuery(write, "uses of static "+*scriptXrefStatic, index.StaticUses(scriptName, n)); err != nil {
+			return err
+		}
+	}
+
+	if *scriptXrefDOT != "" {
+		return writeWith(*scriptXrefDOT, func(w io.Writer) error {
+			return index.WriteCallGraphDOT(w, *scriptXrefDOTNatives)
+		})
+	}
+	return nil
+}
+
+/* loadNativeDB loads the native DB once for a set of scripts, rather than by each script */
+func loadNativeDB() *script.NativeDB {
+	db, err := script.LoadNatives(scriptNatives)
+	if err != nil {
+		log.Printf("Unable to load hash dictionary (%v). Natives will be unnamed\n", err)
+		return nil
+	}
+
+	if err = db.LoadTranslations(strings.Split(scriptTranslation, ",")...); err != nil {
+		log.Printf("Unable to load native translations (%v)\n", err)
+	}
+	return db
+}
+
+func scriptInfo(cmd *command, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	db := loadNativeDB()
+	infos := make([]*script.ScriptInfo, 0)
+	for _, p := range args {
+		err := filepath.Walk(p, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() {
+				return err
+			}
+
+			if format, ok := resource.FormatByExtension(file); !ok || format.Name != "script" {
+				return nil
+			}
+
+			code := make([]script.Instruction, 0)
+			outScript, err := unpackScript(file, false, func(istr script.Instruction) {
+				code = append(code, istr)
+			})
+			if err != nil {
+				log.Printf("%v: %v\n", file, err)
+				return nil
+			}
+
+			/* Natives were resolved while unpacking, so only the info needs the DB */
+			outScript.HashTable = db
+			infos = append(infos, script.NewScriptInfo(strings.TrimSuffix(path.Base(file), path.Ext(file)), outScript, code))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if *scriptInfoCSV != "" {
+		return script.WriteScriptInfoCSV(os.Stdout, infos, *scriptInfoCSV)
+	}
+	return script.WriteScriptInfoJSON(os.Stdout, infos)
+}
+
+func xrefQuery(write func(io.Writer, []script.Xref) error, desc string, refs []script.Xref) error {
+	log.Printf("%v: %v found\n", desc, len(refs))
+	return write(os.Stdout, refs)
+}
+
+func writeWith(file string, write func(w io.Writer) error) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return write(out)
+}
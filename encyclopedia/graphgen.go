@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource/item"
 	"github.com/tgascoigne/ragekit/resource/types"
 	_ "gopkg.in/cq.v1"
 )
@@ -123,6 +124,12 @@ func typeConvPropertyValue(value interface{}) interface{} {
 	case types.Unknown32:
 		return int64(value)
 
+	case item.EnumValue:
+		return int64(value.Value)
+
+	case item.FlagsValue:
+		return int64(value.Value)
+
 	default:
 		if value == nil {
 			return "nil"
@@ -2,9 +2,10 @@ package jenkins
 
 import (
 	"bufio"
-	"fmt"
 	"io"
+	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -40,7 +41,7 @@ const (
 
 func ReadIndexFromEnv() error {
 	indexFile := os.Getenv(IndexFileEnv)
-	fmt.Printf("Reading index from %v\n", indexFile)
+	log.Printf("Reading index from %v\n", indexFile)
 	fd, err := os.Open(indexFile)
 	if err != nil {
 		return err
@@ -97,29 +98,16 @@ func Lookup(j Jenkins32) string {
 		panic("index not loaded")
 	}
 
-	var binSearch func(s []string, offset int, hash uint32) int
-	binSearch = func(s []string, offset int, hash uint32) int {
-		n := len(s)
-		i := n / 2
-		if n == 0 || i == 0 {
-			return -1
-		}
-
-		h, _ := splitEntry(s[i])
-		if h < hash {
-			offset = offset + i
-			return binSearch(s[i:], offset, hash)
-		} else if h > hash {
-			return binSearch(s[:i], offset, hash)
-		} else if h == hash {
-			return offset + i
-		}
-		return -1
-	}
+	index := sort.Search(len(Index), func(i int) bool {
+		h, _ := splitEntry(Index[i])
+		return h >= uint32(j)
+	})
 
-	index := binSearch(Index, 0, uint32(j))
+	if index == len(Index) {
+		return ""
+	}
 
-	if index == -1 {
+	if h, _ := splitEntry(Index[index]); h != uint32(j) {
 		return ""
 	}
 
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 	"unsafe"
 )
 
@@ -38,6 +40,25 @@ func (j Jenkins32) AsPropertyName() string {
 	return fmt.Sprintf("unk_%v", uint32(j))
 }
 
+// Name returns the string for the hash in the form used by XML tooling, where
+// unknown hashes are written as hash_XXXXXXXX
+func (j Jenkins32) Name() string {
+	if j == 0 {
+		return ""
+	}
+
+	if Index != nil {
+		result := Lookup(j)
+		if result != "" {
+			_, value := splitEntry(result)
+			if propNameRegexp.MatchString(value) {
+				return value
+			}
+		}
+	}
+	return fmt.Sprintf("hash_%08X", uint32(j))
+}
+
 func (j Jenkins32) Uint32() uint32 {
 	return uint32(j)
 }
@@ -54,6 +75,40 @@ func (j Jenkins32) MarshalJSON() ([]byte, error) {
 	return json.Marshal(j.String())
 }
 
+func (j *Jenkins32) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var value uint32
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*j = Jenkins32(value)
+		return nil
+	}
+
+	*j = Parse(s)
+	return nil
+}
+
+// Parse returns the hash of a name. The placeholder forms returned by String
+// and Hex for unknown hashes are converted back into their original value
+func Parse(s string) Jenkins32 {
+	var value uint32
+	if _, err := fmt.Sscanf(s, "jenkins(%d)", &value); err == nil && s == fmt.Sprintf("jenkins(%v)", value) {
+		return Jenkins32(value)
+	}
+
+	if strings.HasPrefix(s, "hash_") || strings.HasPrefix(s, "0x") {
+		if value, err := strconv.ParseUint(s[strings.IndexAny(s, "_x")+1:], 16, 32); err == nil {
+			return Jenkins32(value)
+		}
+	}
+
+	hash := New()
+	hash.UpdateArray([]byte(s))
+	return hash.HashJenkins32()
+}
+
 type Jenkins struct {
 	hash uint32
 }
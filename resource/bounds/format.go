@@ -0,0 +1,13 @@
+package bounds
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		nodes := new(Nodes)
+		return nodes, nodes.Unpack(res)
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "bounds", Extension: ".ybn", Type: resource.ResourceBN, Arch: resource.ArchPC, Unpack: unpack})
+	resource.RegisterFormat(resource.Format{Name: "bounds", Extension: ".xbn", Type: resource.ResourceBN, Arch: resource.Arch360, Unpack: unpack})
+}
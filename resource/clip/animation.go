@@ -0,0 +1,93 @@
+package clip
+
+import (
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+)
+
+type AnimationHeader struct {
+	_                  uint32 /* vtable */
+	_                  uint32
+	_                  uint32
+	_                  uint32
+	_                  uint8
+	_                  uint8
+	Flags              uint16
+	Frames             uint16
+	SequenceFrameLimit uint16
+	Duration           float32
+	Hash               jenkins.Jenkins32
+	_                  uint32
+	_                  uint32
+	_                  uint32
+	_                  uint32
+	_                  uint32
+	_                  uint32
+	MaxSeqBlockLength  uint32
+	UsageCount         uint32
+	Sequences          resource.PointerCollection64
+	BoneIds            resource.Collection64
+}
+
+type Animation struct {
+	Header    AnimationHeader
+	Sequences []*Sequence
+	BoneIds   []BoneId
+}
+
+func (anim *Animation) Unpack(res *resource.Container) error {
+	res.Parse(&anim.Header)
+
+	anim.BoneIds = make([]BoneId, anim.Header.BoneIds.Count)
+	if err := anim.Header.BoneIds.For(res, func(i int) error {
+		res.Parse(&anim.BoneIds[i])
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	anim.Sequences = make([]*Sequence, anim.Header.Sequences.Count)
+	return anim.Header.Sequences.For(res, func(i int) error {
+		anim.Sequences[i] = new(Sequence)
+		return anim.Sequences[i].Unpack(res, len(anim.BoneIds))
+	})
+}
+
+/* FrameTime returns the time in seconds between two frames */
+func (anim *Animation) FrameTime() float32 {
+	if anim.Header.Frames < 2 {
+		return anim.Header.Duration
+	}
+	return anim.Header.Duration / float32(anim.Header.Frames-1)
+}
+
+// Sample evaluates the track identified by bone at the given frame.
+// Quaternions are returned as XYZW, vectors in XYZ, floats in X
+func (anim *Animation) Sample(bone int, frame int) ([4]float32, bool) {
+	if len(anim.Sequences) == 0 {
+		return [4]float32{}, false
+	}
+
+	limit := int(anim.Header.SequenceFrameLimit)
+	seq := 0
+	if limit > 0 {
+		seq = frame / limit
+		frame = frame % limit
+	}
+
+	if seq >= len(anim.Sequences) {
+		seq = len(anim.Sequences) - 1
+		frame = int(anim.Sequences[seq].Header.NumFrames) - 1
+	}
+
+	if frame < 0 {
+		frame = 0
+	}
+
+	sequence := anim.Sequences[seq]
+	if sequence == nil || bone >= len(sequence.Bones) || sequence.Bones[bone] == nil {
+		return [4]float32{}, false
+	}
+
+	return sequence.Bones[bone].Evaluate(frame), true
+}
@@ -0,0 +1,26 @@
+package clip
+
+/* bitReader reads little endian, LSB first bit packed values */
+type bitReader struct {
+	data []byte
+	pos  uint
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{data: data}
+}
+
+func (r *bitReader) Read(bits uint) uint32 {
+	var result uint32
+	for i := uint(0); i < bits; i++ {
+		byteIdx := (r.pos + i) / 8
+		if int(byteIdx) >= len(r.data) {
+			break
+		}
+
+		bit := (r.data[byteIdx] >> ((r.pos + i) % 8)) & 1
+		result |= uint32(bit) << i
+	}
+	r.pos += bits
+	return result
+}
@@ -0,0 +1,118 @@
+package clip
+
+import (
+	"fmt"
+)
+
+//go:generate stringer -type=Track
+
+type Track uint8
+
+const (
+	TrackBoneTranslation    Track = 0
+	TrackBoneRotation       Track = 1
+	TrackBoneScale          Track = 2
+	TrackMoverTranslation   Track = 5
+	TrackMoverRotation      Track = 6
+	TrackCameraTranslation  Track = 7
+	TrackCameraRotation     Track = 8
+	TrackFacialControl      Track = 24
+	TrackFacialTranslation  Track = 25
+	TrackFacialRotation     Track = 26
+	TrackCameraFieldOfView  Track = 27
+	TrackCameraDepthOfField Track = 28
+)
+
+//go:generate stringer -type=TrackFormat
+
+type TrackFormat uint8
+
+const (
+	FormatVector3    TrackFormat = 0
+	FormatQuaternion TrackFormat = 1
+	FormatFloat      TrackFormat = 2
+)
+
+/* Components returns the number of channels which make up a value of this format */
+func (f TrackFormat) Components() int {
+	switch f {
+	case FormatVector3:
+		return 3
+	case FormatQuaternion:
+		return 4
+	}
+	return 1
+}
+
+type BoneId struct {
+	Tag    uint16
+	Format TrackFormat
+	Track  Track
+}
+
+/* Name returns the skeleton bone name for the tag, if known */
+func (id BoneId) Name() string {
+	if name, ok := BoneTagNames[id.Tag]; ok {
+		return name
+	}
+	return fmt.Sprintf("bone_%v", id.Tag)
+}
+
+/* BoneTagNames maps the bone tags used by the ped skeleton to their names */
+var BoneTagNames = map[uint16]string{
+	0:     "SKEL_ROOT",
+	11816: "SKEL_Pelvis",
+	58271: "SKEL_L_Thigh",
+	63931: "SKEL_L_Calf",
+	14201: "SKEL_L_Foot",
+	2108:  "SKEL_L_Toe0",
+	51826: "SKEL_R_Thigh",
+	36864: "SKEL_R_Calf",
+	52301: "SKEL_R_Foot",
+	20781: "SKEL_R_Toe0",
+	57597: "SKEL_Spine_Root",
+	23553: "SKEL_Spine0",
+	24816: "SKEL_Spine1",
+	24817: "SKEL_Spine2",
+	24818: "SKEL_Spine3",
+	64729: "SKEL_L_Clavicle",
+	45509: "SKEL_L_UpperArm",
+	61163: "SKEL_L_Forearm",
+	18905: "SKEL_L_Hand",
+	26610: "SKEL_L_Finger00",
+	4089:  "SKEL_L_Finger01",
+	4090:  "SKEL_L_Finger02",
+	26611: "SKEL_L_Finger10",
+	4169:  "SKEL_L_Finger11",
+	4170:  "SKEL_L_Finger12",
+	26612: "SKEL_L_Finger20",
+	4185:  "SKEL_L_Finger21",
+	4186:  "SKEL_L_Finger22",
+	26613: "SKEL_L_Finger30",
+	4137:  "SKEL_L_Finger31",
+	4138:  "SKEL_L_Finger32",
+	26614: "SKEL_L_Finger40",
+	4153:  "SKEL_L_Finger41",
+	4154:  "SKEL_L_Finger42",
+	10706: "SKEL_R_Clavicle",
+	40269: "SKEL_R_UpperArm",
+	28252: "SKEL_R_Forearm",
+	57005: "SKEL_R_Hand",
+	58866: "SKEL_R_Finger00",
+	64016: "SKEL_R_Finger01",
+	64017: "SKEL_R_Finger02",
+	58867: "SKEL_R_Finger10",
+	64096: "SKEL_R_Finger11",
+	64097: "SKEL_R_Finger12",
+	58868: "SKEL_R_Finger20",
+	64112: "SKEL_R_Finger21",
+	64113: "SKEL_R_Finger22",
+	58869: "SKEL_R_Finger30",
+	64064: "SKEL_R_Finger31",
+	64065: "SKEL_R_Finger32",
+	58870: "SKEL_R_Finger40",
+	64080: "SKEL_R_Finger41",
+	64081: "SKEL_R_Finger42",
+	39317: "SKEL_Neck_1",
+	31086: "SKEL_Head",
+}
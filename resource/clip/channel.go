@@ -0,0 +1,299 @@
+package clip
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+//go:generate stringer -type=ChannelType
+
+type ChannelType uint8
+
+const (
+	StaticQuaternion      ChannelType = 0
+	StaticVector3         ChannelType = 1
+	StaticFloat           ChannelType = 2
+	RawFloat              ChannelType = 3
+	QuantizeFloat         ChannelType = 4
+	IndirectQuantizeFloat ChannelType = 5
+	LinearFloat           ChannelType = 6
+	CachedQuaternion1     ChannelType = 7
+	CachedQuaternion2     ChannelType = 8
+	numChannelTypes                   = 9
+)
+
+/* Channel provides the value of a single component of a track for each frame */
+type Channel interface {
+	Type() ChannelType
+	Value(frame int, component int) float32
+}
+
+/* frameChannel is implemented by channels which read a value from each frame */
+type frameChannel interface {
+	Channel
+	readFrame(bits *bitReader)
+}
+
+/* channelReader reads the channel headers from a sequence's data block */
+type channelReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *channelReader) uint16() uint16 {
+	if r.pos+2 > len(r.data) {
+		r.pos = len(r.data)
+		return 0
+	}
+	v := binary.LittleEndian.Uint16(r.data[r.pos:])
+	r.pos += 2
+	return v
+}
+
+func (r *channelReader) uint32() uint32 {
+	if r.pos+4 > len(r.data) {
+		r.pos = len(r.data)
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v
+}
+
+func (r *channelReader) float32() float32 {
+	return math.Float32frombits(r.uint32())
+}
+
+func (r *channelReader) words(n int) []byte {
+	end := r.pos + n*4
+	if end > len(r.data) {
+		end = len(r.data)
+	}
+	b := r.data[r.pos:end]
+	r.pos = end
+	return b
+}
+
+func newChannel(typ ChannelType, r *channelReader) Channel {
+	switch typ {
+	case StaticQuaternion:
+		x, y, z := r.float32(), r.float32(), r.float32()
+		w := float32(math.Sqrt(math.Max(0, float64(1-(x*x+y*y+z*z)))))
+		return &StaticQuaternionChannel{Quaternion: [4]float32{x, y, z, w}}
+	case StaticVector3:
+		return &StaticVector3Channel{Vector: [3]float32{r.float32(), r.float32(), r.float32()}}
+	case StaticFloat:
+		return &StaticFloatChannel{Constant: r.float32()}
+	case RawFloat:
+		return &RawFloatChannel{}
+	case QuantizeFloat:
+		return &QuantizeFloatChannel{
+			ValueBits: r.uint32(),
+			Quantum:   r.float32(),
+			Offset:    r.float32(),
+		}
+	case IndirectQuantizeFloat:
+		ch := &IndirectQuantizeFloatChannel{
+			FrameBits: r.uint32(),
+			ValueBits: r.uint32(),
+		}
+		numInts := int(r.uint32())
+		ch.Quantum = r.float32()
+		ch.Offset = r.float32()
+
+		if ch.ValueBits > 0 {
+			/* The palette is sized by the data actually present, rather than the count in the header */
+			data := r.words(numInts)
+			bits := newBitReader(data)
+			count := uint32(len(data)*8) / ch.ValueBits
+			ch.Palette = make([]float32, count)
+			for i := range ch.Palette {
+				ch.Palette[i] = float32(bits.Read(uint(ch.ValueBits)))*ch.Quantum + ch.Offset
+			}
+		} else {
+			r.words(numInts)
+		}
+		return ch
+	case LinearFloat:
+		numInts := int(r.uint32())
+		numKeys := int(r.uint32())
+		quantum := r.float32()
+		offset := r.float32()
+
+		/* Keys are packed as 16 bit frame indices followed by 16 bit quantized values */
+		data := r.words(numInts)
+		if numKeys > len(data)/4 {
+			numKeys = len(data) / 4
+		}
+
+		bits := newBitReader(data)
+		ch := &LinearFloatChannel{Keys: make([]LinearKey, numKeys)}
+		for i := range ch.Keys {
+			ch.Keys[i].Frame = int(bits.Read(16))
+			ch.Keys[i].Value = float32(bits.Read(16))*quantum + offset
+		}
+		return ch
+	case CachedQuaternion1, CachedQuaternion2:
+		return &CachedQuaternionChannel{Kind: typ}
+	}
+	return nil
+}
+
+type StaticQuaternionChannel struct {
+	Quaternion [4]float32
+}
+
+func (ch *StaticQuaternionChannel) Type() ChannelType {
+	return StaticQuaternion
+}
+
+func (ch *StaticQuaternionChannel) Value(frame int, component int) float32 {
+	return ch.Quaternion[component&3]
+}
+
+type StaticVector3Channel struct {
+	Vector [3]float32
+}
+
+func (ch *StaticVector3Channel) Type() ChannelType {
+	return StaticVector3
+}
+
+func (ch *StaticVector3Channel) Value(frame int, component int) float32 {
+	if component > 2 {
+		return 0
+	}
+	return ch.Vector[component]
+}
+
+type StaticFloatChannel struct {
+	Constant float32
+}
+
+func (ch *StaticFloatChannel) Type() ChannelType {
+	return StaticFloat
+}
+
+func (ch *StaticFloatChannel) Value(frame int, component int) float32 {
+	return ch.Constant
+}
+
+type RawFloatChannel struct {
+	Values []float32
+}
+
+func (ch *RawFloatChannel) Type() ChannelType {
+	return RawFloat
+}
+
+func (ch *RawFloatChannel) Value(frame int, component int) float32 {
+	return frameValue(ch.Values, frame)
+}
+
+func (ch *RawFloatChannel) readFrame(bits *bitReader) {
+	ch.Values = append(ch.Values, math.Float32frombits(bits.Read(32)))
+}
+
+type QuantizeFloatChannel struct {
+	ValueBits uint32
+	Quantum   float32
+	Offset    float32
+	Values    []float32
+}
+
+func (ch *QuantizeFloatChannel) Type() ChannelType {
+	return QuantizeFloat
+}
+
+func (ch *QuantizeFloatChannel) Value(frame int, component int) float32 {
+	return frameValue(ch.Values, frame)
+}
+
+func (ch *QuantizeFloatChannel) readFrame(bits *bitReader) {
+	value := float32(bits.Read(uint(ch.ValueBits)))*ch.Quantum + ch.Offset
+	ch.Values = append(ch.Values, value)
+}
+
+type IndirectQuantizeFloatChannel struct {
+	FrameBits uint32
+	ValueBits uint32
+	Quantum   float32
+	Offset    float32
+	Palette   []float32
+	Values    []float32
+}
+
+func (ch *IndirectQuantizeFloatChannel) Type() ChannelType {
+	return IndirectQuantizeFloat
+}
+
+func (ch *IndirectQuantizeFloatChannel) Value(frame int, component int) float32 {
+	return frameValue(ch.Values, frame)
+}
+
+func (ch *IndirectQuantizeFloatChannel) readFrame(bits *bitReader) {
+	idx := int(bits.Read(uint(ch.FrameBits)))
+	var value float32
+	if idx < len(ch.Palette) {
+		value = ch.Palette[idx]
+	}
+	ch.Values = append(ch.Values, value)
+}
+
+type LinearKey struct {
+	Frame int
+	Value float32
+}
+
+/* LinearFloatChannel is a piecewise linear curve through a set of keys */
+type LinearFloatChannel struct {
+	Keys []LinearKey
+}
+
+func (ch *LinearFloatChannel) Type() ChannelType {
+	return LinearFloat
+}
+
+func (ch *LinearFloatChannel) Value(frame int, component int) float32 {
+	if len(ch.Keys) == 0 {
+		return 0
+	}
+
+	prev := ch.Keys[0]
+	if frame <= prev.Frame {
+		return prev.Value
+	}
+
+	for _, key := range ch.Keys[1:] {
+		if frame <= key.Frame {
+			t := float32(frame-prev.Frame) / float32(key.Frame-prev.Frame)
+			return prev.Value + (key.Value-prev.Value)*t
+		}
+		prev = key
+	}
+	return prev.Value
+}
+
+// CachedQuaternionChannel marks a quaternion component which isn't stored,
+// and must be derived from the other three. CachedQuaternion2 stores the negated value
+type CachedQuaternionChannel struct {
+	Kind ChannelType
+}
+
+func (ch *CachedQuaternionChannel) Type() ChannelType {
+	return ch.Kind
+}
+
+func (ch *CachedQuaternionChannel) Value(frame int, component int) float32 {
+	return 0
+}
+
+func frameValue(values []float32, frame int) float32 {
+	if len(values) == 0 {
+		return 0
+	}
+	if frame >= len(values) {
+		frame = len(values) - 1
+	}
+	return values[frame]
+}
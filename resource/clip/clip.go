@@ -0,0 +1,166 @@
+package clip
+
+import (
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+//go:generate stringer -type=ClipType
+
+type ClipType uint8
+
+const (
+	ClipTypeAnimation     ClipType = 1
+	ClipTypeAnimationList ClipType = 2
+)
+
+type ClipHeader struct {
+	_            uint32 /* vtable */
+	_            uint32
+	_            uint32
+	_            uint32
+	Type         ClipType
+	_            uint8
+	_            uint16
+	_            uint32
+	Name         types.Ptr32
+	_            uint32
+	NameLength   uint16
+	NameCapacity uint16
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	Tags         types.Ptr32
+	_            uint32
+	Properties   types.Ptr32
+	_            uint32
+	_            uint32
+	_            uint32
+}
+
+type clipAnimationHeader struct {
+	Animation types.Ptr32
+	_         uint32
+	StartTime float32
+	EndTime   float32
+	Rate      float32
+	_         uint32
+	_         uint32
+	_         uint32
+}
+
+type clipAnimationListHeader struct {
+	Animations types.Ptr32
+	_          uint32
+	Count      uint16
+	Capacity   uint16
+	_          uint32
+	Duration   float32
+	_          uint32
+	_          uint32
+	_          uint32
+}
+
+type clipAnimationListEntry struct {
+	StartTime float32
+	EndTime   float32
+	Rate      float32
+	_         uint32
+	Animation types.Ptr32
+	_         uint32
+}
+
+/* ClipAnimation is a time slice of an animation referenced by a clip */
+type ClipAnimation struct {
+	StartTime float32
+	EndTime   float32
+	Rate      float32
+	Animation *Animation `json:"-"`
+}
+
+type Clip struct {
+	Header     ClipHeader
+	Hash       jenkins.Jenkins32
+	Name       string
+	Animations []*ClipAnimation
+}
+
+func (clip *Clip) Unpack(res *resource.Container, dict *Dictionary) error {
+	res.Parse(&clip.Header)
+
+	if clip.Header.Name.Valid() {
+		if err := res.Detour(clip.Header.Name, func() error {
+			res.Parse(&clip.Name)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	switch clip.Header.Type {
+	case ClipTypeAnimation:
+		var header clipAnimationHeader
+		res.Parse(&header)
+
+		if err := clip.addAnimation(res, dict, header.Animation, header.StartTime, header.EndTime, header.Rate); err != nil {
+			return err
+		}
+
+	case ClipTypeAnimationList:
+		var header clipAnimationListHeader
+		res.Parse(&header)
+
+		entries := make([]clipAnimationListEntry, header.Count)
+		if header.Animations.Valid() {
+			if err := res.Detour(header.Animations, func() error {
+				res.Parse(entries)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range entries {
+			if err := clip.addAnimation(res, dict, entry.Animation, entry.StartTime, entry.EndTime, entry.Rate); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (clip *Clip) addAnimation(res *resource.Container, dict *Dictionary, addr types.Ptr32, start, end, rate float32) error {
+	if !addr.Valid() {
+		return nil
+	}
+
+	anim, err := dict.animation(res, addr)
+	if err != nil {
+		return err
+	}
+
+	clip.Animations = append(clip.Animations, &ClipAnimation{
+		StartTime: start,
+		EndTime:   end,
+		Rate:      rate,
+		Animation: anim,
+	})
+	return nil
+}
+
+/* Duration returns the playback length of the clip in seconds */
+func (clip *Clip) Duration() float32 {
+	var duration float32
+	for _, ca := range clip.Animations {
+		rate := ca.Rate
+		if rate == 0 {
+			rate = 1
+		}
+		duration += (ca.EndTime - ca.StartTime) / rate
+	}
+	return duration
+}
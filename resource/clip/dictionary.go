@@ -0,0 +1,180 @@
+package clip
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+type DictionaryHeader struct {
+	_               uint32 /* vtable */
+	_               uint32
+	BlockMap        types.Ptr32
+	_               uint32
+	_               uint32
+	_               uint32
+	AnimationMap    types.Ptr32
+	_               uint32
+	_               uint32
+	_               uint32
+	ClipBuckets     types.Ptr32
+	_               uint32
+	ClipBucketCount uint16
+	ClipCount       uint16
+	_               uint32
+	_               uint32
+	_               uint32
+}
+
+type Dictionary struct {
+	Header     DictionaryHeader
+	Animations []*Animation
+	Clips      []*Clip
+
+	animations map[types.Ptr32]*Animation
+}
+
+func (dict *Dictionary) Unpack(res *resource.Container) error {
+	res.Parse(&dict.Header)
+
+	dict.animations = make(map[types.Ptr32]*Animation)
+
+	if dict.Header.AnimationMap.Valid() {
+		if err := res.Detour(dict.Header.AnimationMap, func() error {
+			return dict.unpackAnimationMap(res)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return unpackHashMap(res, dict.Header.ClipBuckets, dict.Header.ClipBucketCount, func(hash jenkins.Jenkins32, addr types.Ptr32) error {
+		clip := new(Clip)
+		clip.Hash = hash
+		if err := res.Detour(addr, func() error {
+			return clip.Unpack(res, dict)
+		}); err != nil {
+			return err
+		}
+
+		dict.Clips = append(dict.Clips, clip)
+		return nil
+	})
+}
+
+/* Clip looks up a clip by name */
+func (dict *Dictionary) Clip(name string) *Clip {
+	hash := jenkins.New()
+	hash.UpdateArray([]byte(strings.ToLower(name)))
+	for _, clip := range dict.Clips {
+		if clip.Name == name || clip.Hash == hash.HashJenkins32() {
+			return clip
+		}
+	}
+	return nil
+}
+
+/* animation returns the animation at addr, unpacking it if it hasn't been seen before */
+func (dict *Dictionary) animation(res *resource.Container, addr types.Ptr32) (*Animation, error) {
+	if anim, ok := dict.animations[addr]; ok {
+		return anim, nil
+	}
+
+	anim := new(Animation)
+	if err := res.Detour(addr, func() error {
+		return anim.Unpack(res)
+	}); err != nil {
+		return nil, err
+	}
+
+	dict.animations[addr] = anim
+	dict.Animations = append(dict.Animations, anim)
+	return anim, nil
+}
+
+type animationMapHeader struct {
+	_           uint32 /* vtable */
+	_           uint32
+	Buckets     types.Ptr32
+	_           uint32
+	BucketCount uint16
+	Count       uint16
+	_           uint32
+}
+
+func (dict *Dictionary) unpackAnimationMap(res *resource.Container) error {
+	var header animationMapHeader
+	res.Parse(&header)
+
+	return unpackHashMap(res, header.Buckets, header.BucketCount, func(hash jenkins.Jenkins32, addr types.Ptr32) error {
+		/* Each animation map entry points to an intermediate block which in turn points to the animation */
+		var entry resource.Ptr64
+		if err := res.Detour(addr, func() error {
+			res.Parse(&entry)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		_, err := dict.animation(res, entry.Addr)
+		return err
+	})
+}
+
+type hashMapEntry struct {
+	Hash  jenkins.Jenkins32
+	_     uint32
+	Value types.Ptr32
+	_     uint32
+	Next  types.Ptr32
+	_     uint32
+	_     uint32
+	_     uint32
+}
+
+/* unpackHashMap walks each of the bucket chains in a rage::atMap */
+func unpackHashMap(res *resource.Container, buckets types.Ptr32, count uint16, callback func(jenkins.Jenkins32, types.Ptr32) error) error {
+	if !buckets.Valid() {
+		return nil
+	}
+
+	collection := resource.PointerCollection64{
+		Addr:  buckets,
+		Count: count,
+	}
+
+	heads, err := collection.Pointers(res)
+	if err != nil {
+		return err
+	}
+
+	visited := make(map[types.Ptr32]bool)
+	for _, addr := range heads {
+		for addr.Valid() {
+			if visited[addr] {
+				return fmt.Errorf("hash map entry %v is linked more than once", addr)
+			}
+			visited[addr] = true
+
+			var entry hashMapEntry
+			if err := res.Detour(addr, func() error {
+				res.Parse(&entry)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			if entry.Value.Valid() {
+				if err := callback(entry.Hash, entry.Value); err != nil {
+					return err
+				}
+			}
+
+			addr = entry.Next
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package clip
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		dict := new(Dictionary)
+		return dict, dict.Unpack(res)
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "clip dictionary", Extension: ".ycd", Type: resource.ResourceClip, Arch: resource.ArchPC, Unpack: unpack})
+}
@@ -0,0 +1,128 @@
+package clip
+
+import (
+	"math"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+)
+
+type SequenceHeader struct {
+	Hash                 jenkins.Jenkins32
+	DataLength           uint32
+	_                    uint32
+	FrameOffset          uint32
+	RootMotionOffset     uint32
+	_                    uint16
+	NumFrames            uint16
+	FrameLength          uint16
+	IndirectQuantizeInts uint16
+	QuantizeValueBits    uint16
+	ChunkSize            uint8
+	RootMotionRefCounts  uint8
+}
+
+/* BoneSequence is the set of channels which make up a single track of a sequence */
+type BoneSequence struct {
+	Channels [4]Channel
+}
+
+type Sequence struct {
+	Header   SequenceHeader
+	Channels []Channel
+	Bones    []*BoneSequence
+}
+
+func (seq *Sequence) Unpack(res *resource.Container, numBones int) error {
+	res.Parse(&seq.Header)
+
+	data := make([]byte, seq.Header.DataLength)
+	res.Parse(data)
+
+	reader := &channelReader{data: data}
+	for typ := ChannelType(0); typ < numChannelTypes; typ++ {
+		count := int(reader.uint16())
+		for i := 0; i < count; i++ {
+			seq.Channels = append(seq.Channels, newChannel(typ, reader))
+		}
+	}
+
+	/* Each channel is followed by a mapping of (bone sequence << 2) | component */
+	seq.Bones = make([]*BoneSequence, numBones)
+	for _, ch := range seq.Channels {
+		mapping := reader.uint16()
+		bone := int(mapping >> 2)
+		component := int(mapping & 3)
+		if bone >= numBones {
+			continue
+		}
+
+		if seq.Bones[bone] == nil {
+			seq.Bones[bone] = new(BoneSequence)
+		}
+		seq.Bones[bone].Channels[component] = ch
+	}
+
+	if seq.Header.FrameLength > 0 && int(seq.Header.FrameOffset) <= len(data) {
+		frameData := data[seq.Header.FrameOffset:]
+		for frame := 0; frame < int(seq.Header.NumFrames); frame++ {
+			start := frame * int(seq.Header.FrameLength)
+			if start >= len(frameData) {
+				break
+			}
+
+			bits := newBitReader(frameData[start:])
+			for _, ch := range seq.Channels {
+				if ch, ok := ch.(frameChannel); ok {
+					ch.readFrame(bits)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+/* Evaluate returns the value of each component of the track at the given frame */
+func (bone *BoneSequence) Evaluate(frame int) [4]float32 {
+	var result [4]float32
+	cached := -1
+	for i, ch := range bone.Channels {
+		if ch == nil {
+			continue
+		}
+
+		if _, ok := ch.(*CachedQuaternionChannel); ok {
+			cached = i
+			continue
+		}
+
+		if ch, ok := ch.(*StaticQuaternionChannel); ok {
+			return ch.Quaternion
+		}
+
+		if ch, ok := ch.(*StaticVector3Channel); ok {
+			copy(result[:], ch.Vector[:])
+			return result
+		}
+
+		result[i] = ch.Value(frame, i)
+	}
+
+	if cached != -1 {
+		var sum float32
+		for i, v := range result {
+			if i != cached {
+				sum += v * v
+			}
+		}
+
+		value := float32(math.Sqrt(math.Max(0, float64(1-sum))))
+		if bone.Channels[cached].Type() == CachedQuaternion2 {
+			value = -value
+		}
+		result[cached] = value
+	}
+
+	return result
+}
@@ -0,0 +1,33 @@
+package resource
+
+import (
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+/* Collection64 is the 64 bit equivalent of Collection, as found in PC resources */
+type Collection64 struct {
+	Addr     types.Ptr32
+	_        uint32
+	Count    uint16
+	Capacity uint16
+	_        uint32
+}
+
+func (col *Collection64) Detour(res *Container, callback func() error) error {
+	return res.Detour(col.Addr, callback)
+}
+
+func (col *Collection64) For(res *Container, callback func(i int) error) error {
+	if !col.Addr.Valid() {
+		return nil
+	}
+
+	return col.Detour(res, func() error {
+		for i := 0; i < int(col.Count); i++ {
+			if err := callback(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -5,6 +5,10 @@ import (
 	"embed"
 	"encoding/binary"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
 )
 
 //go:embed res/gtav_aes_key.dat
@@ -20,6 +24,9 @@ const (
 	HashLookupFile      = "res/gtav_hash_lut.dat"
 )
 
+/* KeyDirEnv names a directory of key files to use in place of the embedded ones */
+const KeyDirEnv = "RAGEKIT_KEY_DIR"
+
 type Keys struct {
 	aesKey         []byte
 	ngKeys         [][]byte
@@ -32,17 +39,21 @@ func LoadKeys() (Keys, error) {
 	keys := Keys{}
 
 	// Load AES key
-	keys.aesKey, err = resFS.ReadFile(AESKeyFile)
+	keys.aesKey, err = readKeyFile(AESKeyFile)
 	if err != nil {
 		return keys, fmt.Errorf("reading AES key: %w", err)
 	}
 
 	// Load NG keys
-	ngKeyBytes, err := resFS.ReadFile(NGKeyFile)
+	ngKeyBytes, err := readKeyFile(NGKeyFile)
 	if err != nil {
 		return keys, fmt.Errorf("reading NG key: %w", err)
 	}
 
+	if len(ngKeyBytes) < 101*272 {
+		return keys, fmt.Errorf("reading NG key: expected %v bytes, got %v", 101*272, len(ngKeyBytes))
+	}
+
 	keys.ngKeys = make([][]byte, 101)
 	for i := 0; i < 101; i++ {
 		keys.ngKeys[i] = ngKeyBytes[:272]
@@ -50,7 +61,7 @@ func LoadKeys() (Keys, error) {
 	}
 
 	// Load NG decrypt tables
-	ngTableBytes, err := resFS.ReadFile(NGDecryptTablesFile)
+	ngTableBytes, err := readKeyFile(NGDecryptTablesFile)
 	if err != nil {
 		return keys, fmt.Errorf("reading NG decrypt tables: %w", err)
 	}
@@ -66,7 +77,7 @@ func LoadKeys() (Keys, error) {
 	}
 
 	// Load hash lookup
-	keys.hashLookup, err = resFS.ReadFile(HashLookupFile)
+	keys.hashLookup, err = readKeyFile(HashLookupFile)
 	if err != nil {
 		return keys, fmt.Errorf("reading hash lookup: %w", err)
 	}
@@ -79,3 +90,11 @@ func (k Keys) NgKeyForFile(filename string, length uint32) []byte {
 	keyIdx := (hash + (length) + (101 - 40)) % 0x65
 	return k.ngKeys[keyIdx]
 }
+
+/* readKeyFile reads a key file from the directory in KeyDirEnv if it's set, or the embedded copy otherwise */
+func readKeyFile(name string) ([]byte, error) {
+	if dir := os.Getenv(KeyDirEnv); dir != "" {
+		return ioutil.ReadFile(filepath.Join(dir, path.Base(name)))
+	}
+	return resFS.ReadFile(name)
+}
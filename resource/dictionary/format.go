@@ -0,0 +1,13 @@
+package dictionary
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		dict := new(Dictionary)
+		return dict, dict.Unpack(res)
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "drawable dictionary", Extension: ".ydd", Type: resource.ResourceDrawable, Arch: resource.ArchPC, Unpack: unpack})
+	resource.RegisterFormat(resource.Format{Name: "drawable dictionary", Extension: ".xdd", Type: resource.ResourceDrawable, Arch: resource.Arch360, Unpack: unpack})
+}
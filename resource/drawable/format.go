@@ -0,0 +1,13 @@
+package drawable
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		drawable := new(Drawable)
+		return drawable, drawable.Unpack(res)
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "drawable", Extension: ".ydr", Type: resource.ResourceDrawable, Arch: resource.ArchPC, Unpack: unpack})
+	resource.RegisterFormat(resource.Format{Name: "drawable", Extension: ".xdr", Type: resource.ResourceDrawable, Arch: resource.Arch360, Unpack: unpack})
+}
@@ -0,0 +1,47 @@
+package expression
+
+import (
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+type DictionaryHeader struct {
+	_           uint32 /* vtable */
+	_           uint32
+	BlockMap    types.Ptr32
+	_           uint32
+	_           uint32
+	_           uint32
+	_           uint32
+	_           uint32
+	NameHashes  resource.Collection64
+	Expressions resource.PointerCollection64
+}
+
+type Dictionary struct {
+	Header      DictionaryHeader
+	NameHashes  []jenkins.Jenkins32
+	Expressions []*Expression
+}
+
+func (dict *Dictionary) Unpack(res *resource.Container) error {
+	res.Parse(&dict.Header)
+
+	dict.NameHashes = make([]jenkins.Jenkins32, dict.Header.NameHashes.Count)
+	if err := dict.Header.NameHashes.For(res, func(i int) error {
+		res.Parse(&dict.NameHashes[i])
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	dict.Expressions = make([]*Expression, dict.Header.Expressions.Count)
+	return dict.Header.Expressions.For(res, func(i int) error {
+		dict.Expressions[i] = new(Expression)
+		if i < len(dict.NameHashes) {
+			dict.Expressions[i].Hash = dict.NameHashes[i]
+		}
+		return dict.Expressions[i].Unpack(res)
+	})
+}
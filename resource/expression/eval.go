@@ -0,0 +1,206 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/resource/clip"
+)
+
+var ErrStackUnderflow = errors.New("expression stack underflow")
+
+/* Skeleton provides the bone track values an expression reads and writes */
+type Skeleton interface {
+	Track(track BoneTrack) [4]float32
+	SetTrack(track BoneTrack, value [4]float32)
+}
+
+// Pose is a simple Skeleton which stores track values by bone and track,
+// returning the identity for any track which hasn't been set
+type Pose map[BoneTrack][4]float32
+
+func (pose Pose) Track(track BoneTrack) [4]float32 {
+	track.Flags = 0
+	if value, ok := pose[track]; ok {
+		return value
+	}
+	switch track.Track {
+	case clip.TrackBoneRotation, clip.TrackMoverRotation, clip.TrackFacialRotation, clip.TrackCameraRotation:
+		return [4]float32{0, 0, 0, 1}
+	case clip.TrackBoneScale:
+		return [4]float32{1, 1, 1, 0}
+	}
+	return [4]float32{}
+}
+
+func (pose Pose) SetTrack(track BoneTrack, value [4]float32) {
+	track.Flags = 0
+	pose[track] = value
+}
+
+/* Evaluate runs each of the expression's streams against skel */
+func (expr *Expression) Evaluate(skel Skeleton) error {
+	for _, stream := range expr.Streams {
+		if err := stream.Evaluate(expr, skel); err != nil {
+			return fmt.Errorf("stream %v: %v", stream.Header.Hash, err)
+		}
+	}
+	return nil
+}
+
+type vmStack [][4]float32
+
+func (s *vmStack) push(v [4]float32) {
+	*s = append(*s, v)
+}
+
+/* popN pops n values, returning them in the order they were pushed */
+func (s *vmStack) popN(n int) ([][4]float32, error) {
+	if n > len(*s) {
+		return nil, ErrStackUnderflow
+	}
+	values := make([][4]float32, n)
+	copy(values, (*s)[len(*s)-n:])
+	*s = (*s)[:len(*s)-n]
+	return values, nil
+}
+
+func (stream *Stream) Evaluate(expr *Expression, skel Skeleton) error {
+	var stack vmStack
+
+	track := func(inst Instruction) (BoneTrack, error) {
+		if int(*inst.Ref) >= len(expr.BoneTracks) {
+			return BoneTrack{}, fmt.Errorf("%v: invalid track %v", inst.Op, *inst.Ref)
+		}
+		return expr.BoneTracks[*inst.Ref], nil
+	}
+
+	componentwise := func(a, b [4]float32, f func(a, b float32) float32) [4]float32 {
+		var r [4]float32
+		for i := range r {
+			r[i] = f(a[i], b[i])
+		}
+		return r
+	}
+
+	each := func(a [4]float32, f func(a float32) float32) [4]float32 {
+		for i := range a {
+			a[i] = f(a[i])
+		}
+		return a
+	}
+
+	for _, inst := range stream.Instructions {
+		/* Operands are popped up front, so an underflow is caught before the op does anything */
+		args, err := stack.popN(inst.Op.Pops())
+		if err != nil {
+			return fmt.Errorf("%v: %v", inst.Op, err)
+		}
+
+		switch inst.Op {
+		case OpEnd:
+			return nil
+		case OpPop:
+		case OpDup:
+			stack.push(args[0])
+			stack.push(args[0])
+		case OpPushZero:
+			stack.push([4]float32{})
+		case OpPushOne:
+			stack.push([4]float32{1, 1, 1, 1})
+		case OpPushVector:
+			stack.push(*inst.Vector)
+		case OpGetTrack:
+			t, err := track(inst)
+			if err != nil {
+				return err
+			}
+			stack.push(skel.Track(t))
+		case OpSetTrack:
+			t, err := track(inst)
+			if err != nil {
+				return err
+			}
+			skel.SetTrack(t, args[0])
+		case OpBlendTrack:
+			t, err := track(inst)
+			if err != nil {
+				return err
+			}
+			value, weight := args[0], args[1]
+			current := skel.Track(t)
+			for i := range current {
+				current[i] += (value[i] - current[i]) * weight[0]
+			}
+			skel.SetTrack(t, current)
+		case OpAdd:
+			stack.push(componentwise(args[0], args[1], func(a, b float32) float32 { return a + b }))
+		case OpSub:
+			stack.push(componentwise(args[0], args[1], func(a, b float32) float32 { return a - b }))
+		case OpMul:
+			stack.push(componentwise(args[0], args[1], func(a, b float32) float32 { return a * b }))
+		case OpDiv:
+			stack.push(componentwise(args[0], args[1], func(a, b float32) float32 {
+				if b == 0 {
+					return 0
+				}
+				return a / b
+			}))
+		case OpNeg:
+			stack.push(each(args[0], func(a float32) float32 { return -a }))
+		case OpAbs:
+			stack.push(each(args[0], func(a float32) float32 { return float32(math.Abs(float64(a))) }))
+		case OpMin:
+			stack.push(componentwise(args[0], args[1], func(a, b float32) float32 { return float32(math.Min(float64(a), float64(b))) }))
+		case OpMax:
+			stack.push(componentwise(args[0], args[1], func(a, b float32) float32 { return float32(math.Max(float64(a), float64(b))) }))
+		case OpClamp:
+			a, min, max := args[0], args[1], args[2]
+			for i := range a {
+				a[i] = float32(math.Max(float64(min[i]), math.Min(float64(max[i]), float64(a[i]))))
+			}
+			stack.push(a)
+		case OpLerp:
+			a, b, t := args[0], args[1], args[2]
+			for i := range a {
+				a[i] += (b[i] - a[i]) * t[i]
+			}
+			stack.push(a)
+		case OpSplatX, OpSplatY, OpSplatZ, OpSplatW:
+			c := args[0][inst.Op-OpSplatX]
+			stack.push([4]float32{c, c, c, c})
+		case OpQuatMul:
+			stack.push(fromQuat(toQuat(args[0]).Mul(toQuat(args[1]))))
+		case OpQuatFromEuler:
+			a := args[0]
+			stack.push(fromQuat(mathgl.EulerToQuatf(a[0], a[1], a[2])))
+		case OpEulerFromQuat:
+			q := args[0]
+			x, y, z, w := float64(q[0]), float64(q[1]), float64(q[2]), float64(q[3])
+			stack.push([4]float32{
+				float32(math.Atan2(2*(w*x+y*z), 1-2*(x*x+y*y))),
+				float32(math.Asin(math.Max(-1, math.Min(1, 2*(w*y-z*x))))),
+				float32(math.Atan2(2*(w*z+x*y), 1-2*(y*y+z*z))),
+				0,
+			})
+		case OpQuatSlerp:
+			a, b, t := toQuat(args[0]), toQuat(args[1]), args[2]
+			stack.push(fromQuat(mathgl.QuatSlerpf(a, b, t[0])))
+		default:
+			return fmt.Errorf("unknown op %v", inst.Op)
+		}
+	}
+
+	return nil
+}
+
+func toQuat(v [4]float32) mathgl.Quatf {
+	return mathgl.Quatf{W: v[3], V: mathgl.Vec3f{v[0], v[1], v[2]}}
+}
+
+func fromQuat(q mathgl.Quatf) [4]float32 {
+	return [4]float32{q.V[0], q.V[1], q.V[2], q.W}
+}
@@ -0,0 +1,112 @@
+package expression
+
+import (
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/clip"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+type ExpressionHeader struct {
+	_            uint32 /* vtable */
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	Streams      resource.PointerCollection64
+	BoneTracks   resource.Collection64
+	Springs      resource.Collection64
+	TrackHashes  resource.Collection64
+	Name         types.Ptr32
+	_            uint32
+	NameLength   uint16
+	NameCapacity uint16
+	_            uint32
+	_            uint32
+	_            uint32
+	Signature    uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+	_            uint32
+}
+
+/* BoneTrack identifies a bone transform read or written by an expression */
+type BoneTrack struct {
+	Tag   uint16
+	Track clip.Track
+	Flags uint8
+}
+
+func (t BoneTrack) Name() string {
+	return clip.BoneId{Tag: t.Tag, Track: t.Track}.Name()
+}
+
+/* Spring is a secondary motion spring attached to a bone */
+type Spring struct {
+	Tag       uint16
+	_         uint16
+	_         uint32
+	Strength  [4]float32
+	Damping   [4]float32
+	MinLimits [4]float32
+	MaxLimits [4]float32
+	Gravity   [4]float32
+}
+
+type Expression struct {
+	Header      ExpressionHeader
+	Hash        jenkins.Jenkins32
+	Name        string
+	Streams     []*Stream
+	BoneTracks  []BoneTrack
+	Springs     []Spring
+	TrackHashes []jenkins.Jenkins32
+}
+
+func (expr *Expression) Unpack(res *resource.Container) error {
+	res.Parse(&expr.Header)
+
+	if expr.Header.Name.Valid() {
+		if err := res.Detour(expr.Header.Name, func() error {
+			res.Parse(&expr.Name)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	expr.BoneTracks = make([]BoneTrack, expr.Header.BoneTracks.Count)
+	if err := expr.Header.BoneTracks.For(res, func(i int) error {
+		res.Parse(&expr.BoneTracks[i])
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	expr.Springs = make([]Spring, expr.Header.Springs.Count)
+	if err := expr.Header.Springs.For(res, func(i int) error {
+		res.Parse(&expr.Springs[i])
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	expr.TrackHashes = make([]jenkins.Jenkins32, expr.Header.TrackHashes.Count)
+	if err := expr.Header.TrackHashes.For(res, func(i int) error {
+		res.Parse(&expr.TrackHashes[i])
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	expr.Streams = make([]*Stream, expr.Header.Streams.Count)
+	return expr.Header.Streams.For(res, func(i int) error {
+		expr.Streams[i] = new(Stream)
+		return expr.Streams[i].Unpack(res)
+	})
+}
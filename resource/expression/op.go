@@ -0,0 +1,71 @@
+package expression
+
+//go:generate stringer -type=Op
+
+// Op is an expression stack op. Neither this numbering nor the split of a stream into code, vectors
+// and track references in StreamHeader comes from a documented source, and neither has been checked
+// against expression dictionaries from the game. Stream.Unpack rejects streams which leave operands
+// unused, and Evaluate rejects unknown ops, so a mismatch is reported rather than evaluated
+type Op uint8
+
+const (
+	OpEnd           Op = 0x00
+	OpPop           Op = 0x01
+	OpDup           Op = 0x02
+	OpPushZero      Op = 0x03
+	OpPushOne       Op = 0x04
+	OpPushVector    Op = 0x05
+	OpGetTrack      Op = 0x06
+	OpSetTrack      Op = 0x07
+	OpBlendTrack    Op = 0x08
+	OpAdd           Op = 0x10
+	OpSub           Op = 0x11
+	OpMul           Op = 0x12
+	OpDiv           Op = 0x13
+	OpNeg           Op = 0x14
+	OpAbs           Op = 0x15
+	OpMin           Op = 0x16
+	OpMax           Op = 0x17
+	OpClamp         Op = 0x18
+	OpLerp          Op = 0x19
+	OpSplatX        Op = 0x20
+	OpSplatY        Op = 0x21
+	OpSplatZ        Op = 0x22
+	OpSplatW        Op = 0x23
+	OpQuatMul       Op = 0x28
+	OpQuatFromEuler Op = 0x29
+	OpEulerFromQuat Op = 0x2a
+	OpQuatSlerp     Op = 0x2b
+)
+
+type OperandKind int
+
+const (
+	OperandNone OperandKind = iota
+	OperandVector
+	OperandRef
+)
+
+/* Pops returns the number of values the op takes from the stack */
+func (op Op) Pops() int {
+	switch op {
+	case OpPop, OpDup, OpSetTrack, OpNeg, OpAbs, OpSplatX, OpSplatY, OpSplatZ, OpSplatW, OpQuatFromEuler, OpEulerFromQuat:
+		return 1
+	case OpBlendTrack, OpAdd, OpSub, OpMul, OpDiv, OpMin, OpMax, OpQuatMul:
+		return 2
+	case OpClamp, OpLerp, OpQuatSlerp:
+		return 3
+	}
+	return 0
+}
+
+/* Operand returns the kind of operand consumed by the op */
+func (op Op) Operand() OperandKind {
+	switch op {
+	case OpPushVector:
+		return OperandVector
+	case OpGetTrack, OpSetTrack, OpBlendTrack:
+		return OperandRef
+	}
+	return OperandNone
+}
@@ -0,0 +1,94 @@
+package expression
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+)
+
+// Stream data is split in three: the opcode bytes, the vector immediates
+// and the 16 bit bone track references, each consumed in order as the ops execute
+type StreamHeader struct {
+	Hash         jenkins.Jenkins32
+	_            uint32
+	_            uint32
+	_            uint32
+	CodeLength   uint32
+	VectorLength uint32
+	RefLength    uint16
+	MaxDepth     uint16
+	_            uint32
+}
+
+type Stream struct {
+	Header       StreamHeader
+	Instructions []Instruction
+}
+
+type Instruction struct {
+	Op     Op
+	Vector *[4]float32 `json:",omitempty"`
+	Ref    *uint16     `json:",omitempty"`
+}
+
+func (inst Instruction) String() string {
+	switch {
+	case inst.Vector != nil:
+		return fmt.Sprintf("%v %v", inst.Op, *inst.Vector)
+	case inst.Ref != nil:
+		return fmt.Sprintf("%v %v", inst.Op, *inst.Ref)
+	}
+	return inst.Op.String()
+}
+
+func (stream *Stream) Unpack(res *resource.Container) error {
+	res.Parse(&stream.Header)
+
+	code := make([]byte, stream.Header.CodeLength)
+	vectors := make([][4]float32, stream.Header.VectorLength/16)
+	refs := make([]uint16, stream.Header.RefLength/2)
+
+	res.Parse(code)
+	res.Parse(vectors)
+	res.Parse(refs)
+
+	for _, b := range code {
+		inst := Instruction{Op: Op(b)}
+		switch inst.Op.Operand() {
+		case OperandVector:
+			if len(vectors) == 0 {
+				return fmt.Errorf("%v: vector operand out of range", inst.Op)
+			}
+			inst.Vector = &vectors[0]
+			vectors = vectors[1:]
+		case OperandRef:
+			if len(refs) == 0 {
+				return fmt.Errorf("%v: track operand out of range", inst.Op)
+			}
+			inst.Ref = &refs[0]
+			refs = refs[1:]
+		}
+
+		stream.Instructions = append(stream.Instructions, inst)
+		if inst.Op == OpEnd {
+			break
+		}
+	}
+
+	if len(vectors) != 0 || len(refs) != 0 {
+		return fmt.Errorf("%v vector and %v track operands weren't used by any op", len(vectors), len(refs))
+	}
+
+	return nil
+}
+
+/* Disassemble returns a listing of the stream's instructions */
+func (stream *Stream) Disassemble() string {
+	var buf bytes.Buffer
+	for i, inst := range stream.Instructions {
+		fmt.Fprintf(&buf, "%.4d: %v\n", i, inst)
+	}
+	return buf.String()
+}
@@ -0,0 +1,140 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Formats are registered by the packages which implement them, usually from an init function.
+// A format is resolved from a file's extension, which also determines the architecture, and
+// checked against the type byte in the container header
+
+/* UnpackFunc unpacks a resource of a format from its container */
+type UnpackFunc func(res *Container, name string, size uint32) (interface{}, error)
+
+type Format struct {
+	Name      string
+	Extension string
+	Type      uint8 /* the container type, or 0 if unknown */
+	Arch      Arch
+	Unpack    UnpackFunc
+}
+
+var ErrUnknownFormat error = errors.New("unknown resource format")
+
+var (
+	formats = make(map[string]*Format)
+	order   = make([]string, 0)
+)
+
+/* RegisterFormat adds a format to the registry, replacing any format with the same extension */
+func RegisterFormat(format Format) {
+	format.Extension = strings.ToLower(format.Extension)
+	if _, ok := formats[format.Extension]; !ok {
+		order = append(order, format.Extension)
+	}
+	formats[format.Extension] = &format
+}
+
+/* Formats returns every registered format, ordered by extension */
+func Formats() []*Format {
+	result := make([]*Format, 0, len(formats))
+	for _, format := range formats {
+		result = append(result, format)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Extension < result[j].Extension
+	})
+	return result
+}
+
+/* FormatByExtension returns the format registered for the extension of a file name */
+func FormatByExtension(filename string) (*Format, bool) {
+	format, ok := formats[strings.ToLower(filepath.Ext(filename))]
+	return format, ok
+}
+
+/* FormatByType returns the first registered format with a container type and architecture */
+func FormatByType(typ uint8, arch Arch) (*Format, bool) {
+	for _, ext := range order {
+		format := formats[ext]
+		if format.Type == typ && format.Arch == arch {
+			return format, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveFormat finds the format of a file from its extension, falling back to the type in its
+// header if the extension isn't registered. An error is returned if the two disagree
+func ResolveFormat(filename string, header ContainerHeader) (*Format, error) {
+	format, ok := FormatByExtension(filename)
+	if !ok {
+		if format, ok = FormatByType(header.Type(), ArchPC); !ok {
+			return nil, fmt.Errorf("%v: %v (type %#x)", filename, ErrUnknownFormat, header.Type())
+		}
+		return format, nil
+	}
+
+	if format.Type != 0 && format.Type != header.Type() {
+		return nil, fmt.Errorf("%v: expected %v container type %#x, got %#x", filename, format.Name, format.Type, header.Type())
+	}
+
+	return format, nil
+}
+
+// Open reads a resource file, sets the architecture of its format and unpacks it. The format
+// is returned along with the unpacked value
+func Open(path string) (interface{}, *Format, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return Read(path, data)
+}
+
+/* Read unpacks a resource from the contents of a file with the given name */
+func Read(name string, data []byte) (interface{}, *Format, error) {
+	format, ok := FormatByExtension(name)
+	if !ok {
+		return read(name, data, ArchPC, false)
+	}
+	return read(name, data, format.Arch, false)
+}
+
+/* ReadArch is like Read, but unpacks the resource with the given architecture rather than that of its format */
+func ReadArch(name string, data []byte, arch Arch) (interface{}, *Format, error) {
+	return read(name, data, arch, true)
+}
+
+func read(name string, data []byte, arch Arch, forceArch bool) (value interface{}, format *Format, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v: %v", name, r)
+		}
+	}()
+
+	SetArch(arch)
+
+	res := new(Container)
+	if err = res.Unpack(data, filepath.Base(name), uint32(len(data))); err != nil {
+		return nil, nil, err
+	}
+
+	if format, err = ResolveFormat(name, res.Header); err != nil {
+		return nil, nil, err
+	}
+
+	if !forceArch {
+		SetArch(format.Arch)
+	}
+
+	value, err = format.Unpack(res, name, uint32(len(data)))
+	return value, format, err
+}
@@ -0,0 +1,13 @@
+package frag
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		frag := new(FragType)
+		return frag, frag.Unpack(res)
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "fragment", Extension: ".yft", Type: resource.ResourceFrag, Arch: resource.ArchPC, Unpack: unpack})
+	resource.RegisterFormat(resource.Format{Name: "fragment", Extension: ".xft", Type: resource.ResourceFrag, Arch: resource.Arch360, Unpack: unpack})
+}
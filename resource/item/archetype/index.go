@@ -0,0 +1,182 @@
+package archetype
+
+import (
+	"encoding/gob"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource/item"
+)
+
+const (
+	IndexFileEnv = "RAGEKIT_ARCHETYPE_INDEX"
+)
+
+/* Asset types of an archetype's model */
+const (
+	AssetUninitialized = iota
+	AssetFragment
+	AssetDrawable
+	AssetDrawableDictionary
+	AssetAssetless
+)
+
+/* Entry is the summary of an archetype definition */
+type Entry struct {
+	Name               jenkins.Jenkins32
+	File               string
+	Type               item.SectionType
+	Flags              uint32
+	SpecialAttribute   uint32
+	LodDist            float32
+	BbMin              mathgl.Vec3f
+	BbMax              mathgl.Vec3f
+	BsCentre           mathgl.Vec3f
+	BsRadius           float32
+	TextureDictionary  jenkins.Jenkins32
+	DrawableDictionary jenkins.Jenkins32
+	ClipDictionary     jenkins.Jenkins32
+	PhysicsDictionary  jenkins.Jenkins32
+	AssetType          uint32
+	AssetName          jenkins.Jenkins32
+}
+
+/* Dependency is an asset which an archetype requires */
+type Dependency struct {
+	Kind string
+	Name jenkins.Jenkins32
+}
+
+/* Dependencies returns the assets required to draw the archetype */
+func (e *Entry) Dependencies() []Dependency {
+	deps := make([]Dependency, 0)
+	add := func(kind string, name jenkins.Jenkins32) {
+		if name != 0 {
+			deps = append(deps, Dependency{kind, name})
+		}
+	}
+
+	switch e.AssetType {
+	case AssetFragment:
+		add("yft", e.AssetName)
+	case AssetDrawable:
+		add("ydr", e.AssetName)
+	case AssetDrawableDictionary:
+		add("ydd", e.DrawableDictionary)
+	}
+
+	add("ytd", e.TextureDictionary)
+	add("ycd", e.ClipDictionary)
+	add("ypdb", e.PhysicsDictionary)
+	return deps
+}
+
+/* Index maps archetype names to the ytyp which defines them */
+type Index struct {
+	Entries map[jenkins.Jenkins32]*Entry
+}
+
+func NewIndex() *Index {
+	return &Index{
+		Entries: make(map[jenkins.Jenkins32]*Entry),
+	}
+}
+
+/* AddDefinition adds every archetype of a ytyp to the index */
+func (idx *Index) AddDefinition(file string, def *item.ItemDefinition) error {
+	for _, t := range []item.SectionType{item.CBaseArchetypeDef, item.CTimeArchetypeDef, item.CMloArchetypeDef} {
+		for _, entry := range def.Sections[t] {
+			archetype := new(item.BaseArchetypeDef)
+			if err := entry.Decode(archetype); err != nil {
+				return err
+			}
+
+			idx.Entries[archetype.Name] = &Entry{
+				Name:               archetype.Name,
+				File:               file,
+				Type:               t,
+				Flags:              archetype.Flags,
+				SpecialAttribute:   archetype.SpecialAttribute,
+				LodDist:            archetype.LodDist,
+				BbMin:              archetype.BbMin,
+				BbMax:              archetype.BbMax,
+				BsCentre:           archetype.BsCentre,
+				BsRadius:           archetype.BsRadius,
+				TextureDictionary:  archetype.TextureDictionary,
+				DrawableDictionary: archetype.DrawableDictionary,
+				ClipDictionary:     archetype.ClipDictionary,
+				PhysicsDictionary:  archetype.PhysicsDictionary,
+				AssetType:          archetype.AssetType,
+				AssetName:          archetype.AssetName,
+			}
+		}
+	}
+	return nil
+}
+
+/* Lookup returns the archetype with the given name hash */
+func (idx *Index) Lookup(name jenkins.Jenkins32) (*Entry, bool) {
+	entry, ok := idx.Entries[name]
+	return entry, ok
+}
+
+/* Find returns the archetype with the given name. Names are matched case insensitively, as the game does */
+func (idx *Index) Find(name string) (*Entry, bool) {
+	return idx.Lookup(HashName(name))
+}
+
+/* Defines returns the archetypes defined by a file, sorted by name hash */
+func (idx *Index) Defines(file string) []*Entry {
+	result := make([]*Entry, 0)
+	for _, entry := range idx.Entries {
+		if entry.File == file {
+			result = append(result, entry)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+/* HashName returns the hash of an archetype name */
+func HashName(name string) jenkins.Jenkins32 {
+	hash := jenkins.New()
+	hash.UpdateArray([]byte(strings.ToLower(name)))
+	return hash.HashJenkins32()
+}
+
+// Save writes the index to a file. Gob is used rather than JSON so that hashes
+// are stored as numbers, and not as names which may not hash back to the same value
+func (idx *Index) Save(path string) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	return gob.NewEncoder(fd).Encode(idx)
+}
+
+func Load(path string) (*Index, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	idx := NewIndex()
+	if err := gob.NewDecoder(fd).Decode(idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func LoadFromEnv() (*Index, error) {
+	return Load(os.Getenv(IndexFileEnv))
+}
@@ -0,0 +1,201 @@
+package item
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+)
+
+/* structTypes maps section types to their typed equivalent */
+var structTypes = map[SectionType]reflect.Type{
+	CMapData:                            reflect.TypeOf(MapData{}),
+	CEntityDef:                          reflect.TypeOf(EntityDef{}),
+	CBaseArchetypeDef:                   reflect.TypeOf(BaseArchetypeDef{}),
+	CTimeArchetypeDef:                   reflect.TypeOf(TimeArchetypeDef{}),
+	CMloArchetypeDef:                    reflect.TypeOf(MloArchetypeDef{}),
+	CMloRoomDef:                         reflect.TypeOf(MloRoomDef{}),
+	CMloPortalDef:                       reflect.TypeOf(MloPortalDef{}),
+	CMloEntitySet:                       reflect.TypeOf(MloEntitySet{}),
+	CMloInstanceDef:                     reflect.TypeOf(MloInstanceDef{}),
+	CCarGen:                             reflect.TypeOf(CarGen{}),
+	CExtensionDefLightEffect:            reflect.TypeOf(ExtensionDefLightEffect{}),
+	CExtensionDefAudioCollisionSettings: reflect.TypeOf(ExtensionDefAudioCollisionSettings{}),
+	CExtensionDefAudioEmitter:           reflect.TypeOf(ExtensionDefAudioEmitter{}),
+	CExtensionDefParticleEffect:         reflect.TypeOf(ExtensionDefParticleEffect{}),
+	CExtensionDefLadder:                 reflect.TypeOf(ExtensionDefLadder{}),
+	CExtensionDefBuoyancy:               reflect.TypeOf(ExtensionDefBuoyancy{}),
+	CExtensionDefSpawnPoint:             reflect.TypeOf(ExtensionDefSpawnPoint{}),
+	CExtensionDefExplosionEffect:        reflect.TypeOf(ExtensionDefExplosionEffect{}),
+	CExtensionDefDoor:                   reflect.TypeOf(ExtensionDefDoor{}),
+	CExtensionDefProcObject:             reflect.TypeOf(ExtensionDefProcObject{}),
+	CExtensionDefSpawnPointOverride:     reflect.TypeOf(ExtensionDefSpawnPointOverride{}),
+	CExtensionDefLightShaft:             reflect.TypeOf(ExtensionDefLightShaft{}),
+	CExtensionDefExpression:             reflect.TypeOf(ExtensionDefExpression{}),
+	CExtensionDefWindDisturbance:        reflect.TypeOf(ExtensionDefWindDisturbance{}),
+}
+
+/* StructType returns the typed equivalent of a section type, if there is one */
+func StructType(typ SectionType) (reflect.Type, bool) {
+	t, ok := structTypes[typ]
+	return t, ok
+}
+
+// NewStruct decodes entry into the typed struct for typ.
+// If typ has no typed equivalent, the entry is returned as is
+func NewStruct(typ SectionType, entry SectionEntry) (interface{}, error) {
+	t, ok := structTypes[typ]
+	if !ok {
+		return entry, nil
+	}
+
+	value := reflect.New(t)
+	if err := entry.Decode(value.Interface()); err != nil {
+		return nil, fmt.Errorf("%v: %v", typ, err)
+	}
+	return value.Interface(), nil
+}
+
+var tagHashes sync.Map
+
+/* MetaName returns the field name of a meta tag */
+func MetaName(tag string) FieldName {
+	if name, ok := tagHashes.Load(tag); ok {
+		return name.(FieldName)
+	}
+
+	hash := jenkins.New()
+	hash.UpdateArray([]byte(tag))
+	name := FieldName(hash.HashJenkins32())
+	tagHashes.Store(tag, name)
+	return name
+}
+
+// Decode populates the struct pointed to by dest from the entry's fields.
+// Struct fields are matched by their meta tag, and embedded structs are flattened
+func (s SectionEntry) Decode(dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode: expected pointer to struct, got %v", value.Type())
+	}
+
+	return s.decodeStruct(value.Elem())
+}
+
+func (s SectionEntry) decodeStruct(dest reflect.Value) error {
+	t := dest.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := s.decodeStruct(dest.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag := field.Tag.Get("meta")
+		if tag == "" {
+			continue
+		}
+
+		src, ok := s[MetaName(tag)]
+		if !ok || src == nil {
+			continue
+		}
+
+		if err := decodeValue(dest.Field(i), reflect.ValueOf(src)); err != nil {
+			return fmt.Errorf("%v: %v", tag, err)
+		}
+	}
+	return nil
+}
+
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func decodeValue(dest reflect.Value, src reflect.Value) error {
+	for src.Kind() == reflect.Interface || src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return nil
+		}
+		src = src.Elem()
+	}
+
+	switch value := src.Interface().(type) {
+	case StructValue:
+		if dest.Kind() == reflect.Interface {
+			result, err := NewStruct(value.Type, value.Entry)
+			if err != nil {
+				return err
+			}
+			dest.Set(reflect.ValueOf(result))
+			return nil
+		}
+		return decodeValue(dest, reflect.ValueOf(value.Entry))
+
+	case EnumValue:
+		if dest.Kind() != reflect.Interface && dest.Type() != src.Type() {
+			return decodeValue(dest, reflect.ValueOf(value.Value))
+		}
+
+	case FlagsValue:
+		if dest.Kind() != reflect.Interface && dest.Type() != src.Type() {
+			return decodeValue(dest, reflect.ValueOf(value.Value))
+		}
+	}
+
+	if src.Type().AssignableTo(dest.Type()) {
+		dest.Set(src)
+		return nil
+	}
+
+	switch {
+	case isNumeric(dest.Kind()) && isNumeric(src.Kind()):
+		dest.Set(src.Convert(dest.Type()))
+		return nil
+
+	case dest.Kind() == reflect.Bool && src.Kind() == reflect.Bool:
+		dest.SetBool(src.Bool())
+		return nil
+
+	case dest.Kind() == reflect.Bool && isNumeric(src.Kind()):
+		dest.SetBool(src.Convert(reflect.TypeOf(float64(0))).Float() != 0)
+		return nil
+
+	case dest.Kind() == reflect.String && src.Kind() == reflect.String:
+		dest.SetString(src.String())
+		return nil
+
+	case dest.Kind() == reflect.Array && (src.Kind() == reflect.Array || src.Kind() == reflect.Slice):
+		for i := 0; i < dest.Len() && i < src.Len(); i++ {
+			if err := decodeValue(dest.Index(i), src.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case dest.Kind() == reflect.Slice && (src.Kind() == reflect.Array || src.Kind() == reflect.Slice):
+		slice := reflect.MakeSlice(dest.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := decodeValue(slice.Index(i), src.Index(i)); err != nil {
+				return err
+			}
+		}
+		dest.Set(slice)
+		return nil
+
+	case dest.Kind() == reflect.Struct && src.Type() == reflect.TypeOf(SectionEntry{}):
+		return src.Interface().(SectionEntry).decodeStruct(dest)
+	}
+
+	return fmt.Errorf("unable to decode %v into %v", src.Type(), dest.Type())
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"strings"
 
+	"github.com/tgascoigne/ragekit/jenkins"
 	"github.com/tgascoigne/ragekit/resource"
 	"github.com/tgascoigne/ragekit/resource/types"
 )
@@ -14,9 +17,14 @@ type ItemDefinition struct {
 	FileName       string
 	StringTable    StringTable `json:"-"`
 	Sections       Sections
-	SectionMapPtrs map[SectionType]SectionMapPtr `json:"-"`
+	SectionMapPtrs map[SectionType]SectionMapPtr     `json:"-"`
 	SectionPtrs    []SectionPtr                      `json:"-"`
 	SectionMaps    map[SectionType][]SectionMapField `json:"-"`
+	Enums          map[jenkins.Jenkins32]*EnumInfo   `json:"-"`
+	Root           *StructValue                      `json:",omitempty"`
+
+	/* Legacy is set when any section was read using a fixed layout rather than a field map */
+	Legacy bool `json:"-"`
 }
 
 func NewDefinition(filename string) *ItemDefinition {
@@ -26,6 +34,7 @@ func NewDefinition(filename string) *ItemDefinition {
 		SectionMapPtrs: make(map[SectionType]SectionMapPtr),
 		SectionPtrs:    make([]SectionPtr, 0),
 		SectionMaps:    make(map[SectionType][]SectionMapField),
+		Enums:          make(map[jenkins.Jenkins32]*EnumInfo),
 	}
 }
 
@@ -52,6 +61,25 @@ func (typ *ItemDefinition) Unpack(res *resource.Container) error {
 		return err
 	}
 
+	err = res.Detour(typ.Header.EnumInfoPtr, func() error {
+		for i := 0; i < int(typ.Header.NumEnumInfos); i++ {
+			enumInfoPtr := new(EnumInfoPtr)
+			res.Parse(enumInfoPtr)
+
+			info, err := enumInfoPtr.Unpack(res)
+			if err != nil {
+				return err
+			}
+
+			typ.Enums[info.Hash] = info
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
 	err = res.Detour(typ.Header.SectionsPtr, func() error {
 		for i := 0; i < int(typ.Header.NumSections); i++ {
 			sectionPtr := new(SectionPtr)
@@ -66,22 +94,31 @@ func (typ *ItemDefinition) Unpack(res *resource.Container) error {
 	}
 
 	for _, section := range typ.SectionPtrs {
-		sectionMap, ok := typ.SectionMaps[section.Type]
-		if !ok {
+		if typ.isLegacy(section.Type) {
+			entries, err := typ.unpackLegacy(res, section.Type, section.Ptr, section.Size)
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range entries {
+				typ.Sections.Add(section.Type, entry)
+			}
+			typ.Legacy = true
+			continue
+		}
+
+		if _, ok := typ.SectionMaps[section.Type]; !ok {
 			fmt.Printf("missing section map for section %v\n", section.Type)
 			continue
 		}
 
 		entrySize := typ.SectionMapPtrs[section.Type].EntrySize
-		sectionSize := section.Size
-		fmt.Printf("%v %v %v\n", section.Type, sectionSize, entrySize)
-		numEntries := sectionSize / entrySize
+		numEntries := section.Size / entrySize
 
 		for i := uint32(0); i < numEntries; i++ {
 			baseAddr := section.Ptr + types.Ptr32(i*entrySize)
 
-			entry := make(SectionEntry)
-			err := entry.UnpackFromMap(res, baseAddr, sectionMap)
+			entry, err := typ.unpackStruct(res, section.Type, baseAddr)
 			if err != nil {
 				return err
 			}
@@ -89,11 +126,79 @@ func (typ *ItemDefinition) Unpack(res *resource.Container) error {
 		}
 	}
 
+	/* The root block index is 1 based */
+	if root := int(typ.Header.RootBlockIndex) - 1; root >= 0 && root < len(typ.SectionPtrs) {
+		section := typ.SectionPtrs[root]
+		if typ.isLegacy(section.Type) {
+			layout := legacyLayouts[section.Type]
+			entries, err := typ.unpackLegacy(res, section.Type, section.Ptr, layout.Size)
+			if err != nil {
+				return err
+			}
+
+			typ.Root = &StructValue{Type: section.Type, Entry: entries[0]}
+			typ.linkLegacyRoot()
+			return nil
+		}
+
+		if _, ok := typ.SectionMaps[section.Type]; !ok {
+			return nil
+		}
+
+		entry, err := typ.unpackStruct(res, section.Type, section.Ptr)
+		if err != nil {
+			return err
+		}
+		typ.Root = &StructValue{Type: section.Type, Entry: entry}
+	}
+
 	return nil
 }
 
+// Open reads an item definition from a ymap or ytyp, or a JSON file written by Dump. Both the
+// current layout and the legacy layout without field maps are accepted
+func Open(path string) (*ItemDefinition, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return Load(path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(path, data)
+}
+
+/* Read unpacks an item definition from the contents of a resource file */
+func Read(name string, data []byte) (def *ItemDefinition, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v: %v", name, r)
+		}
+	}()
+
+	res := new(resource.Container)
+	if err = res.Unpack(data, filepath.Base(name), uint32(len(data))); err != nil {
+		return nil, err
+	}
+
+	def = NewDefinition(name)
+	if err = def.Unpack(res); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+/* dumpedDefinition is the JSON form of a definition, which carries the schema needed to pack it again */
+type dumpedDefinition struct {
+	*ItemDefinition
+	Schema *Schema
+}
+
 func (typ *ItemDefinition) Dump(path string) error {
-	data, err := json.MarshalIndent(typ, "", "\t")
+	data, err := json.MarshalIndent(dumpedDefinition{typ, typ.Schema()}, "", "\t")
 	if err != nil {
 		return err
 	}
@@ -106,3 +211,64 @@ func (typ *ItemDefinition) Dump(path string) error {
 
 	return nil
 }
+
+/* SetRoot replaces the root structure of the definition. root may be a typed struct, a StructValue or a decoded JSON object */
+func (typ *ItemDefinition) SetRoot(root interface{}) error {
+	value, err := toStructValue(root)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := typ.SectionMaps[value.Type]; !ok {
+		return fmt.Errorf("missing section map for %v", value.Type)
+	}
+
+	typ.Root = value
+	return nil
+}
+
+/* Load reads a definition previously written by Dump */
+func Load(path string) (*ItemDefinition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var dumped struct {
+		FileName string
+		Schema   *Schema
+		Root     map[string]interface{}
+	}
+
+	if err := json.Unmarshal(data, &dumped); err != nil {
+		return nil, err
+	}
+
+	if dumped.Schema == nil || dumped.Root == nil {
+		return nil, fmt.Errorf("%v: missing schema or root", path)
+	}
+
+	typ := NewDefinition(dumped.FileName)
+	typ.SetSchema(dumped.Schema)
+	if err := typ.SetRoot(dumped.Root); err != nil {
+		return nil, err
+	}
+
+	/* Round trip through the binary form so that values take on their proper types */
+	packed, err := typ.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(resource.Container)
+	if err := res.Unpack(packed, dumped.FileName, uint32(len(packed))); err != nil {
+		return nil, err
+	}
+
+	result := NewDefinition(dumped.FileName)
+	if err := result.Unpack(res); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,304 @@
+package item
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+)
+
+// Conversions from the values accepted by the writer. Values may be those produced by Unpack,
+// generic values decoded from JSON, or the fields of the typed structs
+
+/* Encode converts a typed struct into a SectionEntry. It is the inverse of Decode */
+func Encode(src interface{}) (SectionEntry, error) {
+	value := reflect.ValueOf(src)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encode: expected struct, got %v", value.Type())
+	}
+
+	entry := make(SectionEntry)
+	encodeStruct(entry, value)
+	return entry, nil
+}
+
+func encodeStruct(entry SectionEntry, value reflect.Value) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			encodeStruct(entry, value.Field(i))
+			continue
+		}
+
+		tag := field.Tag.Get("meta")
+		if tag == "" {
+			continue
+		}
+
+		entry[MetaName(tag)] = encodeValue(value.Field(i))
+	}
+}
+
+func encodeValue(value reflect.Value) FieldValue {
+	switch value.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+
+		if t, ok := typedSectionType(value.Elem().Type()); ok {
+			entry, _ := Encode(value.Interface())
+			return StructValue{Type: t, Entry: entry}
+		}
+		return encodeValue(value.Elem())
+
+	case reflect.Struct:
+		entry := make(SectionEntry)
+		encodeStruct(entry, value)
+		return entry
+
+	case reflect.Slice:
+		list := make([]FieldValue, value.Len())
+		for i := range list {
+			list[i] = encodeValue(value.Index(i))
+		}
+		return list
+	}
+
+	return value.Interface()
+}
+
+/* typedSectionType returns the section type of a typed struct */
+func typedSectionType(t reflect.Type) (SectionType, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for section, structType := range structTypes {
+		if structType == t {
+			return section, true
+		}
+	}
+	return 0, false
+}
+
+func toEntry(value FieldValue) (SectionEntry, error) {
+	switch value := value.(type) {
+	case nil:
+		return SectionEntry{}, nil
+	case SectionEntry:
+		return value, nil
+	case StructValue:
+		return value.Entry, nil
+	case *StructValue:
+		return value.Entry, nil
+	case map[string]interface{}:
+		entry := make(SectionEntry)
+		for k, v := range value {
+			entry[FieldName(jenkins.Parse(k))] = v
+		}
+		return entry, nil
+	}
+
+	if _, ok := typedSectionType(reflect.TypeOf(value)); ok {
+		return Encode(value)
+	}
+	return nil, fmt.Errorf("can't convert %T to a struct", value)
+}
+
+func toStructValue(value FieldValue) (*StructValue, error) {
+	switch value := value.(type) {
+	case StructValue:
+		return &value, nil
+	case *StructValue:
+		return value, nil
+	case map[string]interface{}:
+		name, ok := value["Type"].(string)
+		if !ok {
+			return nil, fmt.Errorf("struct pointer has no type")
+		}
+
+		entry, err := toEntry(value["Entry"])
+		if err != nil {
+			return nil, err
+		}
+		return &StructValue{Type: ParseSectionType(name), Entry: entry}, nil
+	}
+
+	if t, ok := typedSectionType(reflect.TypeOf(value)); ok {
+		entry, err := Encode(value)
+		return &StructValue{Type: t, Entry: entry}, err
+	}
+	return nil, fmt.Errorf("can't convert %T to a struct pointer", value)
+}
+
+func toFloat(value FieldValue) (float64, error) {
+	switch value := value.(type) {
+	case nil:
+		return 0, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	case EnumValue:
+		return float64(value.Value), nil
+	case FlagsValue:
+		return float64(value.Value), nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("can't convert %T to a number", value)
+}
+
+func toInt(value FieldValue) (int64, error) {
+	f, err := toFloat(value)
+	return int64(f), err
+}
+
+func toHash(value FieldValue) (jenkins.Jenkins32, error) {
+	if s, ok := value.(string); ok {
+		return jenkins.Parse(s), nil
+	}
+
+	i, err := toInt(value)
+	return jenkins.Jenkins32(uint32(i)), err
+}
+
+func toVec(value FieldValue) ([4]float32, error) {
+	var result [4]float32
+
+	list, err := toList(value)
+	if err != nil {
+		return result, err
+	}
+
+	for i := 0; i < len(list) && i < len(result); i++ {
+		if list[i] == nil {
+			/* NaN is written out as null */
+			result[i] = float32(math.NaN())
+			continue
+		}
+
+		f, err := toFloat(list[i])
+		if err != nil {
+			return result, err
+		}
+		result[i] = float32(f)
+	}
+	return result, nil
+}
+
+/* toMatrix converts a list of up to four rows to a matrix */
+func toMatrix(value FieldValue) ([4][4]float32, error) {
+	var result [4][4]float32
+
+	rows, err := toList(value)
+	if err != nil {
+		return result, err
+	}
+
+	for i := 0; i < len(rows) && i < len(result); i++ {
+		if result[i], err = toVec(rows[i]); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func toList(value FieldValue) ([]FieldValue, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("can't convert %T to a list", value)
+	}
+
+	list := make([]FieldValue, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list, nil
+}
+
+func toString(value FieldValue) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	}
+	return "", fmt.Errorf("can't convert %T to a string", value)
+}
+
+func toBytes(value FieldValue) ([]byte, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return value, nil
+	case string:
+		return base64.StdEncoding.DecodeString(value)
+	}
+	return nil, fmt.Errorf("can't convert %T to bytes", value)
+}
+
+func toEnum(info *EnumInfo, value FieldValue) (int32, error) {
+	var name jenkins.Jenkins32
+	switch value := value.(type) {
+	case string:
+		name = jenkins.Parse(value)
+	case jenkins.Jenkins32:
+		name = value
+	default:
+		i, err := toInt(value)
+		return int32(i), err
+	}
+
+	if info == nil {
+		return 0, fmt.Errorf("unknown enum for %v", name)
+	}
+
+	v, ok := info.Value(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown enum value %v", name)
+	}
+	return v, nil
+}
+
+func toFlags(info *EnumInfo, value FieldValue) (uint32, error) {
+	switch value.(type) {
+	case []interface{}, []jenkins.Jenkins32:
+		names, _ := toList(value)
+		var result uint32
+		for _, name := range names {
+			bit, err := toEnum(info, name)
+			if err != nil {
+				return 0, err
+			}
+			result |= 1 << uint32(bit)
+		}
+		return result, nil
+	}
+
+	i, err := toInt(value)
+	return uint32(i), err
+}
@@ -0,0 +1,121 @@
+package item
+
+import (
+	"encoding/json"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+type EnumInfoPtr struct {
+	Hash       jenkins.Jenkins32
+	Key        uint32
+	Ptr        types.Ptr32
+	_          uint32
+	NumEntries int32
+	_          uint32
+}
+
+type EnumEntry struct {
+	Name  jenkins.Jenkins32
+	Value int32
+}
+
+type EnumInfo struct {
+	Hash    jenkins.Jenkins32
+	Key     uint32
+	Entries []EnumEntry
+}
+
+func (e EnumInfoPtr) Unpack(res *resource.Container) (*EnumInfo, error) {
+	info := &EnumInfo{
+		Hash:    e.Hash,
+		Key:     e.Key,
+		Entries: make([]EnumEntry, e.NumEntries),
+	}
+
+	err := res.Detour(e.Ptr, func() error {
+		res.Parse(info.Entries)
+		return nil
+	})
+	return info, err
+}
+
+/* Name returns the name of the entry with the given value */
+func (e *EnumInfo) Name(value int32) (jenkins.Jenkins32, bool) {
+	for _, entry := range e.Entries {
+		if entry.Value == value {
+			return entry.Name, true
+		}
+	}
+	return 0, false
+}
+
+/* Value returns the value of the named entry */
+func (e *EnumInfo) Value(name jenkins.Jenkins32) (int32, bool) {
+	for _, entry := range e.Entries {
+		if entry.Name == name {
+			return entry.Value, true
+		}
+	}
+	return 0, false
+}
+
+/* EnumValue is the value of an enum field, along with the name of the matching entry if known */
+type EnumValue struct {
+	Value int32
+	Name  jenkins.Jenkins32
+	Named bool
+}
+
+func (e EnumValue) MarshalJSON() ([]byte, error) {
+	if e.Named {
+		return json.Marshal(e.Name)
+	}
+	return json.Marshal(e.Value)
+}
+
+/* FlagsValue is the value of a bitset field, along with the names of each set bit if known */
+type FlagsValue struct {
+	Value uint32
+	Names []jenkins.Jenkins32
+}
+
+func (f FlagsValue) MarshalJSON() ([]byte, error) {
+	if f.Names != nil {
+		return json.Marshal(f.Names)
+	}
+	return json.Marshal(f.Value)
+}
+
+func newEnumValue(info *EnumInfo, value int32) EnumValue {
+	result := EnumValue{Value: value}
+	if info != nil {
+		result.Name, result.Named = info.Name(value)
+	}
+	return result
+}
+
+func newFlagsValue(info *EnumInfo, value uint32) FlagsValue {
+	result := FlagsValue{Value: value}
+	if info == nil {
+		return result
+	}
+
+	result.Names = make([]jenkins.Jenkins32, 0)
+	for bit := int32(0); bit < 32; bit++ {
+		if value&(1<<uint(bit)) == 0 {
+			continue
+		}
+
+		name, ok := info.Name(bit)
+		if !ok {
+			/* Not every bit is named; fall back to the raw value */
+			result.Names = nil
+			break
+		}
+		result.Names = append(result.Names, name)
+	}
+	return result
+}
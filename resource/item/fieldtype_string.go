@@ -4,40 +4,39 @@ package item
 
 import "fmt"
 
-const (
-	_FieldType_name_0 = "FieldUint32FieldFlags32"
-	_FieldType_name_1 = "FieldFloat32"
-	_FieldType_name_2 = "FieldVec4f"
-	_FieldType_name_3 = "FieldJenkins"
-	_FieldType_name_4 = "FieldUnknown2"
-	_FieldType_name_5 = "FieldUnknown1"
-)
+const _FieldType_name = "FieldBoolFieldStructFieldStructPtrFieldInt8FieldUint8FieldInt16FieldUint16FieldUint32FieldFlags32FieldFloat32FieldVec2fFieldVec4fFieldVec4fXYZWFieldMat34FieldCharArrayFieldCharPtrFieldJenkinsFieldFixedArrayFieldArrayFieldDataBlockPtrFieldByteEnumFieldIntEnumFieldIntFlags1FieldShortFlagsFieldIntFlags2"
 
-var (
-	_FieldType_index_0 = [...]uint8{0, 11, 23}
-	_FieldType_index_1 = [...]uint8{0, 12}
-	_FieldType_index_2 = [...]uint8{0, 10}
-	_FieldType_index_3 = [...]uint8{0, 12}
-	_FieldType_index_4 = [...]uint8{0, 13}
-	_FieldType_index_5 = [...]uint8{0, 13}
-)
+var _FieldType_map = map[FieldType]string{
+	1:   _FieldType_name[0:9],
+	5:   _FieldType_name[9:20],
+	7:   _FieldType_name[20:34],
+	16:  _FieldType_name[34:43],
+	17:  _FieldType_name[43:53],
+	18:  _FieldType_name[53:63],
+	19:  _FieldType_name[63:74],
+	20:  _FieldType_name[74:85],
+	21:  _FieldType_name[85:97],
+	33:  _FieldType_name[97:109],
+	50:  _FieldType_name[109:119],
+	51:  _FieldType_name[119:129],
+	52:  _FieldType_name[129:143],
+	53:  _FieldType_name[143:153],
+	64:  _FieldType_name[153:167],
+	68:  _FieldType_name[167:179],
+	74:  _FieldType_name[179:191],
+	80:  _FieldType_name[191:206],
+	82:  _FieldType_name[206:216],
+	89:  _FieldType_name[216:233],
+	96:  _FieldType_name[233:246],
+	98:  _FieldType_name[246:258],
+	99:  _FieldType_name[258:272],
+	100: _FieldType_name[272:287],
+	101: _FieldType_name[287:301],
+}
 
 func (i FieldType) String() string {
-	switch {
-	case 20 <= i && i <= 21:
-		i -= 20
-		return _FieldType_name_0[_FieldType_index_0[i]:_FieldType_index_0[i+1]]
-	case i == 33:
-		return _FieldType_name_1
-	case i == 51:
-		return _FieldType_name_2
-	case i == 74:
-		return _FieldType_name_3
-	case i == 82:
-		return _FieldType_name_4
-	case i == 98:
-		return _FieldType_name_5
-	default:
-		return fmt.Sprintf("FieldType(%d)", i)
+	if str, ok := _FieldType_map[i]; ok {
+		return str
 	}
+	return fmt.Sprintf("FieldType(%d)", i)
 }
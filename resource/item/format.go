@@ -0,0 +1,14 @@
+package item
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		def := NewDefinition(name)
+		return def, def.Unpack(res)
+	}
+
+	/* Item definitions are only found in PC resources */
+	resource.RegisterFormat(resource.Format{Name: "map", Extension: ".ymap", Type: resource.ResourceMap, Arch: resource.ArchPC, Unpack: unpack})
+	resource.RegisterFormat(resource.Format{Name: "archetype definitions", Extension: ".ytyp", Type: resource.ResourceMap, Arch: resource.ArchPC, Unpack: unpack})
+}
@@ -8,14 +8,14 @@ type Header struct {
 	Unk3Ptr types.Ptr32
 	Unk4    uint32
 
-	Unk4Ptr types.Ptr32
-	Unk5    uint32
-	Unk6    uint32
-	Unk7    uint32
+	Unk4Ptr        types.Ptr32
+	Unk5           uint32
+	Unk6           uint32
+	RootBlockIndex uint32
 
 	SectionDefPtr types.Ptr32
 	Unk9          uint32
-	Unk10Ptr      types.Ptr32
+	EnumInfoPtr   types.Ptr32
 	Unk11         uint32
 
 	SectionsPtr types.Ptr32
@@ -26,7 +26,7 @@ type Header struct {
 	Unk16          types.Ptr32
 	Unk17          uint32
 	NumSectionDefs uint16
-	UnkCount19     uint16
+	NumEnumInfos   uint16
 	NumSections    uint16
 	Unk21          uint16
 }
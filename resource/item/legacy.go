@@ -0,0 +1,220 @@
+package item
+
+import (
+	"fmt"
+
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+// Older definitions carry struct infos without any field maps, so their sections can't be
+// decoded generically. These were previously read by a separate parser with hardcoded layouts,
+// which are kept here and converted into the typed structs
+
+/* legacyLayout reads a single entry of a section type with a fixed layout */
+type legacyLayout struct {
+	Size  uint32
+	Parse func(res *resource.Container) interface{}
+}
+
+var legacyLayouts = map[SectionType]legacyLayout{
+	CEntityDef: {0x80, func(res *resource.Container) interface{} {
+		entry := new(legacyEntityDef)
+		res.Parse(entry)
+		return entry.typed()
+	}},
+	CMapData: {0x70, func(res *resource.Container) interface{} {
+		entry := new(legacyMapData)
+		res.Parse(entry)
+		return entry.typed()
+	}},
+	CBaseArchetypeDef: {0x90, func(res *resource.Container) interface{} {
+		entry := new(legacyBaseArchetypeDef)
+		res.Parse(entry)
+		return entry.typed()
+	}},
+	CTimeArchetypeDef: {0xa0, func(res *resource.Container) interface{} {
+		entry := new(legacyTimeArchetypeDef)
+		res.Parse(entry)
+		return &TimeArchetypeDef{
+			BaseArchetypeDef: *entry.Base.typed(),
+			TimeFlags:        entry.TimeFlags,
+		}
+	}},
+}
+
+/* legacyEntityDef was InstSection */
+type legacyEntityDef struct {
+	_                          [2]uint32
+	ArchetypeName              jenkins.Jenkins32
+	Flags                      uint32
+	Guid                       uint32
+	_                          [3]uint32
+	Position                   mathgl.Vec4f
+	Rotation                   mathgl.Vec4f
+	ScaleXY                    float32
+	ScaleZ                     float32
+	ParentIndex                int32
+	LodDist                    float32
+	ChildLodDist               float32
+	LodLevel                   uint32
+	NumChildren                uint32
+	PriorityLevel              uint32
+	_                          [4]uint32 /* extensions, which can't be read without a field map */
+	AmbientOcclusionMultiplier int32
+	ArtificialAmbientOcclusion int32
+	TintValue                  uint32
+	_                          uint32
+}
+
+func (e *legacyEntityDef) typed() *EntityDef {
+	return &EntityDef{
+		ArchetypeName:              e.ArchetypeName,
+		Flags:                      e.Flags,
+		Guid:                       e.Guid,
+		Position:                   vec3(e.Position),
+		Rotation:                   e.Rotation,
+		ScaleXY:                    e.ScaleXY,
+		ScaleZ:                     e.ScaleZ,
+		ParentIndex:                e.ParentIndex,
+		LodDist:                    e.LodDist,
+		ChildLodDist:               e.ChildLodDist,
+		LodLevel:                   e.LodLevel,
+		NumChildren:                e.NumChildren,
+		PriorityLevel:              e.PriorityLevel,
+		AmbientOcclusionMultiplier: e.AmbientOcclusionMultiplier,
+		ArtificialAmbientOcclusion: e.ArtificialAmbientOcclusion,
+		TintValue:                  e.TintValue,
+	}
+}
+
+/* legacyMapData was LODSection */
+type legacyMapData struct {
+	_                   [2]uint32
+	Name                jenkins.Jenkins32
+	Parent              jenkins.Jenkins32
+	Flags               uint32
+	ContentFlags        uint32
+	_                   [2]uint32
+	StreamingExtentsMin mathgl.Vec4f
+	StreamingExtentsMax mathgl.Vec4f
+	EntitiesExtentsMin  mathgl.Vec4f
+	EntitiesExtentsMax  mathgl.Vec4f
+	_                   [8]uint16
+}
+
+func (m *legacyMapData) typed() *MapData {
+	return &MapData{
+		Name:                m.Name,
+		Parent:              m.Parent,
+		Flags:               m.Flags,
+		ContentFlags:        m.ContentFlags,
+		StreamingExtentsMin: vec3(m.StreamingExtentsMin),
+		StreamingExtentsMax: vec3(m.StreamingExtentsMax),
+		EntitiesExtentsMin:  vec3(m.EntitiesExtentsMin),
+		EntitiesExtentsMax:  vec3(m.EntitiesExtentsMax),
+	}
+}
+
+/* legacyBaseArchetypeDef was OBJSection */
+type legacyBaseArchetypeDef struct {
+	_                  [2]uint32
+	LodDist            float32
+	Flags              uint32
+	SpecialAttribute   uint32
+	_                  [3]uint32
+	BbMin              mathgl.Vec4f
+	BbMax              mathgl.Vec4f
+	BsCentre           mathgl.Vec4f
+	BsRadius           float32
+	HdTextureDist      float32
+	Name               jenkins.Jenkins32
+	TextureDictionary  jenkins.Jenkins32
+	ClipDictionary     jenkins.Jenkins32
+	DrawableDictionary jenkins.Jenkins32
+	PhysicsDictionary  jenkins.Jenkins32
+	AssetType          uint32
+	AssetName          jenkins.Jenkins32
+	_                  [3]uint32
+	_                  [4]uint32 /* extensions */
+}
+
+func (a *legacyBaseArchetypeDef) typed() *BaseArchetypeDef {
+	return &BaseArchetypeDef{
+		LodDist:            a.LodDist,
+		Flags:              a.Flags,
+		SpecialAttribute:   a.SpecialAttribute,
+		BbMin:              vec3(a.BbMin),
+		BbMax:              vec3(a.BbMax),
+		BsCentre:           vec3(a.BsCentre),
+		BsRadius:           a.BsRadius,
+		HdTextureDist:      a.HdTextureDist,
+		Name:               a.Name,
+		TextureDictionary:  a.TextureDictionary,
+		ClipDictionary:     a.ClipDictionary,
+		DrawableDictionary: a.DrawableDictionary,
+		PhysicsDictionary:  a.PhysicsDictionary,
+		AssetType:          a.AssetType,
+		AssetName:          a.AssetName,
+	}
+}
+
+/* legacyTimeArchetypeDef was TOBJSection */
+type legacyTimeArchetypeDef struct {
+	Base      legacyBaseArchetypeDef
+	TimeFlags uint32
+	_         [3]uint32
+}
+
+func vec3(v mathgl.Vec4f) mathgl.Vec3f {
+	return mathgl.Vec3f{v[0], v[1], v[2]}
+}
+
+/* isLegacy returns true if a section type has no field map to decode it with, but does have a fixed layout */
+func (typ *ItemDefinition) isLegacy(t SectionType) bool {
+	if _, ok := legacyLayouts[t]; !ok {
+		return false
+	}
+
+	fields, ok := typ.SectionMaps[t]
+	return !ok || len(fields) == 0
+}
+
+/* unpackLegacy reads the entries of a section using its fixed layout */
+func (typ *ItemDefinition) unpackLegacy(res *resource.Container, t SectionType, ptr types.Ptr32, size uint32) ([]SectionEntry, error) {
+	layout := legacyLayouts[t]
+	if size%layout.Size != 0 {
+		return nil, fmt.Errorf("%v: section size %v is not a multiple of %v", t, size, layout.Size)
+	}
+
+	entries := make([]SectionEntry, 0, size/layout.Size)
+	err := res.Detour(ptr, func() error {
+		for i := uint32(0); i < size/layout.Size; i++ {
+			entry, err := Encode(layout.Parse(res))
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+/* linkLegacyRoot fills in the entities of a legacy map, which aren't referenced by its root */
+func (typ *ItemDefinition) linkLegacyRoot() {
+	if typ.Root == nil || typ.Root.Type != CMapData {
+		return
+	}
+
+	entities := make([]FieldValue, 0, len(typ.Sections[CEntityDef]))
+	for _, entry := range typ.Sections[CEntityDef] {
+		entities = append(entities, StructValue{Type: CEntityDef, Entry: entry})
+	}
+
+	typ.Root.Entry[MetaName("entities")] = entities
+}
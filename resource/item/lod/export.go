@@ -0,0 +1,37 @@
+package lod
+
+import (
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+)
+
+// Export returns a scene with a model for each map, made up of the outlines of its
+// streaming extents (the first mesh) and entity extents (the second mesh)
+func (tree *Tree) Export(name string) *export.Scene {
+	scene := export.NewScene(name)
+
+	for _, m := range tree.sortedMaps() {
+		model := export.NewModel()
+		model.Name = m.Name.String()
+
+		streaming := export.NewMesh()
+		streaming.Format = export.VertXYZ
+		streaming.AddBoxOutline(m.Data.StreamingExtentsMin, m.Data.StreamingExtentsMax)
+		model.AddMesh(streaming)
+
+		entities := export.NewMesh()
+		entities.Format = export.VertXYZ
+		entities.AddBoxOutline(m.Data.EntitiesExtentsMin, m.Data.EntitiesExtentsMax)
+		model.AddMesh(entities)
+
+		scene.AddInstance(&export.Instance{
+			Name:     model.Name,
+			Model:    scene.AddModel(model),
+			Rotation: mathgl.QuatIdentf(),
+			Scale:    mathgl.Vec3f{1, 1, 1},
+		})
+	}
+
+	return scene
+}
@@ -0,0 +1,203 @@
+package lod
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource/item"
+)
+
+//go:generate stringer -type=Level
+
+/* Level is the LOD type of an entity */
+type Level uint32
+
+const (
+	LevelHD       Level = 0
+	LevelLOD      Level = 1
+	LevelSLOD1    Level = 2
+	LevelSLOD2    Level = 3
+	LevelSLOD3    Level = 4
+	LevelOrphanHD Level = 5
+	LevelSLOD4    Level = 6
+)
+
+/* Depth returns the position of the level in the hierarchy, with HD at 0 */
+func (l Level) Depth() int {
+	switch l {
+	case LevelHD, LevelOrphanHD:
+		return 0
+	case LevelSLOD4:
+		return 5
+	}
+	return int(l)
+}
+
+/* Node is an entity in the LOD hierarchy */
+type Node struct {
+	Map      *Map
+	Index    int
+	Entity   *item.EntityDef
+	Parent   *Node
+	Children []*Node
+}
+
+func (n *Node) Level() Level {
+	return Level(n.Entity.LodLevel)
+}
+
+func (n *Node) String() string {
+	return fmt.Sprintf("%v[%v] %v (%v)", n.Map.Name, n.Index, n.Entity.ArchetypeName, n.Level())
+}
+
+/* Map is a ymap and its entities */
+type Map struct {
+	Name   jenkins.Jenkins32
+	File   string
+	Data   *item.MapData
+	Parent *Map
+	Nodes  []*Node
+}
+
+/* Tree is the LOD hierarchy of a set of ymaps, linked through the parent of each map */
+type Tree struct {
+	Maps  map[jenkins.Jenkins32]*Map
+	Roots []*Node
+
+	problems []Problem
+}
+
+func NewTree() *Tree {
+	return &Tree{
+		Maps:  make(map[jenkins.Jenkins32]*Map),
+		Roots: make([]*Node, 0),
+	}
+}
+
+/* AddMap adds the entities of a ymap to the tree. Link must be called once all maps are added */
+func (tree *Tree) AddMap(file string, def *item.ItemDefinition) error {
+	data, err := def.MapData()
+	if err != nil {
+		return err
+	}
+
+	m := &Map{
+		Name:  data.Name,
+		File:  file,
+		Data:  data,
+		Nodes: make([]*Node, 0, len(data.Entities)),
+	}
+
+	for i, value := range data.Entities {
+		var entity *item.EntityDef
+		switch value := value.(type) {
+		case *item.EntityDef:
+			entity = value
+		case *item.MloInstanceDef:
+			entity = &value.EntityDef
+		default:
+			return fmt.Errorf("%v: unexpected entity type %T", file, value)
+		}
+
+		m.Nodes = append(m.Nodes, &Node{
+			Map:      m,
+			Index:    i,
+			Entity:   entity,
+			Children: make([]*Node, 0),
+		})
+	}
+
+	tree.Maps[m.Name] = m
+	return nil
+}
+
+/* Link resolves the parent of each map and entity, and returns any problems found along the way */
+func (tree *Tree) Link() []Problem {
+	tree.problems = make([]Problem, 0)
+	tree.Roots = tree.Roots[:0]
+
+	for _, m := range tree.sortedMaps() {
+		m.Parent = nil
+		if m.Data.Parent != 0 {
+			m.Parent = tree.Maps[m.Data.Parent]
+		}
+
+		for _, node := range m.Nodes {
+			node.Parent = nil
+			node.Children = node.Children[:0]
+		}
+	}
+
+	for _, m := range tree.sortedMaps() {
+		for _, node := range m.Nodes {
+			idx := int(node.Entity.ParentIndex)
+			switch {
+			case idx < 0:
+				tree.Roots = append(tree.Roots, node)
+
+			case m.Data.Parent == 0:
+				tree.report(ProblemInvalidParent, node, "parent index %v, but map has no parent", idx)
+				tree.Roots = append(tree.Roots, node)
+
+			case m.Parent == nil:
+				tree.report(ProblemMissingParent, node, "parent map %v is not loaded", m.Data.Parent)
+				tree.Roots = append(tree.Roots, node)
+
+			case idx >= len(m.Parent.Nodes):
+				tree.report(ProblemInvalidParent, node, "parent index %v out of range of %v", idx, m.Parent.Name)
+				tree.Roots = append(tree.Roots, node)
+
+			default:
+				node.Parent = m.Parent.Nodes[idx]
+				node.Parent.Children = append(node.Parent.Children, node)
+			}
+		}
+	}
+
+	return tree.problems
+}
+
+/* Nodes returns every node of the tree, ordered by map name and index */
+func (tree *Tree) Nodes() []*Node {
+	nodes := make([]*Node, 0)
+	for _, m := range tree.sortedMaps() {
+		nodes = append(nodes, m.Nodes...)
+	}
+	return nodes
+}
+
+/* Walk visits each node depth first, starting from the roots */
+func (tree *Tree) Walk(visit func(node *Node, depth int)) {
+	var walk func(node *Node, depth int)
+	walk = func(node *Node, depth int) {
+		visit(node, depth)
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, root := range tree.Roots {
+		walk(root, 0)
+	}
+}
+
+func (tree *Tree) sortedMaps() []*Map {
+	maps := make([]*Map, 0, len(tree.Maps))
+	for _, m := range tree.Maps {
+		maps = append(maps, m)
+	}
+
+	sort.Slice(maps, func(i, j int) bool {
+		return maps[i].File < maps[j].File
+	})
+	return maps
+}
+
+func (tree *Tree) report(kind ProblemKind, node *Node, format string, args ...interface{}) {
+	tree.problems = append(tree.problems, Problem{
+		Kind:    kind,
+		Node:    node,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
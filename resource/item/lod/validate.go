@@ -0,0 +1,113 @@
+package lod
+
+import (
+	"fmt"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+)
+
+//go:generate stringer -type=ProblemKind
+
+type ProblemKind int
+
+const (
+	ProblemInvalidParent ProblemKind = iota
+	ProblemMissingParent
+	ProblemOrphaned
+	ProblemChildless
+	ProblemLevel
+	ProblemChildCount
+	ProblemDistance
+	ProblemGap
+	ProblemOverlap
+	ProblemExtents
+)
+
+type Problem struct {
+	Kind    ProblemKind
+	Node    *Node
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%v: %v: %v", p.Kind, p.Node, p.Message)
+}
+
+/* placement identifies entities which share an archetype and position */
+type placement struct {
+	Archetype jenkins.Jenkins32
+	Level     Level
+	Position  [3]int32
+}
+
+// Validate checks the linked tree for broken LOD hierarchies. This covers levels which don't
+// step up towards the parent, distances which leave gaps between a parent and its children,
+// duplicated placements, and entities outside of their map's extents
+func (tree *Tree) Validate() []Problem {
+	tree.problems = make([]Problem, 0)
+	placements := make(map[placement]*Node)
+
+	for _, node := range tree.Nodes() {
+		entity := node.Entity
+		level := node.Level()
+
+		switch {
+		case node.Parent == nil && level == LevelHD && entity.ParentIndex < 0:
+			tree.report(ProblemOrphaned, node, "HD entity has no parent, expected %v", LevelOrphanHD)
+
+		case node.Parent != nil && level == LevelOrphanHD:
+			tree.report(ProblemLevel, node, "orphan HD entity has a parent %v", node.Parent)
+
+		case level != LevelHD && level != LevelOrphanHD && len(node.Children) == 0:
+			tree.report(ProblemChildless, node, "%v entity has no children", level)
+		}
+
+		if int(entity.NumChildren) != len(node.Children) {
+			tree.report(ProblemChildCount, node, "numChildren is %v, found %v", entity.NumChildren, len(node.Children))
+		}
+
+		if parent := node.Parent; parent != nil {
+			if parent.Level().Depth() != level.Depth()+1 {
+				tree.report(ProblemLevel, node, "parent %v is not one level above %v", parent, level)
+			}
+
+			if entity.LodDist > parent.Entity.LodDist {
+				tree.report(ProblemDistance, node, "lodDist %v exceeds parent lodDist %v", entity.LodDist, parent.Entity.LodDist)
+			}
+
+			/* Children are streamed in at the parent's childLodDist, so they need to be visible from there */
+			if entity.LodDist < parent.Entity.ChildLodDist {
+				tree.report(ProblemGap, node, "lodDist %v is less than parent childLodDist %v", entity.LodDist, parent.Entity.ChildLodDist)
+			}
+		}
+
+		/* Positions are compared to the nearest centimetre */
+		key := placement{
+			Archetype: entity.ArchetypeName,
+			Level:     level,
+			Position: [3]int32{
+				int32(entity.Position[0] * 100),
+				int32(entity.Position[1] * 100),
+				int32(entity.Position[2] * 100),
+			},
+		}
+
+		if other, ok := placements[key]; ok {
+			tree.report(ProblemOverlap, node, "placed at the same position as %v", other)
+		} else {
+			placements[key] = node
+		}
+
+		min, max := node.Map.Data.EntitiesExtentsMin, node.Map.Data.EntitiesExtentsMax
+		if min != max {
+			for i := 0; i < 3; i++ {
+				if entity.Position[i] < min[i] || entity.Position[i] > max[i] {
+					tree.report(ProblemExtents, node, "position %v outside of entity extents %v - %v", entity.Position, min, max)
+					break
+				}
+			}
+		}
+	}
+
+	return tree.problems
+}
@@ -0,0 +1,260 @@
+package item
+
+import (
+	"fmt"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+/* MetaPtr addresses data within one of the definition's data blocks */
+type MetaPtr uint32
+
+func NewMetaPtr(block int, offset uint32) MetaPtr {
+	return MetaPtr(uint32(block+1)&0xFFF | (offset&0xFFFFF)<<12)
+}
+
+/* Block returns the index of the data block the pointer refers to */
+func (p MetaPtr) Block() int {
+	return int(p&0xFFF) - 1
+}
+
+func (p MetaPtr) Offset() uint32 {
+	return (uint32(p) >> 12) & 0xFFFFF
+}
+
+/* StructValue is a structure referenced by pointer, tagged with its type */
+type StructValue struct {
+	Type  SectionType
+	Entry SectionEntry
+}
+
+type metaArray struct {
+	Ptr      MetaPtr
+	_        uint32
+	Count    uint16
+	Capacity uint16
+	_        uint32
+}
+
+/* resolve converts a meta pointer into a container address */
+func (typ *ItemDefinition) resolve(p MetaPtr) (types.Ptr32, SectionType, error) {
+	block := p.Block()
+	if block < 0 || block >= len(typ.SectionPtrs) {
+		return 0, 0, fmt.Errorf("invalid meta pointer %x", uint32(p))
+	}
+
+	section := typ.SectionPtrs[block]
+	if p.Offset() > section.Size {
+		return 0, 0, fmt.Errorf("meta pointer %x out of range of block %v", uint32(p), block)
+	}
+
+	return section.Ptr + types.Ptr32(p.Offset()), section.Type, nil
+}
+
+/* unpackStruct decodes a structure of the given type at addr */
+func (typ *ItemDefinition) unpackStruct(res *resource.Container, t SectionType, addr types.Ptr32) (SectionEntry, error) {
+	fields, ok := typ.SectionMaps[t]
+	if !ok {
+		return nil, fmt.Errorf("missing section map for %v", t)
+	}
+
+	entry := make(SectionEntry)
+	for _, field := range fields {
+		if field.FieldName == ArrayInfoName {
+			continue
+		}
+
+		value, err := typ.unpackField(res, fields, field, addr+types.Ptr32(field.Offset))
+		if err != nil {
+			return nil, fmt.Errorf("%v.%v: %v", t, field.FieldName, err)
+		}
+
+		entry[field.FieldName] = value
+	}
+	return entry, nil
+}
+
+/* fieldSize returns the size of a field, including structures and fixed arrays */
+func (typ *ItemDefinition) fieldSize(fields []SectionMapField, field SectionMapField) uint32 {
+	switch field.FieldType {
+	case FieldStruct:
+		return typ.SectionMapPtrs[SectionType(field.RefKey)].EntrySize
+	case FieldCharArray:
+		return field.RefKey & 0xFFFF
+	case FieldFixedArray:
+		if int(field.RefIndex) < len(fields) {
+			return (field.RefKey & 0xFFFF) * typ.fieldSize(fields, fields[field.RefIndex])
+		}
+	}
+	return field.FieldType.Size()
+}
+
+/* unpackField decodes the field at addr. fields is the structure the field belongs to, for resolving array infos */
+func (typ *ItemDefinition) unpackField(res *resource.Container, fields []SectionMapField, field SectionMapField, addr types.Ptr32) (FieldValue, error) {
+	var result FieldValue
+	var err error
+
+	elementInfo := func() (SectionMapField, error) {
+		if int(field.RefIndex) >= len(fields) {
+			return SectionMapField{}, fmt.Errorf("invalid array info index %v", field.RefIndex)
+		}
+		return fields[field.RefIndex], nil
+	}
+
+	elements := func(start types.Ptr32, count int) ([]FieldValue, error) {
+		info, err := elementInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		size := typ.fieldSize(fields, info)
+		values := make([]FieldValue, count)
+		for i := range values {
+			if values[i], err = typ.unpackField(res, fields, info, start+types.Ptr32(uint32(i)*size)); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	parse := func(value interface{}) error {
+		return res.Detour(addr, func() error {
+			res.Parse(value)
+			return nil
+		})
+	}
+
+	switch field.FieldType {
+	case FieldStruct:
+		result, err = typ.unpackStruct(res, SectionType(field.RefKey), addr)
+
+	case FieldStructPtr:
+		var ptr MetaPtr
+		if err = parse(&ptr); err != nil || ptr == 0 {
+			break
+		}
+
+		var target types.Ptr32
+		var t SectionType
+		if target, t, err = typ.resolve(ptr); err != nil {
+			break
+		}
+
+		var entry SectionEntry
+		if entry, err = typ.unpackStruct(res, t, target); err == nil {
+			result = StructValue{Type: t, Entry: entry}
+		}
+
+	case FieldArray:
+		var array metaArray
+		if err = parse(&array); err != nil {
+			break
+		}
+
+		if array.Count == 0 {
+			result = []FieldValue{}
+			break
+		}
+
+		var target types.Ptr32
+		if target, _, err = typ.resolve(array.Ptr); err != nil {
+			break
+		}
+		result, err = elements(target, int(array.Count))
+
+	case FieldFixedArray:
+		result, err = elements(addr, int(field.RefKey&0xFFFF))
+
+	case FieldCharPtr:
+		var array metaArray
+		if err = parse(&array); err != nil || array.Ptr == 0 {
+			break
+		}
+
+		var target types.Ptr32
+		if target, _, err = typ.resolve(array.Ptr); err != nil {
+			break
+		}
+
+		err = res.Detour(target, func() error {
+			var value string
+			res.Parse(&value)
+			result = value
+			return nil
+		})
+
+	case FieldDataBlockPtr:
+		var array metaArray
+		if err = parse(&array); err != nil || array.Ptr == 0 {
+			break
+		}
+
+		block := array.Ptr.Block()
+		if block < 0 || block >= len(typ.SectionPtrs) {
+			err = fmt.Errorf("invalid meta pointer %x", uint32(array.Ptr))
+			break
+		}
+
+		if array.Ptr.Offset()+uint32(array.Count) > typ.SectionPtrs[block].Size {
+			err = fmt.Errorf("data block %x of %v bytes out of range of block %v", uint32(array.Ptr), array.Count, block)
+			break
+		}
+
+		var target types.Ptr32
+		if target, _, err = typ.resolve(array.Ptr); err != nil {
+			break
+		}
+
+		value := make([]byte, array.Count)
+		err = res.Detour(target, func() error {
+			res.Parse(value)
+			return nil
+		})
+		result = value
+
+	case FieldByteEnum:
+		var value int8
+		if err = parse(&value); err == nil {
+			result = newEnumValue(typ.Enums[enumKey(field)], int32(value))
+		}
+
+	case FieldIntEnum:
+		var value int32
+		if err = parse(&value); err == nil {
+			result = newEnumValue(typ.Enums[enumKey(field)], value)
+		}
+
+	case FieldShortFlags:
+		var value uint16
+		if err = parse(&value); err == nil {
+			result = newFlagsValue(typ.Enums[enumKey(field)], uint32(value))
+		}
+
+	case FieldIntFlags1, FieldIntFlags2:
+		var value uint32
+		if err = parse(&value); err == nil {
+			result = newFlagsValue(typ.Enums[enumKey(field)], value)
+		}
+
+	default:
+		result, err = field.UnpackField(res, addr-types.Ptr32(field.Offset))
+	}
+
+	return result, err
+}
+
+func enumKey(field SectionMapField) jenkins.Jenkins32 {
+	return jenkins.Jenkins32(field.RefKey)
+}
+
+/* cString returns the contents of a NUL terminated byte array */
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
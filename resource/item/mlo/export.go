@@ -0,0 +1,128 @@
+package mlo
+
+import (
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource/item"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+/* ModelResolver returns the model for an archetype, or nil if it isn't available */
+type ModelResolver func(archetype jenkins.Jenkins32) *export.Model
+
+// Export builds a scene of the interior in its local space. Entities are placed using resolve,
+// along with those of the enabled entity sets. Portals are added as quads, and rooms as the
+// outlines of their bounding boxes, so that interior visibility can be inspected
+func (interior *Interior) Export(name string, resolve ModelResolver, sets []jenkins.Jenkins32) *export.Scene {
+	scene := export.NewScene(name)
+
+	place := func(entity *item.EntityDef) {
+		if resolve == nil {
+			return
+		}
+
+		model := resolve(entity.ArchetypeName)
+		if model == nil {
+			return
+		}
+
+		scaleXY, scaleZ := entity.ScaleXY, entity.ScaleZ
+		if scaleXY == 0 {
+			scaleXY = 1
+		}
+		if scaleZ == 0 {
+			scaleZ = 1
+		}
+
+		/* Unlike entities placed in maps, the rotations of interior entities aren't inverted */
+		scene.AddInstance(&export.Instance{
+			Name:     model.Name,
+			Model:    scene.AddModel(model),
+			Position: entity.Position,
+			Rotation: mathgl.Quatf{
+				W: entity.Rotation[3],
+				V: mathgl.Vec3f{entity.Rotation[0], entity.Rotation[1], entity.Rotation[2]},
+			},
+			Scale: mathgl.Vec3f{scaleXY, scaleXY, scaleZ},
+		})
+	}
+
+	for _, entity := range interior.Entities {
+		place(entity)
+	}
+
+	for _, setName := range sets {
+		if set, ok := interior.EntitySet(setName); ok {
+			for _, e := range set.Entities {
+				place(e.Entity)
+			}
+		}
+	}
+
+	identity := func(model *export.Model) {
+		scene.AddInstance(&export.Instance{
+			Name:     model.Name,
+			Model:    scene.AddModel(model),
+			Rotation: mathgl.QuatIdentf(),
+			Scale:    mathgl.Vec3f{1, 1, 1},
+		})
+	}
+
+	identity(interior.PortalModel(name + "_portals"))
+	identity(interior.RoomModel(name + "_rooms"))
+	return scene
+}
+
+/* PortalModel returns a model with a mesh for each portal, made up of its quad and outline */
+func (interior *Interior) PortalModel(name string) *export.Model {
+	model := export.NewModel()
+	model.Name = name
+
+	for _, portal := range interior.Portals {
+		if len(portal.Corners) < 3 {
+			continue
+		}
+
+		mesh := export.NewMesh()
+		mesh.Format = export.VertXYZ
+
+		outline := make(export.Polyline, 0, len(portal.Corners)+1)
+		for i, corner := range portal.Corners {
+			mesh.AddVert4f(mathgl.Vec4f{corner[0], corner[1], corner[2], 1})
+			outline = append(outline, uint16(i))
+		}
+		outline = append(outline, 0)
+
+		/* Portals are convex, so fan out from the first corner */
+		for i := 1; i+1 < len(portal.Corners); i++ {
+			mesh.AddFace(types.Tri{A: 0, B: uint16(i), C: uint16(i + 1)})
+		}
+
+		mesh.AddPolyline(outline)
+		model.AddMesh(mesh)
+	}
+
+	return model
+}
+
+/* RoomModel returns a model with the outline of each room's bounding box */
+func (interior *Interior) RoomModel(name string) *export.Model {
+	model := export.NewModel()
+	model.Name = name
+
+	for _, room := range interior.Rooms {
+		min, max := room.Def.BbMin, room.Def.BbMax
+		if min == max {
+			continue
+		}
+
+		mesh := export.NewMesh()
+		mesh.Format = export.VertXYZ
+		mesh.AddBoxOutline(min, max)
+		model.AddMesh(mesh)
+	}
+
+	return model
+}
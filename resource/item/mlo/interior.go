@@ -0,0 +1,205 @@
+package mlo
+
+import (
+	"fmt"
+
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource/item"
+)
+
+/* Interior links the rooms, portals and entity sets of an MLO archetype to the entities they reference */
+type Interior struct {
+	Def        *item.MloArchetypeDef
+	Entities   []*item.EntityDef
+	Rooms      []*Room
+	Portals    []*Portal
+	EntitySets []*EntitySet
+}
+
+type Room struct {
+	Index    int
+	Def      *item.MloRoomDef
+	Entities []*item.EntityDef
+	Portals  []*Portal
+}
+
+type Portal struct {
+	Index    int
+	Def      *item.MloPortalDef
+	From     *Room
+	To       *Room
+	Corners  []mathgl.Vec3f
+	Entities []*item.EntityDef
+}
+
+/* EntitySetEntity is an entity which is only present when its set is enabled, and the room it is placed in */
+type EntitySetEntity struct {
+	Entity *item.EntityDef
+	Room   *Room
+}
+
+type EntitySet struct {
+	Name     jenkins.Jenkins32
+	Entities []EntitySetEntity
+}
+
+/* New links the contents of an MLO archetype together */
+func New(def *item.MloArchetypeDef) (*Interior, error) {
+	interior := &Interior{
+		Def:        def,
+		Entities:   make([]*item.EntityDef, len(def.Entities)),
+		Rooms:      make([]*Room, len(def.Rooms)),
+		Portals:    make([]*Portal, len(def.Portals)),
+		EntitySets: make([]*EntitySet, len(def.EntitySets)),
+	}
+
+	for i, value := range def.Entities {
+		entity, err := entityDef(value)
+		if err != nil {
+			return nil, fmt.Errorf("entity %v: %v", i, err)
+		}
+		interior.Entities[i] = entity
+	}
+
+	entity := func(idx uint32) (*item.EntityDef, error) {
+		if int(idx) >= len(interior.Entities) {
+			return nil, fmt.Errorf("invalid entity index %v", idx)
+		}
+		return interior.Entities[idx], nil
+	}
+
+	room := func(idx uint32) (*Room, error) {
+		if int(idx) >= len(interior.Rooms) {
+			return nil, fmt.Errorf("invalid room index %v", idx)
+		}
+		return interior.Rooms[idx], nil
+	}
+
+	for i := range def.Rooms {
+		r := &Room{
+			Index:    i,
+			Def:      &def.Rooms[i],
+			Entities: make([]*item.EntityDef, 0),
+			Portals:  make([]*Portal, 0),
+		}
+
+		for _, idx := range r.Def.AttachedObjects {
+			e, err := entity(idx)
+			if err != nil {
+				return nil, fmt.Errorf("room %v: %v", r.Def.Name, err)
+			}
+			r.Entities = append(r.Entities, e)
+		}
+		interior.Rooms[i] = r
+	}
+
+	for i := range def.Portals {
+		var err error
+		p := &Portal{
+			Index:    i,
+			Def:      &def.Portals[i],
+			Corners:  def.Portals[i].Corners,
+			Entities: make([]*item.EntityDef, 0),
+		}
+
+		if p.From, err = room(p.Def.RoomFrom); err != nil {
+			return nil, fmt.Errorf("portal %v: %v", i, err)
+		}
+
+		if p.To, err = room(p.Def.RoomTo); err != nil {
+			return nil, fmt.Errorf("portal %v: %v", i, err)
+		}
+
+		for _, idx := range p.Def.AttachedObjects {
+			e, err := entity(idx)
+			if err != nil {
+				return nil, fmt.Errorf("portal %v: %v", i, err)
+			}
+			p.Entities = append(p.Entities, e)
+		}
+
+		p.From.Portals = append(p.From.Portals, p)
+		if p.To != p.From {
+			p.To.Portals = append(p.To.Portals, p)
+		}
+		interior.Portals[i] = p
+	}
+
+	for i, setDef := range def.EntitySets {
+		set := &EntitySet{
+			Name:     setDef.Name,
+			Entities: make([]EntitySetEntity, len(setDef.Entities)),
+		}
+
+		for j, value := range setDef.Entities {
+			e, err := entityDef(value)
+			if err != nil {
+				return nil, fmt.Errorf("entity set %v: %v", set.Name, err)
+			}
+			set.Entities[j].Entity = e
+
+			/* Each entity has a corresponding location, which is the index of its room */
+			if j < len(setDef.Locations) {
+				if set.Entities[j].Room, err = room(setDef.Locations[j]); err != nil {
+					return nil, fmt.Errorf("entity set %v: %v", set.Name, err)
+				}
+			}
+		}
+		interior.EntitySets[i] = set
+	}
+
+	return interior, nil
+}
+
+/* Interiors links each MLO archetype in a definition */
+func Interiors(def *item.ItemDefinition) ([]*Interior, error) {
+	defs, err := def.Interiors()
+	if err != nil {
+		return nil, err
+	}
+
+	interiors := make([]*Interior, len(defs))
+	for i, d := range defs {
+		if interiors[i], err = New(d); err != nil {
+			return nil, fmt.Errorf("%v: %v", d.Name, err)
+		}
+	}
+	return interiors, nil
+}
+
+func entityDef(value interface{}) (*item.EntityDef, error) {
+	switch value := value.(type) {
+	case *item.EntityDef:
+		return value, nil
+	case *item.MloInstanceDef:
+		return &value.EntityDef, nil
+	}
+	return nil, fmt.Errorf("unexpected entity type %T", value)
+}
+
+/* Room returns the room with the given name */
+func (interior *Interior) Room(name string) (*Room, bool) {
+	for _, r := range interior.Rooms {
+		if r.Def.Name == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+/* EntitySet returns the entity set with the given name */
+func (interior *Interior) EntitySet(name jenkins.Jenkins32) (*EntitySet, bool) {
+	for _, set := range interior.EntitySets {
+		if set.Name == name {
+			return set, true
+		}
+	}
+	return nil, false
+}
+
+/* Exterior returns true if the portal leads outside. Room 0 is the exterior of the interior */
+func (p *Portal) Exterior() bool {
+	return p.From.Index == 0 || p.To.Index == 0
+}
@@ -0,0 +1,117 @@
+package item
+
+import (
+	"sort"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+// Schema is the serializable form of a definition's structure and enum infos,
+// which is needed to rebuild the binary form from a dump
+type Schema struct {
+	Structs []StructSchema
+	Enums   []EnumSchema
+}
+
+type StructSchema struct {
+	Type   SectionType
+	Key    uint32
+	Unk1   uint32
+	Size   uint32
+	Fields []FieldSchema
+}
+
+type FieldSchema struct {
+	Name     jenkins.Jenkins32
+	Offset   uint32
+	Type     uint16
+	RefIndex uint16
+	RefKey   uint32
+}
+
+type EnumSchema struct {
+	Hash    jenkins.Jenkins32
+	Key     uint32
+	Entries []EnumEntry
+}
+
+/* Schema returns the structure and enum infos of the definition */
+func (typ *ItemDefinition) Schema() *Schema {
+	schema := &Schema{
+		Structs: make([]StructSchema, 0),
+		Enums:   make([]EnumSchema, 0),
+	}
+
+	for t, mapPtr := range typ.SectionMapPtrs {
+		s := StructSchema{
+			Type:   t,
+			Key:    uint32(mapPtr.Hash),
+			Unk1:   uint32(mapPtr.Unk1),
+			Size:   mapPtr.EntrySize,
+			Fields: make([]FieldSchema, 0),
+		}
+
+		for _, field := range typ.SectionMaps[t] {
+			s.Fields = append(s.Fields, FieldSchema{
+				Name:     jenkins.Jenkins32(field.FieldName),
+				Offset:   field.Offset,
+				Type:     uint16(field.FieldType),
+				RefIndex: field.RefIndex,
+				RefKey:   field.RefKey,
+			})
+		}
+		schema.Structs = append(schema.Structs, s)
+	}
+
+	for _, info := range typ.Enums {
+		schema.Enums = append(schema.Enums, EnumSchema{
+			Hash:    info.Hash,
+			Key:     info.Key,
+			Entries: info.Entries,
+		})
+	}
+
+	/* Keep the output stable */
+	sort.Slice(schema.Structs, func(i, j int) bool { return schema.Structs[i].Type < schema.Structs[j].Type })
+	sort.Slice(schema.Enums, func(i, j int) bool { return schema.Enums[i].Hash < schema.Enums[j].Hash })
+
+	return schema
+}
+
+/* SetSchema replaces the definition's structure and enum infos */
+func (typ *ItemDefinition) SetSchema(schema *Schema) {
+	typ.SectionMapPtrs = make(map[SectionType]SectionMapPtr)
+	typ.SectionMaps = make(map[SectionType][]SectionMapField)
+	typ.Enums = make(map[jenkins.Jenkins32]*EnumInfo)
+
+	for _, s := range schema.Structs {
+		fields := make([]SectionMapField, len(s.Fields))
+		for i, f := range s.Fields {
+			fields[i] = SectionMapField{
+				FieldName: FieldName(f.Name),
+				Offset:    f.Offset,
+				FieldType: FieldType(f.Type),
+				RefIndex:  f.RefIndex,
+				RefKey:    f.RefKey,
+			}
+		}
+
+		typ.SectionMaps[s.Type] = fields
+		typ.SectionMapPtrs[s.Type] = SectionMapPtr{
+			Type:      s.Type,
+			Hash:      jenkins.Jenkins32(s.Key),
+			Unk1:      types.Unknown32(s.Unk1),
+			EntrySize: s.Size,
+			NumFields: uint16(len(fields)),
+		}
+	}
+
+	for _, e := range schema.Enums {
+		typ.Enums[e.Hash] = &EnumInfo{
+			Hash:    e.Hash,
+			Key:     e.Key,
+			Entries: e.Entries,
+		}
+	}
+}
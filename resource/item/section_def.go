@@ -1,6 +1,8 @@
 package item
 
 import (
+	"fmt"
+
 	"github.com/tgascoigne/ragekit/jenkins"
 	"github.com/tgascoigne/ragekit/resource"
 	"github.com/tgascoigne/ragekit/resource/types"
@@ -20,6 +22,11 @@ type SectionMapPtr struct {
 
 func (s SectionMapPtr) Unpack(res *resource.Container) ([]SectionMapField, error) {
 	fields := make([]SectionMapField, s.NumFields)
+	if s.NumFields == 0 {
+		/* Legacy struct infos have no field map */
+		return fields, nil
+	}
+
 	err := res.Detour(s.Ptr, func() error {
 		for i := 0; i < int(s.NumFields); i++ {
 			res.Parse(&fields[i])
@@ -39,15 +46,36 @@ func (f FieldType) MarshalJSON() ([]byte, error) {
 }
 
 const (
-	FieldJenkins  FieldType = 0x4a
-	FieldVec4f    FieldType = 0x33
-	FieldFloat32  FieldType = 0x21
-	FieldFlags32  FieldType = 0x15
-	FieldUint32   FieldType = 0x14
-	FieldUnknown1 FieldType = 0x62
-	FieldUnknown2 FieldType = 0x52
+	FieldBool         FieldType = 0x01
+	FieldStruct       FieldType = 0x05
+	FieldStructPtr    FieldType = 0x07
+	FieldInt8         FieldType = 0x10
+	FieldUint8        FieldType = 0x11
+	FieldInt16        FieldType = 0x12
+	FieldUint16       FieldType = 0x13
+	FieldUint32       FieldType = 0x14
+	FieldFlags32      FieldType = 0x15
+	FieldFloat32      FieldType = 0x21
+	FieldVec2f        FieldType = 0x32
+	FieldVec4f        FieldType = 0x33
+	FieldVec4fXYZW    FieldType = 0x34
+	FieldMat34        FieldType = 0x35
+	FieldCharArray    FieldType = 0x40
+	FieldCharPtr      FieldType = 0x44
+	FieldJenkins      FieldType = 0x4a
+	FieldFixedArray   FieldType = 0x50
+	FieldArray        FieldType = 0x52
+	FieldDataBlockPtr FieldType = 0x59
+	FieldByteEnum     FieldType = 0x60
+	FieldIntEnum      FieldType = 0x62
+	FieldIntFlags1    FieldType = 0x63
+	FieldShortFlags   FieldType = 0x64
+	FieldIntFlags2    FieldType = 0x65
 )
 
+/* ArrayInfoName is the name given to the pseudo-fields which describe the element type of an array */
+const ArrayInfoName FieldName = 0x100
+
 type FieldName jenkins.Jenkins32
 
 func (name FieldName) String() string {
@@ -60,8 +88,29 @@ type SectionMapField struct {
 	FieldName FieldName
 	Offset    uint32
 	FieldType FieldType
-	Unk1      uint16
-	Unk2      types.Unknown32
+	/* The index of the array info field describing the elements of an array */
+	RefIndex uint16
+	/* The struct or enum type of the field, or the length of a fixed array */
+	RefKey uint32
+}
+
+/* Size returns the number of bytes occupied by a field of this type, excluding structs and fixed arrays */
+func (f FieldType) Size() uint32 {
+	switch f {
+	case FieldBool, FieldInt8, FieldUint8, FieldByteEnum:
+		return 1
+	case FieldInt16, FieldUint16, FieldShortFlags:
+		return 2
+	case FieldUint32, FieldFlags32, FieldFloat32, FieldJenkins, FieldIntEnum, FieldIntFlags1, FieldIntFlags2:
+		return 4
+	case FieldStructPtr, FieldVec2f:
+		return 8
+	case FieldVec4f, FieldVec4fXYZW, FieldCharPtr, FieldArray, FieldDataBlockPtr:
+		return 16
+	case FieldMat34:
+		return 64
+	}
+	return 0
 }
 
 func (f SectionMapField) UnpackField(res *resource.Container, baseAddr types.Ptr32) (result FieldValue, err error) {
@@ -72,11 +121,50 @@ func (f SectionMapField) UnpackField(res *resource.Container, baseAddr types.Ptr
 			res.Parse(&value)
 			result = value
 
+		case FieldBool:
+			var value uint8
+			res.Parse(&value)
+			result = value != 0
+
+		case FieldInt8:
+			var value int8
+			res.Parse(&value)
+			result = value
+
+		case FieldUint8:
+			var value uint8
+			res.Parse(&value)
+			result = value
+
+		case FieldInt16:
+			var value int16
+			res.Parse(&value)
+			result = value
+
+		case FieldUint16:
+			var value uint16
+			res.Parse(&value)
+			result = value
+
+		case FieldVec2f:
+			var value [2]types.Float32
+			res.Parse(&value)
+			result = value
+
 		case FieldVec4f:
+			fallthrough
+
+		case FieldVec4fXYZW:
 			var value types.Vec4f
 			res.Parse(&value)
 			result = value
 
+		case FieldMat34:
+			/* Stored as four padded rows, the last of which is the translation */
+			var value [4]types.Vec4f
+			res.Parse(&value)
+			result = value
+
 		case FieldFloat32:
 			var value types.Float32
 			res.Parse(&value)
@@ -90,14 +178,13 @@ func (f SectionMapField) UnpackField(res *resource.Container, baseAddr types.Ptr
 			res.Parse(&value)
 			result = value
 
-		case FieldUnknown1:
-			fallthrough
-
-		case FieldUnknown2:
-			var value types.Unknown32
-			res.Parse(&value)
-			result = value
+		case FieldCharArray:
+			value := make([]byte, f.RefKey&0xFFFF)
+			res.Parse(value)
+			result = cString(value)
 
+		default:
+			return fmt.Errorf("unknown field type %v", f.FieldType)
 		}
 		return nil
 	})
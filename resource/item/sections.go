@@ -2,7 +2,9 @@ package item
 
 import (
 	"encoding/json"
+	"fmt"
 
+	"github.com/tgascoigne/ragekit/jenkins"
 	"github.com/tgascoigne/ragekit/resource"
 	"github.com/tgascoigne/ragekit/resource/types"
 )
@@ -64,6 +66,80 @@ const (
 	SectionUNKNOWN12 SectionType = 0x33
 )
 
+/* sectionTypes lists each of the named section types, for parsing their names */
+var sectionTypes = []SectionType{
+	CEntityDef,
+	CMapData,
+	CBaseArchetypeDef,
+	CTimeArchetypeDef,
+	CTimeCycleModifier,
+	CExtensionDefAudioCollisionSettings,
+	CExtensionDefAudioEmitter,
+	CExtensionDefParticleEffect,
+	CMapTypes,
+	CExtensionDefLadder,
+	CExtensionDefBuoyancy,
+	CExtensionDefSpawnPoint,
+	CCarGen,
+	CExtensionDefExplosionEffect,
+	CMloInstanceDef,
+	CMloRoomDef,
+	CExtensionDefDoor,
+	CExtensionDefProcObject,
+	CMloPortalDef,
+	CExtensionDefSpawnPointOverride,
+	CExtensionDefLightShaft,
+	CMloArchetypeDef,
+	CMloEntitySet,
+	CExtensionDefExpression,
+	CLightAttrDef,
+	CExtensionDefWindDisturbance,
+	CExtensionDefLightEffect,
+	CMloTimeCycleModifier,
+	PhVerletClothCustomBounds,
+	SectionUNKNOWN1,
+	SectionUNKNOWN2,
+	SectionUNKNOWN3,
+	SectionUNKNOWN4,
+	SectionUNKNOWN5,
+	SectionUNKNOWN6,
+	SectionUNKNOWN7,
+	SectionUNKNOWN8,
+	SectionUNKNOWN9,
+	SectionTypeRef,
+	SectionSTRINGS,
+	SectionUNKNOWN10,
+	SectionUNKNOWN11,
+	SectionUNKNOWN12,
+}
+
+/* ParseSectionType returns the section type with the given name */
+func ParseSectionType(name string) SectionType {
+	for _, t := range sectionTypes {
+		if t.String() == name {
+			return t
+		}
+	}
+
+	var value uint32
+	if _, err := fmt.Sscanf(name, "SectionType(%d)", &value); err == nil {
+		return SectionType(value)
+	}
+
+	/* Section types are the hash of their struct name */
+	return SectionType(jenkins.Parse(name))
+}
+
+func (s *SectionType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	*s = ParseSectionType(name)
+	return nil
+}
+
 type SectionPtr struct {
 	Type SectionType
 	Size uint32
@@ -103,6 +179,10 @@ func (s SectionEntry) MarshalJSON() ([]byte, error) {
 
 func (s SectionEntry) UnpackFromMap(res *resource.Container, baseAddr types.Ptr32, sectionMap []SectionMapField) error {
 	for _, field := range sectionMap {
+		if field.FieldName == ArrayInfoName {
+			continue
+		}
+
 		value, err := field.UnpackField(res, baseAddr)
 		if err != nil {
 			return err
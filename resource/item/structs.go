@@ -0,0 +1,292 @@
+package item
+
+import (
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+)
+
+/* Typed equivalents of the common section types. Fields are matched by the jenkins hash of their meta tag */
+
+type EntityDef struct {
+	ArchetypeName              jenkins.Jenkins32 `meta:"archetypeName"`
+	Flags                      uint32            `meta:"flags"`
+	Guid                       uint32            `meta:"guid"`
+	Position                   mathgl.Vec3f      `meta:"position"`
+	Rotation                   mathgl.Vec4f      `meta:"rotation"`
+	ScaleXY                    float32           `meta:"scaleXY"`
+	ScaleZ                     float32           `meta:"scaleZ"`
+	ParentIndex                int32             `meta:"parentIndex"`
+	LodDist                    float32           `meta:"lodDist"`
+	ChildLodDist               float32           `meta:"childLodDist"`
+	LodLevel                   uint32            `meta:"lodLevel"`
+	NumChildren                uint32            `meta:"numChildren"`
+	PriorityLevel              uint32            `meta:"priorityLevel"`
+	Extensions                 []interface{}     `meta:"extensions"`
+	AmbientOcclusionMultiplier int32             `meta:"ambientOcclusionMultiplier"`
+	ArtificialAmbientOcclusion int32             `meta:"artificialAmbientOcclusion"`
+	TintValue                  uint32            `meta:"tintValue"`
+}
+
+type MapData struct {
+	Name                jenkins.Jenkins32   `meta:"name"`
+	Parent              jenkins.Jenkins32   `meta:"parent"`
+	Flags               uint32              `meta:"flags"`
+	ContentFlags        uint32              `meta:"contentFlags"`
+	StreamingExtentsMin mathgl.Vec3f        `meta:"streamingExtentsMin"`
+	StreamingExtentsMax mathgl.Vec3f        `meta:"streamingExtentsMax"`
+	EntitiesExtentsMin  mathgl.Vec3f        `meta:"entitiesExtentsMin"`
+	EntitiesExtentsMax  mathgl.Vec3f        `meta:"entitiesExtentsMax"`
+	Entities            []interface{}       `meta:"entities"`
+	PhysicsDictionaries []jenkins.Jenkins32 `meta:"physicsDictionaries"`
+	CarGenerators       []CarGen            `meta:"carGenerators"`
+}
+
+type BaseArchetypeDef struct {
+	LodDist            float32           `meta:"lodDist"`
+	Flags              uint32            `meta:"flags"`
+	SpecialAttribute   uint32            `meta:"specialAttribute"`
+	BbMin              mathgl.Vec3f      `meta:"bbMin"`
+	BbMax              mathgl.Vec3f      `meta:"bbMax"`
+	BsCentre           mathgl.Vec3f      `meta:"bsCentre"`
+	BsRadius           float32           `meta:"bsRadius"`
+	HdTextureDist      float32           `meta:"hdTextureDist"`
+	Name               jenkins.Jenkins32 `meta:"name"`
+	TextureDictionary  jenkins.Jenkins32 `meta:"textureDictionary"`
+	ClipDictionary     jenkins.Jenkins32 `meta:"clipDictionary"`
+	DrawableDictionary jenkins.Jenkins32 `meta:"drawableDictionary"`
+	PhysicsDictionary  jenkins.Jenkins32 `meta:"physicsDictionary"`
+	AssetType          uint32            `meta:"assetType"`
+	AssetName          jenkins.Jenkins32 `meta:"assetName"`
+	Extensions         []interface{}     `meta:"extensions"`
+}
+
+type TimeArchetypeDef struct {
+	BaseArchetypeDef
+	TimeFlags uint32 `meta:"timeFlags"`
+}
+
+type MloArchetypeDef struct {
+	BaseArchetypeDef
+	MloFlags           uint32         `meta:"mloFlags"`
+	Entities           []interface{}  `meta:"entities"`
+	Rooms              []MloRoomDef   `meta:"rooms"`
+	Portals            []MloPortalDef `meta:"portals"`
+	EntitySets         []MloEntitySet `meta:"entitySets"`
+	TimeCycleModifiers []interface{}  `meta:"timeCycleModifiers"`
+}
+
+type MloEntitySet struct {
+	Name      jenkins.Jenkins32 `meta:"name"`
+	Locations []uint32          `meta:"locations"`
+	Entities  []interface{}     `meta:"entities"`
+}
+
+type MloInstanceDef struct {
+	EntityDef
+	GroupId           uint32              `meta:"groupId"`
+	FloorId           uint32              `meta:"floorId"`
+	DefaultEntitySets []jenkins.Jenkins32 `meta:"defaultEntitySets"`
+	NumExitPortals    uint32              `meta:"numExitPortals"`
+	MLOInstflags      uint32              `meta:"MLOInstflags"`
+}
+
+type MloRoomDef struct {
+	Name                    string            `meta:"name"`
+	BbMin                   mathgl.Vec3f      `meta:"bbMin"`
+	BbMax                   mathgl.Vec3f      `meta:"bbMax"`
+	Blend                   float32           `meta:"blend"`
+	TimecycleName           jenkins.Jenkins32 `meta:"timecycleName"`
+	SecondaryTimecycleName  jenkins.Jenkins32 `meta:"secondaryTimecycleName"`
+	Flags                   uint32            `meta:"flags"`
+	PortalCount             uint32            `meta:"portalCount"`
+	FloorId                 int32             `meta:"floorId"`
+	ExteriorVisibilityDepth int32             `meta:"exteriorVisibiltyDepth"`
+	AttachedObjects         []uint32          `meta:"attachedObjects"`
+}
+
+type MloPortalDef struct {
+	RoomFrom        uint32         `meta:"roomFrom"`
+	RoomTo          uint32         `meta:"roomTo"`
+	Flags           uint32         `meta:"flags"`
+	MirrorPriority  uint32         `meta:"mirrorPriority"`
+	Opacity         uint32         `meta:"opacity"`
+	AudioOcclusion  uint32         `meta:"audioOcclusion"`
+	Corners         []mathgl.Vec3f `meta:"corners"`
+	AttachedObjects []uint32       `meta:"attachedObjects"`
+}
+
+type CarGen struct {
+	Position            mathgl.Vec3f      `meta:"position"`
+	OrientX             float32           `meta:"orientX"`
+	OrientY             float32           `meta:"orientY"`
+	PerpendicularLength float32           `meta:"perpendicularLength"`
+	CarModel            jenkins.Jenkins32 `meta:"carModel"`
+	Flags               uint32            `meta:"flags"`
+	BodyColorRemap1     int32             `meta:"bodyColorRemap1"`
+	BodyColorRemap2     int32             `meta:"bodyColorRemap2"`
+	BodyColorRemap3     int32             `meta:"bodyColorRemap3"`
+	BodyColorRemap4     int32             `meta:"bodyColorRemap4"`
+	PopGroup            jenkins.Jenkins32 `meta:"popGroup"`
+	Livery              int8              `meta:"livery"`
+}
+
+/* ExtensionDef is embedded in each of the extension types */
+type ExtensionDef struct {
+	Name           jenkins.Jenkins32 `meta:"name"`
+	OffsetPosition mathgl.Vec3f      `meta:"offsetPosition"`
+}
+
+type ExtensionDefLightEffect struct {
+	ExtensionDef
+	Instances []interface{} `meta:"instances"`
+}
+
+type ExtensionDefAudioCollisionSettings struct {
+	ExtensionDef
+	Settings jenkins.Jenkins32 `meta:"settings"`
+}
+
+type ExtensionDefAudioEmitter struct {
+	ExtensionDef
+	OffsetRotation mathgl.Vec4f      `meta:"offsetRotation"`
+	EffectHash     jenkins.Jenkins32 `meta:"effectHash"`
+}
+
+type ExtensionDefParticleEffect struct {
+	ExtensionDef
+	OffsetRotation mathgl.Vec4f `meta:"offsetRotation"`
+	FxName         string       `meta:"fxName"`
+	FxType         int32        `meta:"fxType"`
+	BoneTag        int32        `meta:"boneTag"`
+	Scale          float32      `meta:"scale"`
+	Probability    int32        `meta:"probability"`
+	Flags          int32        `meta:"flags"`
+	Color          uint32       `meta:"color"`
+}
+
+type ExtensionDefLadder struct {
+	ExtensionDef
+	Bottom            mathgl.Vec3f      `meta:"bottom"`
+	Top               mathgl.Vec3f      `meta:"top"`
+	Normal            mathgl.Vec3f      `meta:"normal"`
+	MaterialType      uint32            `meta:"materialType"`
+	Template          jenkins.Jenkins32 `meta:"template"`
+	CanGetOffAtTop    bool              `meta:"canGetOffAtTop"`
+	CanGetOffAtBottom bool              `meta:"canGetOffAtBottom"`
+}
+
+type ExtensionDefBuoyancy struct {
+	ExtensionDef
+}
+
+type ExtensionDefSpawnPoint struct {
+	ExtensionDef
+	OffsetRotation    mathgl.Vec4f      `meta:"offsetRotation"`
+	SpawnType         jenkins.Jenkins32 `meta:"spawnType"`
+	PedType           jenkins.Jenkins32 `meta:"pedType"`
+	Group             jenkins.Jenkins32 `meta:"group"`
+	Interior          jenkins.Jenkins32 `meta:"interior"`
+	RequiredImap      jenkins.Jenkins32 `meta:"requiredImap"`
+	AvailableInMpSp   uint32            `meta:"availableInMpSp"`
+	Probability       float32           `meta:"probability"`
+	TimeTillPedLeaves float32           `meta:"timeTillPedLeaves"`
+	Radius            float32           `meta:"radius"`
+	Start             uint8             `meta:"start"`
+	End               uint8             `meta:"end"`
+	Flags             uint32            `meta:"flags"`
+	HighPri           bool              `meta:"highPri"`
+	ExtendedRange     bool              `meta:"extendedRange"`
+	ShortRange        bool              `meta:"shortRange"`
+}
+
+type ExtensionDefExplosionEffect struct {
+	ExtensionDef
+	OffsetRotation mathgl.Vec4f `meta:"offsetRotation"`
+	ExplosionName  string       `meta:"explosionName"`
+	BoneTag        int32        `meta:"boneTag"`
+	ExplosionTag   int32        `meta:"explosionTag"`
+	ExplosionType  int32        `meta:"explosionType"`
+	Flags          uint32       `meta:"flags"`
+}
+
+type ExtensionDefDoor struct {
+	ExtensionDef
+	EnableLimitAngle bool              `meta:"enableLimitAngle"`
+	StartsLocked     bool              `meta:"startsLocked"`
+	CanBreak         bool              `meta:"canBreak"`
+	LimitAngle       float32           `meta:"limitAngle"`
+	DoorTargetRatio  float32           `meta:"doorTargetRatio"`
+	AudioHash        jenkins.Jenkins32 `meta:"audioHash"`
+}
+
+type ExtensionDefProcObject struct {
+	ExtensionDef
+	RadiusInner float32           `meta:"radiusInner"`
+	RadiusOuter float32           `meta:"radiusOuter"`
+	Spacing     float32           `meta:"spacing"`
+	MinScale    float32           `meta:"minScale"`
+	MaxScale    float32           `meta:"maxScale"`
+	MinScaleZ   float32           `meta:"minScaleZ"`
+	MaxScaleZ   float32           `meta:"maxScaleZ"`
+	MinZOffset  float32           `meta:"minZOffset"`
+	MaxZOffset  float32           `meta:"maxZOffset"`
+	ObjectHash  jenkins.Jenkins32 `meta:"objectHash"`
+	Flags       uint32            `meta:"flags"`
+}
+
+type ExtensionDefSpawnPointOverride struct {
+	ExtensionDef
+	ScenarioType       jenkins.Jenkins32 `meta:"ScenarioType"`
+	TimeStartOverride  uint8             `meta:"iTimeStartOverride"`
+	TimeEndOverride    uint8             `meta:"iTimeEndOverride"`
+	Group              jenkins.Jenkins32 `meta:"Group"`
+	ModelSet           jenkins.Jenkins32 `meta:"ModelSet"`
+	AvailabilityInMpSp uint32            `meta:"AvailabilityInMpSp"`
+	Flags              uint32            `meta:"Flags"`
+	Radius             float32           `meta:"Radius"`
+	TimeTillPedLeaves  float32           `meta:"TimeTillPedLeaves"`
+}
+
+type ExtensionDefLightShaft struct {
+	ExtensionDef
+	CornerA             mathgl.Vec3f `meta:"cornerA"`
+	CornerB             mathgl.Vec3f `meta:"cornerB"`
+	CornerC             mathgl.Vec3f `meta:"cornerC"`
+	CornerD             mathgl.Vec3f `meta:"cornerD"`
+	Direction           mathgl.Vec3f `meta:"direction"`
+	DirectionAmount     float32      `meta:"directionAmount"`
+	Length              float32      `meta:"length"`
+	FadeInTimeStart     float32      `meta:"fadeInTimeStart"`
+	FadeInTimeEnd       float32      `meta:"fadeInTimeEnd"`
+	FadeOutTimeStart    float32      `meta:"fadeOutTimeStart"`
+	FadeOutTimeEnd      float32      `meta:"fadeOutTimeEnd"`
+	FadeDistanceStart   float32      `meta:"fadeDistanceStart"`
+	FadeDistanceEnd     float32      `meta:"fadeDistanceEnd"`
+	Color               uint32       `meta:"color"`
+	Intensity           float32      `meta:"intensity"`
+	Flashiness          uint8        `meta:"flashiness"`
+	Flags               uint32       `meta:"flags"`
+	DensityType         uint32       `meta:"densityType"`
+	VolumeType          uint32       `meta:"volumeType"`
+	Softness            float32      `meta:"softness"`
+	ScaleBySunIntensity bool         `meta:"scaleBySunIntensity"`
+}
+
+type ExtensionDefExpression struct {
+	ExtensionDef
+	ExpressionDictionaryName jenkins.Jenkins32 `meta:"expressionDictionaryName"`
+	ExpressionName           jenkins.Jenkins32 `meta:"expressionName"`
+	CreatureMetadataName     jenkins.Jenkins32 `meta:"creatureMetadataName"`
+	InitialiseOnCollision    bool              `meta:"initialiseOnCollision"`
+}
+
+type ExtensionDefWindDisturbance struct {
+	ExtensionDef
+	OffsetRotation  mathgl.Vec4f `meta:"offsetRotation"`
+	DisturbanceType int32        `meta:"disturbanceType"`
+	BoneTag         int32        `meta:"boneTag"`
+	Size            mathgl.Vec4f `meta:"size"`
+	Strength        float32      `meta:"strength"`
+	Flags           int32        `meta:"flags"`
+}
@@ -0,0 +1,95 @@
+package item
+
+import "fmt"
+
+// Structs returns each entry of a section type decoded into its typed struct,
+// or as a SectionEntry if the type has no typed equivalent
+func (typ *ItemDefinition) Structs(t SectionType) ([]interface{}, error) {
+	entries := typ.Sections[t]
+	result := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		value, err := NewStruct(t, entry)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+func (typ *ItemDefinition) Entities() ([]*EntityDef, error) {
+	entities := make([]*EntityDef, 0)
+	for _, entry := range typ.Sections[CEntityDef] {
+		entity := new(EntityDef)
+		if err := entry.Decode(entity); err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+/* Archetypes returns the base definition of each archetype, including time and MLO archetypes */
+func (typ *ItemDefinition) Archetypes() ([]*BaseArchetypeDef, error) {
+	archetypes := make([]*BaseArchetypeDef, 0)
+	for _, t := range []SectionType{CBaseArchetypeDef, CTimeArchetypeDef, CMloArchetypeDef} {
+		for _, entry := range typ.Sections[t] {
+			archetype := new(BaseArchetypeDef)
+			if err := entry.Decode(archetype); err != nil {
+				return nil, err
+			}
+			archetypes = append(archetypes, archetype)
+		}
+	}
+	return archetypes, nil
+}
+
+func (typ *ItemDefinition) CarGenerators() ([]*CarGen, error) {
+	carGens := make([]*CarGen, 0)
+	for _, entry := range typ.Sections[CCarGen] {
+		carGen := new(CarGen)
+		if err := entry.Decode(carGen); err != nil {
+			return nil, err
+		}
+		carGens = append(carGens, carGen)
+	}
+	return carGens, nil
+}
+
+/* MloInstances returns the placements of interiors */
+func (typ *ItemDefinition) MloInstances() ([]*MloInstanceDef, error) {
+	instances := make([]*MloInstanceDef, 0)
+	for _, entry := range typ.Sections[CMloInstanceDef] {
+		instance := new(MloInstanceDef)
+		if err := entry.Decode(instance); err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func (typ *ItemDefinition) Interiors() ([]*MloArchetypeDef, error) {
+	interiors := make([]*MloArchetypeDef, 0)
+	for _, entry := range typ.Sections[CMloArchetypeDef] {
+		interior := new(MloArchetypeDef)
+		if err := entry.Decode(interior); err != nil {
+			return nil, err
+		}
+		interiors = append(interiors, interior)
+	}
+	return interiors, nil
+}
+
+/* MapData returns the root structure of a ymap */
+func (typ *ItemDefinition) MapData() (*MapData, error) {
+	if typ.Root == nil || typ.Root.Type != CMapData {
+		return nil, fmt.Errorf("%v is not a map", typ.FileName)
+	}
+
+	data := new(MapData)
+	if err := typ.Root.Entry.Decode(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
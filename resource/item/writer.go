@@ -0,0 +1,486 @@
+package item
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+const (
+	systemBase = 0x50000000
+	headerSize = 0x70
+
+	/* Header values used when building a definition from scratch */
+	defaultVTable = 0x405bc808
+	defaultMagic  = 0x50524430
+	defaultUnk5   = 0x79
+)
+
+type metaBlock struct {
+	Type SectionType
+	Data []byte
+}
+
+// metaWriter lays out the data blocks of a definition. Each type is written to its own block,
+// except for data block pointers, which each have a block to themselves
+type metaWriter struct {
+	def    *ItemDefinition
+	blocks []*metaBlock
+	byType map[SectionType]int
+}
+
+type pagesInfo struct {
+	_          uint32
+	_          uint32
+	SysPages   uint8
+	GfxPages   uint8
+	_          uint16
+	_          uint32
+	SysPagePtr uint64
+	_          uint64
+}
+
+/* Pack rebuilds the binary form of the definition from its schema and root structure */
+func (typ *ItemDefinition) Pack() ([]byte, error) {
+	sys, err := typ.buildSystem()
+	if err != nil {
+		return nil, err
+	}
+
+	return resource.Pack(resource.ResourceMap, sys, nil)
+}
+
+func (typ *ItemDefinition) buildSystem() ([]byte, error) {
+	if typ.Root == nil {
+		return nil, fmt.Errorf("definition has no root structure")
+	}
+
+	if typ.Legacy {
+		return nil, fmt.Errorf("legacy definitions have no field maps to pack with")
+	}
+
+	w := &metaWriter{
+		def:    typ,
+		byType: make(map[SectionType]int),
+	}
+
+	rootBlock, rootOffset, err := w.alloc(typ.Root.Type, w.structSize(typ.Root.Type), 16)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.writeStruct(typ.Root.Type, typ.Root.Entry, rootBlock, rootOffset); err != nil {
+		return nil, err
+	}
+
+	order := resource.ByteOrder()
+	var buf bytes.Buffer
+	buf.Write(make([]byte, headerSize))
+
+	ptr := func() types.Ptr32 {
+		return types.Ptr32(systemBase + buf.Len())
+	}
+
+	align := func(n int) {
+		for buf.Len()%n != 0 {
+			buf.WriteByte(0)
+		}
+	}
+
+	write := func(data interface{}) {
+		binary.Write(&buf, order, data)
+	}
+
+	header := typ.Header
+	if header.Unk1 == 0 {
+		header.Unk1 = defaultVTable
+		header.Unk2 = 1
+		header.Unk4Ptr = defaultMagic
+		header.Unk5 = defaultUnk5
+	}
+
+	/* Page map */
+	header.Unk3Ptr = ptr()
+	write(pagesInfo{SysPages: 1, SysPagePtr: systemBase})
+
+	/* Structure infos, followed by their fields */
+	align(16)
+	structTypes := make([]SectionType, 0, len(typ.SectionMapPtrs))
+	for _, s := range typ.Schema().Structs {
+		structTypes = append(structTypes, s.Type)
+	}
+
+	structInfoAddr := ptr()
+	buf.Write(make([]byte, len(structTypes)*binary.Size(SectionMapPtr{})))
+
+	structInfos := make([]SectionMapPtr, len(structTypes))
+	for i, t := range structTypes {
+		align(16)
+		structInfos[i] = typ.SectionMapPtrs[t]
+		structInfos[i].Type = t
+		structInfos[i].Ptr = ptr()
+		structInfos[i].NumFields = uint16(len(typ.SectionMaps[t]))
+		write(typ.SectionMaps[t])
+	}
+
+	/* Enum infos, followed by their entries */
+	align(16)
+	enums := typ.Schema().Enums
+	enumInfoAddr := ptr()
+	buf.Write(make([]byte, len(enums)*binary.Size(EnumInfoPtr{})))
+
+	enumInfos := make([]EnumInfoPtr, len(enums))
+	for i, e := range enums {
+		align(16)
+		enumInfos[i] = EnumInfoPtr{
+			Hash:       e.Hash,
+			Key:        e.Key,
+			Ptr:        ptr(),
+			NumEntries: int32(len(e.Entries)),
+		}
+		write(e.Entries)
+	}
+
+	/* Data blocks */
+	align(16)
+	blockPtrsAddr := ptr()
+	buf.Write(make([]byte, len(w.blocks)*binary.Size(SectionPtr{})))
+
+	blockPtrs := make([]SectionPtr, len(w.blocks))
+	for i, block := range w.blocks {
+		align(16)
+		blockPtrs[i] = SectionPtr{
+			Type: block.Type,
+			Size: uint32(len(block.Data)),
+			Ptr:  ptr(),
+		}
+		buf.Write(block.Data)
+	}
+	align(16)
+
+	header.SectionDefPtr = structInfoAddr
+	header.EnumInfoPtr = enumInfoAddr
+	header.SectionsPtr = blockPtrsAddr
+	header.NumSectionDefs = uint16(len(structInfos))
+	header.NumEnumInfos = uint16(len(enumInfos))
+	header.NumSections = uint16(len(blockPtrs))
+	header.RootBlockIndex = uint32(rootBlock + 1)
+
+	data := buf.Bytes()
+	put := func(addr types.Ptr32, value interface{}) {
+		var b bytes.Buffer
+		binary.Write(&b, order, value)
+		copy(data[addr-systemBase:], b.Bytes())
+	}
+
+	put(systemBase, header)
+	put(structInfoAddr, structInfos)
+	put(enumInfoAddr, enumInfos)
+	put(blockPtrsAddr, blockPtrs)
+
+	return data, nil
+}
+
+/* alloc reserves size bytes in the block for type t, returning the block index and offset */
+func (w *metaWriter) alloc(t SectionType, size uint32, align uint32) (int, uint32, error) {
+	idx, ok := w.byType[t]
+	if !ok {
+		idx = w.newBlock(t)
+		w.byType[t] = idx
+	}
+
+	block := w.blocks[idx]
+	for uint32(len(block.Data))%align != 0 {
+		block.Data = append(block.Data, 0)
+	}
+
+	offset := uint32(len(block.Data))
+	if offset+size > 0xFFFFF {
+		return 0, 0, fmt.Errorf("block %v too large", t)
+	}
+
+	block.Data = append(block.Data, make([]byte, size)...)
+	return idx, offset, nil
+}
+
+/* newBlock adds an empty block of type t which isn't shared with alloc, returning its index */
+func (w *metaWriter) newBlock(t SectionType) int {
+	w.blocks = append(w.blocks, &metaBlock{Type: t})
+	return len(w.blocks) - 1
+}
+
+func (w *metaWriter) put(block int, offset uint32, value interface{}) {
+	var b bytes.Buffer
+	binary.Write(&b, resource.ByteOrder(), value)
+	copy(w.blocks[block].Data[offset:], b.Bytes())
+}
+
+func (w *metaWriter) structSize(t SectionType) uint32 {
+	return w.def.SectionMapPtrs[t].EntrySize
+}
+
+func (w *metaWriter) writeStruct(t SectionType, value FieldValue, block int, offset uint32) error {
+	fields, ok := w.def.SectionMaps[t]
+	if !ok {
+		return fmt.Errorf("missing section map for %v", t)
+	}
+
+	entry, err := toEntry(value)
+	if err != nil {
+		return fmt.Errorf("%v: %v", t, err)
+	}
+
+	for _, field := range fields {
+		if field.FieldName == ArrayInfoName {
+			continue
+		}
+
+		if err := w.writeField(fields, field, entry[field.FieldName], block, offset+field.Offset); err != nil {
+			return fmt.Errorf("%v.%v: %v", t, field.FieldName, err)
+		}
+	}
+	return nil
+}
+
+// elementBlockType returns the block an array of the field's type is written to.
+// Arrays of primitives are stored in blocks named by their type id
+func elementBlockType(field SectionMapField) SectionType {
+	if field.FieldType == FieldStruct {
+		return SectionType(field.RefKey)
+	}
+	return SectionType(field.FieldType)
+}
+
+func (w *metaWriter) writeField(fields []SectionMapField, field SectionMapField, value FieldValue, block int, offset uint32) error {
+	elementInfo := func() (SectionMapField, error) {
+		if int(field.RefIndex) >= len(fields) {
+			return SectionMapField{}, fmt.Errorf("invalid array info index %v", field.RefIndex)
+		}
+		return fields[field.RefIndex], nil
+	}
+
+	elements := func(info SectionMapField, list []FieldValue, block int, offset uint32) error {
+		size := w.def.fieldSize(fields, info)
+		for i, v := range list {
+			if err := w.writeField(fields, info, v, block, offset+uint32(i)*size); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch field.FieldType {
+	case FieldBool:
+		f, err := toFloat(value)
+		if err != nil {
+			return err
+		}
+
+		var b uint8
+		if f != 0 {
+			b = 1
+		}
+		w.put(block, offset, b)
+
+	case FieldInt8, FieldUint8:
+		i, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, uint8(i))
+
+	case FieldInt16, FieldUint16:
+		i, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, uint16(i))
+
+	case FieldUint32, FieldFlags32:
+		i, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, uint32(i))
+
+	case FieldFloat32:
+		f, err := toFloat(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, float32(f))
+
+	case FieldVec2f:
+		v, err := toVec(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, [2]float32{v[0], v[1]})
+
+	case FieldVec4f, FieldVec4fXYZW:
+		v, err := toVec(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, v)
+
+	case FieldMat34:
+		m, err := toMatrix(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, m)
+
+	case FieldJenkins:
+		h, err := toHash(value)
+		if err != nil {
+			return err
+		}
+		w.put(block, offset, h)
+
+	case FieldCharArray:
+		s, err := toString(value)
+		if err != nil {
+			return err
+		}
+
+		b := make([]byte, field.RefKey&0xFFFF)
+		copy(b, s)
+		w.put(block, offset, b)
+
+	case FieldByteEnum, FieldIntEnum:
+		e, err := toEnum(w.def.Enums[enumKey(field)], value)
+		if err != nil {
+			return err
+		}
+
+		if field.FieldType == FieldByteEnum {
+			w.put(block, offset, int8(e))
+		} else {
+			w.put(block, offset, e)
+		}
+
+	case FieldShortFlags, FieldIntFlags1, FieldIntFlags2:
+		f, err := toFlags(w.def.Enums[enumKey(field)], value)
+		if err != nil {
+			return err
+		}
+
+		if field.FieldType == FieldShortFlags {
+			w.put(block, offset, uint16(f))
+		} else {
+			w.put(block, offset, f)
+		}
+
+	case FieldStruct:
+		return w.writeStruct(SectionType(field.RefKey), value, block, offset)
+
+	case FieldStructPtr:
+		if value == nil {
+			break
+		}
+
+		s, err := toStructValue(value)
+		if err != nil {
+			return err
+		}
+
+		b, o, err := w.alloc(s.Type, w.structSize(s.Type), 16)
+		if err != nil {
+			return err
+		}
+
+		if err := w.writeStruct(s.Type, s.Entry, b, o); err != nil {
+			return err
+		}
+		w.put(block, offset, NewMetaPtr(b, o))
+
+	case FieldArray:
+		list, err := toList(value)
+		if err != nil || len(list) == 0 {
+			return err
+		}
+
+		info, err := elementInfo()
+		if err != nil {
+			return err
+		}
+
+		size := w.def.fieldSize(fields, info)
+		b, o, err := w.alloc(elementBlockType(info), size*uint32(len(list)), 16)
+		if err != nil {
+			return err
+		}
+
+		if err := elements(info, list, b, o); err != nil {
+			return err
+		}
+
+		w.put(block, offset, metaArray{
+			Ptr:      NewMetaPtr(b, o),
+			Count:    uint16(len(list)),
+			Capacity: uint16(len(list)),
+		})
+
+	case FieldFixedArray:
+		list, err := toList(value)
+		if err != nil {
+			return err
+		}
+
+		info, err := elementInfo()
+		if err != nil {
+			return err
+		}
+
+		if count := int(field.RefKey & 0xFFFF); len(list) > count {
+			list = list[:count]
+		}
+		return elements(info, list, block, offset)
+
+	case FieldCharPtr:
+		s, err := toString(value)
+		if err != nil || value == nil {
+			return err
+		}
+
+		b, o, err := w.alloc(SectionSTRINGS, uint32(len(s)+1), 1)
+		if err != nil {
+			return err
+		}
+
+		copy(w.blocks[b].Data[o:], s)
+		w.put(block, offset, metaArray{
+			Ptr:      NewMetaPtr(b, o),
+			Count:    uint16(len(s) + 1),
+			Capacity: uint16(len(s) + 1),
+		})
+
+	case FieldDataBlockPtr:
+		data, err := toBytes(value)
+		if err != nil || len(data) == 0 {
+			return err
+		}
+
+		if len(data) > 0xFFFF {
+			return fmt.Errorf("data block of %v bytes too large", len(data))
+		}
+
+		/* Each data block gets a block of its own, so it can't be read as part of another */
+		b := w.newBlock(SectionType(FieldUint8))
+		w.blocks[b].Data = append([]byte(nil), data...)
+		w.put(block, offset, metaArray{
+			Ptr:      NewMetaPtr(b, 0),
+			Count:    uint16(len(data)),
+			Capacity: uint16(len(data)),
+		})
+
+	default:
+		return fmt.Errorf("unsupported field type %v", field.FieldType)
+	}
+
+	return nil
+}
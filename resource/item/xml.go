@@ -0,0 +1,519 @@
+package item
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+)
+
+/* Import and export of the XML layout used by CodeWalker */
+
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []*xmlNode `xml:",any"`
+}
+
+func newXMLNode(name string) *xmlNode {
+	return &xmlNode{XMLName: xml.Name{Local: name}}
+}
+
+func (n *xmlNode) setAttr(name, value string) {
+	n.Attrs = append(n.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func (n *xmlNode) child(name string) *xmlNode {
+	for _, c := range n.Children {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 32)
+}
+
+func formatVec(v []float32) string {
+	parts := make([]string, len(v))
+	for i, c := range v {
+		parts[i] = formatFloat(float64(c))
+	}
+	return strings.Join(parts, ", ")
+}
+
+/* vecComponents returns the number of components written for a vector type */
+func vecComponents(t FieldType) int {
+	switch t {
+	case FieldVec2f:
+		return 2
+	case FieldVec4f:
+		return 3
+	}
+	return 4
+}
+
+// typeName returns the name of a section type. Types without a name are written as
+// hash_XXXXXXXX, the same as other unknown hashes
+func typeName(t SectionType) string {
+	for _, known := range sectionTypes {
+		if known == t {
+			return t.String()
+		}
+	}
+	return jenkins.Jenkins32(t).Name()
+}
+
+/* ExportXML writes the root structure of the definition as XML */
+func (typ *ItemDefinition) ExportXML(w io.Writer) error {
+	if typ.Root == nil {
+		return fmt.Errorf("definition has no root structure")
+	}
+
+	root, err := typ.structXML(typeName(typ.Root.Type), typ.Root.Type, typ.Root.Entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", " ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func (typ *ItemDefinition) structXML(name string, t SectionType, entry SectionEntry) (*xmlNode, error) {
+	fields, ok := typ.SectionMaps[t]
+	if !ok {
+		return nil, fmt.Errorf("missing section map for %v", t)
+	}
+
+	node := newXMLNode(name)
+	for _, field := range fields {
+		if field.FieldName == ArrayInfoName {
+			continue
+		}
+
+		fieldName := jenkins.Jenkins32(field.FieldName).Name()
+		child, err := typ.fieldXML(fieldName, fields, field, entry[field.FieldName])
+		if err != nil {
+			return nil, fmt.Errorf("%v.%v: %v", t, fieldName, err)
+		}
+		node.Children = append(node.Children, child)
+	}
+	return node, nil
+}
+
+func (typ *ItemDefinition) fieldXML(name string, fields []SectionMapField, field SectionMapField, value FieldValue) (*xmlNode, error) {
+	node := newXMLNode(name)
+
+	switch field.FieldType {
+	case FieldBool:
+		f, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		node.setAttr("value", strconv.FormatBool(f != 0))
+
+	case FieldInt8, FieldUint8, FieldInt16, FieldUint16:
+		i, err := toInt(value)
+		if err != nil {
+			return nil, err
+		}
+		node.setAttr("value", strconv.FormatInt(i, 10))
+
+	case FieldUint32, FieldFlags32:
+		i, err := toInt(value)
+		if err != nil {
+			return nil, err
+		}
+		node.setAttr("value", strconv.FormatUint(uint64(uint32(i)), 10))
+
+	case FieldFloat32:
+		f, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		node.setAttr("value", formatFloat(f))
+
+	case FieldVec2f, FieldVec4f, FieldVec4fXYZW:
+		v, err := toVec(value)
+		if err != nil {
+			return nil, err
+		}
+
+		components := []string{"x", "y", "z", "w"}[:vecComponents(field.FieldType)]
+		for i, c := range components {
+			node.setAttr(c, formatFloat(float64(v[i])))
+		}
+
+	case FieldMat34:
+		m, err := toMatrix(value)
+		if err != nil {
+			return nil, err
+		}
+
+		lines := make([]string, len(m))
+		for i, row := range m {
+			lines[i] = formatVec(row[:3])
+		}
+		node.setAttr("content", "matrix")
+		node.Content = "\n" + strings.Join(lines, "\n") + "\n"
+
+	case FieldJenkins:
+		h, err := toHash(value)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = h.Name()
+
+	case FieldCharArray, FieldCharPtr:
+		s, err := toString(value)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = s
+
+	case FieldByteEnum, FieldIntEnum:
+		switch value := value.(type) {
+		case EnumValue:
+			if value.Named {
+				node.Content = value.Name.Name()
+			} else {
+				node.Content = strconv.Itoa(int(value.Value))
+			}
+		default:
+			i, err := toInt(value)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = strconv.FormatInt(i, 10)
+		}
+
+	case FieldShortFlags, FieldIntFlags1, FieldIntFlags2:
+		switch value := value.(type) {
+		case FlagsValue:
+			if value.Names == nil {
+				node.Content = strconv.FormatUint(uint64(value.Value), 10)
+				break
+			}
+
+			names := make([]string, len(value.Names))
+			for i, n := range value.Names {
+				names[i] = n.Name()
+			}
+			node.Content = strings.Join(names, ", ")
+		default:
+			i, err := toInt(value)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = strconv.FormatInt(i, 10)
+		}
+
+	case FieldStruct:
+		entry, err := toEntry(value)
+		if err != nil {
+			return nil, err
+		}
+		return typ.structXML(name, SectionType(field.RefKey), entry)
+
+	case FieldStructPtr:
+		if value == nil {
+			node.setAttr("type", "NULL")
+			break
+		}
+
+		s, err := toStructValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if node, err = typ.structXML(name, s.Type, s.Entry); err != nil {
+			return nil, err
+		}
+		node.Attrs = append([]xml.Attr{{Name: xml.Name{Local: "type"}, Value: typeName(s.Type)}}, node.Attrs...)
+
+	case FieldArray, FieldFixedArray:
+		if int(field.RefIndex) >= len(fields) {
+			return nil, fmt.Errorf("invalid array info index %v", field.RefIndex)
+		}
+		info := fields[field.RefIndex]
+
+		list, err := toList(value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch info.FieldType {
+		case FieldFloat32, FieldInt8, FieldUint8, FieldInt16, FieldUint16, FieldUint32:
+			lines := make([]string, len(list))
+			for i, v := range list {
+				f, err := toFloat(v)
+				if err != nil {
+					return nil, err
+				}
+				lines[i] = formatFloat(f)
+			}
+
+			contentType := "int_array"
+			if info.FieldType == FieldFloat32 {
+				contentType = "float_array"
+			}
+			node.setAttr("content", contentType)
+			if len(lines) > 0 {
+				node.Content = "\n" + strings.Join(lines, "\n") + "\n"
+			}
+
+		case FieldVec2f, FieldVec4f, FieldVec4fXYZW:
+			lines := make([]string, len(list))
+			for i, v := range list {
+				vec, err := toVec(v)
+				if err != nil {
+					return nil, err
+				}
+				lines[i] = formatVec(vec[:vecComponents(info.FieldType)])
+			}
+
+			node.setAttr("content", fmt.Sprintf("vector%v_array", vecComponents(info.FieldType)))
+			if len(lines) > 0 {
+				node.Content = "\n" + strings.Join(lines, "\n") + "\n"
+			}
+
+		default:
+			for _, v := range list {
+				item, err := typ.fieldXML("Item", fields, info, v)
+				if err != nil {
+					return nil, err
+				}
+				node.Children = append(node.Children, item)
+			}
+		}
+
+	case FieldDataBlockPtr:
+		data, err := toBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		node.setAttr("content", "char_array")
+		node.Content = hex.EncodeToString(data)
+
+	default:
+		return nil, fmt.Errorf("unsupported field type %v", field.FieldType)
+	}
+
+	return node, nil
+}
+
+// ImportXML replaces the root structure of the definition with one read from XML.
+// The definition must already hold the schema for the structures in the document,
+// for example by loading an existing file of the same type
+func (typ *ItemDefinition) ImportXML(r io.Reader) error {
+	root := new(xmlNode)
+	if err := xml.NewDecoder(r).Decode(root); err != nil {
+		return err
+	}
+
+	t := ParseSectionType(root.XMLName.Local)
+	entry, err := typ.structFromXML(t, root)
+	if err != nil {
+		return err
+	}
+
+	return typ.SetRoot(StructValue{Type: t, Entry: entry})
+}
+
+func (typ *ItemDefinition) structFromXML(t SectionType, node *xmlNode) (SectionEntry, error) {
+	fields, ok := typ.SectionMaps[t]
+	if !ok {
+		return nil, fmt.Errorf("missing section map for %v", t)
+	}
+
+	entry := make(SectionEntry)
+	for _, field := range fields {
+		if field.FieldName == ArrayInfoName {
+			continue
+		}
+
+		fieldName := jenkins.Jenkins32(field.FieldName).Name()
+		child := node.child(fieldName)
+		if child == nil {
+			continue
+		}
+
+		value, err := typ.fieldFromXML(fields, field, child)
+		if err != nil {
+			return nil, fmt.Errorf("%v.%v: %v", t, fieldName, err)
+		}
+		entry[field.FieldName] = value
+	}
+	return entry, nil
+}
+
+func (typ *ItemDefinition) fieldFromXML(fields []SectionMapField, field SectionMapField, node *xmlNode) (FieldValue, error) {
+	number := func(s string) (float64, error) {
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	}
+
+	content := strings.TrimSpace(node.Content)
+
+	switch field.FieldType {
+	case FieldBool:
+		value, _ := node.attr("value")
+		return strconv.ParseBool(value)
+
+	case FieldInt8, FieldUint8, FieldInt16, FieldUint16, FieldFloat32:
+		value, _ := node.attr("value")
+		return number(value)
+
+	case FieldUint32, FieldFlags32:
+		value, _ := node.attr("value")
+		i, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+		return uint32(i), err
+
+	case FieldVec2f, FieldVec4f, FieldVec4fXYZW:
+		result := make([]interface{}, 4)
+		for i, c := range []string{"x", "y", "z", "w"} {
+			value, ok := node.attr(c)
+			if !ok {
+				result[i] = float64(0)
+				continue
+			}
+
+			f, err := number(value)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = f
+		}
+		return result, nil
+
+	case FieldMat34:
+		return vectorLines(content)
+
+	case FieldJenkins:
+		return jenkins.Parse(content), nil
+
+	case FieldCharArray, FieldCharPtr:
+		return node.Content, nil
+
+	case FieldByteEnum, FieldIntEnum:
+		if f, err := number(content); err == nil {
+			return f, nil
+		}
+		return content, nil
+
+	case FieldShortFlags, FieldIntFlags1, FieldIntFlags2:
+		if f, err := number(content); err == nil {
+			return f, nil
+		}
+
+		names := make([]interface{}, 0)
+		for _, name := range strings.FieldsFunc(content, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		}) {
+			names = append(names, name)
+		}
+		return names, nil
+
+	case FieldStruct:
+		return typ.structFromXML(SectionType(field.RefKey), node)
+
+	case FieldStructPtr:
+		name, _ := node.attr("type")
+		if name == "" || name == "NULL" {
+			return nil, nil
+		}
+
+		t := ParseSectionType(name)
+		entry, err := typ.structFromXML(t, node)
+		if err != nil {
+			return nil, err
+		}
+		return StructValue{Type: t, Entry: entry}, nil
+
+	case FieldArray, FieldFixedArray:
+		if int(field.RefIndex) >= len(fields) {
+			return nil, fmt.Errorf("invalid array info index %v", field.RefIndex)
+		}
+		info := fields[field.RefIndex]
+
+		var err error
+		list := make([]interface{}, 0)
+		contentType, _ := node.attr("content")
+		switch contentType {
+		case "int_array", "float_array":
+			for _, s := range strings.Fields(content) {
+				f, err := number(s)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, f)
+			}
+
+		case "vector2_array", "vector3_array", "vector4_array":
+			if list, err = vectorLines(content); err != nil {
+				return nil, err
+			}
+
+		default:
+			for _, child := range node.Children {
+				value, err := typ.fieldFromXML(fields, info, child)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+		}
+		return list, nil
+
+	case FieldDataBlockPtr:
+		return hex.DecodeString(strings.Join(strings.Fields(content), ""))
+	}
+
+	return nil, fmt.Errorf("unsupported field type %v", field.FieldType)
+}
+
+/* vectorLines parses a vector per line, with comma separated components */
+func vectorLines(content string) ([]interface{}, error) {
+	list := make([]interface{}, 0)
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		vec := make([]interface{}, 0, 4)
+		for _, s := range strings.Split(line, ",") {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, err
+			}
+			vec = append(vec, f)
+		}
+		list = append(list, vec)
+	}
+	return list, nil
+}
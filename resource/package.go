@@ -1,8 +1,6 @@
 package resource
 
 import (
-	"fmt"
-
 	"github.com/tgascoigne/ragekit/resource/crypto"
 	"github.com/tgascoigne/ragekit/resource/types"
 	"github.com/tgascoigne/ragekit/util/stack"
@@ -57,14 +55,15 @@ func (pkg *Package) Unpack(data []byte, filename string, filesize uint32) error
 		return err
 	}
 
-	ctx, err := pkg.cryptoContext()
-	if err != nil {
-		return err
+	/* Unencrypted packages can be read without any keys */
+	if pkg.Header.Encryption != EncNone {
+		pkg.cryptoCtx, err = pkg.cryptoContext()
+		if err != nil {
+			return err
+		}
 	}
 
-	pkg.cryptoCtx = ctx
-
-	err = pkg.decryptTOC(ctx)
+	err = pkg.decryptTOC(pkg.cryptoCtx)
 	if err != nil {
 		return err
 	}
@@ -72,7 +71,7 @@ func (pkg *Package) Unpack(data []byte, filename string, filesize uint32) error
 	pkg.entries = make([]PackageNode, pkg.Header.EntryCount)
 	pkg.entriesVisited = make([]bool, pkg.Header.EntryCount)
 
-	fmt.Printf("header %#v\n", pkg.Header)
+	debugf("header %#v\n", pkg.Header)
 
 	for i := uint32(0); i < pkg.Header.EntryCount; i++ {
 		entry, err := pkg.parseEntry()
@@ -81,7 +80,7 @@ func (pkg *Package) Unpack(data []byte, filename string, filesize uint32) error
 		}
 
 		pkg.entries[i] = entry
-		fmt.Printf("entry %v %#v\n", i, entry)
+		debugf("entry %v %#v\n", i, entry)
 	}
 
 	return nil
@@ -5,7 +5,6 @@ import (
 	"compress/flate"
 	"encoding/binary"
 	"errors"
-	"fmt"
 	"io"
 	"io/ioutil"
 
@@ -292,12 +291,12 @@ func (pkg *Package) decryptTOC(ctx *crypto.Context) error {
 	//	pkg.blocksPtr = pkg.namesPtr + types.Ptr32(pkg.Header.NamesLength)
 	//	fmt.Printf("block ptr is %v\n", pkg.blocksPtr)
 
-	fmt.Printf("encryption is %v\n", pkg.Header.Encryption)
+	debugf("encryption is %v\n", pkg.Header.Encryption)
 	if pkg.Header.Encryption == EncNone {
 		// nothing to do
 	} else if pkg.Header.Encryption == EncAES {
 		// AES
-		fmt.Printf("decrypting aes\n")
+		debugf("decrypting aes\n")
 		err := pkg.Detour(pkg.entriesPtr, func() error {
 			return pkg.Decrypt(ctx, entriesTotalBytes)
 		})
@@ -316,7 +315,7 @@ func (pkg *Package) decryptTOC(ctx *crypto.Context) error {
 
 	} else {
 		// NG
-		fmt.Printf("decrypting ng\n")
+		debugf("decrypting ng\n")
 		err := pkg.Detour(pkg.entriesPtr, func() error {
 			return pkg.DecryptPackageNG(ctx, entriesTotalBytes)
 		})
@@ -0,0 +1,180 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	packageMagic  = 0x52504637
+	resourceMagic = 0x37435352
+)
+
+/* packageNode is a file or directory waiting to be packed */
+type packageNode struct {
+	name     string
+	data     []byte
+	dir      bool
+	children map[string]*packageNode
+}
+
+// PackageBuilder assembles an unencrypted package. Files which start with a resource header are
+// stored as resource entries, and everything else is stored as uncompressed blobs
+type PackageBuilder struct {
+	root *packageNode
+}
+
+func NewPackageBuilder() *PackageBuilder {
+	return &PackageBuilder{
+		root: &packageNode{dir: true, children: make(map[string]*packageNode)},
+	}
+}
+
+/* Add adds a file to the package. Directories in its path are created as needed */
+func (b *PackageBuilder) Add(path string, data []byte) error {
+	parts := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '\\'
+	})
+
+	if len(parts) == 0 {
+		return fmt.Errorf("invalid package path: %v", path)
+	}
+
+	node := b.root
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := node.children[strings.ToLower(part)]
+		if !ok {
+			child = &packageNode{name: part, dir: true, children: make(map[string]*packageNode)}
+			node.children[strings.ToLower(part)] = child
+		} else if !child.dir {
+			return fmt.Errorf("%v: %v is a file", path, part)
+		}
+		node = child
+	}
+
+	name := parts[len(parts)-1]
+	if existing, ok := node.children[strings.ToLower(name)]; ok && existing.dir {
+		return fmt.Errorf("%v is a directory", path)
+	}
+
+	node.children[strings.ToLower(name)] = &packageNode{name: name, data: data}
+	return nil
+}
+
+/* sortedChildren returns the children of a directory ordered by name, as the game expects */
+func (n *packageNode) sortedChildren() []*packageNode {
+	children := make([]*packageNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return strings.ToLower(children[i].name) < strings.ToLower(children[j].name)
+	})
+	return children
+}
+
+/* Pack returns the contents of the package file */
+func (b *PackageBuilder) Pack() ([]byte, error) {
+	/* Lay out the entries breadth first, so that the children of each directory are contiguous */
+	nodes := []*packageNode{b.root}
+	firstChild := make(map[*packageNode]int)
+	for i := 0; i < len(nodes); i++ {
+		if nodes[i].dir {
+			firstChild[nodes[i]] = len(nodes)
+			nodes = append(nodes, nodes[i].sortedChildren()...)
+		}
+	}
+
+	var names bytes.Buffer
+	nameOffsets := make([]uint32, len(nodes))
+	for i, node := range nodes {
+		nameOffsets[i] = uint32(names.Len())
+		names.WriteString(node.name)
+		names.WriteByte(0)
+	}
+
+	for names.Len()%16 != 0 {
+		names.WriteByte(0)
+	}
+
+	if names.Len() > 0xFFFF {
+		return nil, fmt.Errorf("package names table is too large (%v bytes)", names.Len())
+	}
+
+	entrySize := uint32(binary.Size(PackageDirEntry{}))
+	tocSize := 0x10 + entrySize*uint32(len(nodes)) + uint32(names.Len())
+	firstBlock := (tocSize + BlockSize - 1) / BlockSize
+
+	var entries, blocks bytes.Buffer
+	for i, node := range nodes {
+		if node.dir {
+			binary.Write(&entries, binary.LittleEndian, []uint32{
+				nameOffsets[i],
+				0x7FFFFF00,
+				uint32(firstChild[node]),
+				uint32(len(node.children)),
+			})
+			continue
+		}
+
+		size := uint32(len(node.data))
+		offset := firstBlock + uint32(blocks.Len())/BlockSize
+		if size >= 0xFFFFFF || offset > 0x7FFFFF {
+			return nil, fmt.Errorf("%v is too large to pack", node.name)
+		}
+
+		if isResource(node.data) {
+			var header ContainerHeader
+			binary.Read(bytes.NewReader(node.data), binary.LittleEndian, &header)
+
+			/* The container version is split across the top nibbles of the flags */
+			version := header.Version & 0xFF
+			sysFlags := (header.SysFlags & 0x0FFFFFFF) | ((version >> 4) << 28)
+			gfxFlags := (header.GfxFlags & 0x0FFFFFFF) | ((version & 0xF) << 28)
+
+			entries.Write(packedEntry(nameOffsets[i], size, offset|0x800000))
+			binary.Write(&entries, binary.LittleEndian, []uint32{sysFlags, gfxFlags})
+		} else {
+			entries.Write(packedEntry(nameOffsets[i], 0, offset))
+			binary.Write(&entries, binary.LittleEndian, []uint32{size, 0})
+		}
+
+		blocks.Write(node.data)
+		for blocks.Len()%int(BlockSize) != 0 {
+			blocks.WriteByte(0)
+		}
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, PackageHeader{
+		Magic:       packageMagic,
+		EntryCount:  uint32(len(nodes)),
+		NamesLength: uint32(names.Len()),
+		Encryption:  EncNone,
+	})
+	out.Write(entries.Bytes())
+	out.Write(names.Bytes())
+	for out.Len()%int(BlockSize) != 0 {
+		out.WriteByte(0)
+	}
+	out.Write(blocks.Bytes())
+
+	return out.Bytes(), nil
+}
+
+/* packedEntry returns the name offset, 24 bit size and 24 bit block offset common to file entries */
+func packedEntry(nameOffset, size, offset uint32) []byte {
+	return []byte{
+		byte(nameOffset), byte(nameOffset >> 8),
+		byte(size), byte(size >> 8), byte(size >> 16),
+		byte(offset), byte(offset >> 8), byte(offset >> 16),
+	}
+}
+
+func isResource(data []byte) bool {
+	return len(data) >= 0x10 && binary.LittleEndian.Uint32(data) == resourceMagic
+}
@@ -0,0 +1,57 @@
+package resource
+
+import (
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+/* Ptr64 is a 64 bit pointer, of which only the lower 32 bits are meaningful */
+type Ptr64 struct {
+	Addr types.Ptr32
+	_    uint32
+}
+
+/* PointerCollection64 is the 64 bit equivalent of PointerCollection, as found in PC resources */
+type PointerCollection64 struct {
+	Addr     types.Ptr32
+	_        uint32
+	Count    uint16
+	Capacity uint16
+	_        uint32
+}
+
+func (col *PointerCollection64) Pointers(res *Container) ([]types.Ptr32, error) {
+	ptrs := make([]types.Ptr32, col.Count)
+	if !col.Addr.Valid() {
+		return ptrs, nil
+	}
+
+	err := res.Detour(col.Addr, func() error {
+		for i := range ptrs {
+			var ptr Ptr64
+			res.Parse(&ptr)
+			ptrs[i] = ptr.Addr
+		}
+		return nil
+	})
+	return ptrs, err
+}
+
+func (col *PointerCollection64) For(res *Container, callback func(i int) error) error {
+	ptrs, err := col.Pointers(res)
+	if err != nil {
+		return err
+	}
+
+	for i, addr := range ptrs {
+		if !addr.Valid() {
+			continue
+		}
+
+		if err := res.Detour(addr, func() error {
+			return callback(i)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
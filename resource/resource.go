@@ -5,9 +5,9 @@ import (
 	"compress/flate"
 	"encoding/binary"
 	"errors"
-	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 
 	"github.com/Microsoft/go-winio/wim/lzx"
 	"github.com/tgascoigne/ragekit/resource/crypto"
@@ -23,7 +23,7 @@ const (
 )
 
 const (
-	CryptoKeyEnv = "RAGEKIT_KEY_DIR"
+	CryptoKeyEnv = crypto.KeyDirEnv
 )
 
 var ErrInvalidResource error = errors.New("invalid resource")
@@ -36,6 +36,8 @@ type ContainerHeader struct {
 	GfxFlags uint32
 }
 
+// Type returns the container type from the version. Containers extracted from packages are given a
+// little endian header on every architecture, so console resources have the same type as on PC
 func (c ContainerHeader) Type() uint8 {
 	return uint8((c.Version >> 24) & 0xFF)
 }
@@ -86,6 +88,22 @@ func (res *Container) DecryptNG(ctx *crypto.Context, filename string, filesize u
 	return nil
 }
 
+/* isPlaintext checks whether the data inflates to the size of the partitions, without being decrypted first */
+func (res *Container) isPlaintext() bool {
+	deflateReader := flate.NewReader(bytes.NewReader(res.Data[res.position:]))
+	deflated, err := ioutil.ReadAll(deflateReader)
+	if err != nil {
+		return false
+	}
+
+	/* The header is read big endian, so take the flags from the data in the native order */
+	sysFlags := nativeEndian.Uint32(res.Data[0x8:])
+	gfxFlags := nativeEndian.Uint32(res.Data[0xC:])
+
+	expected := getPartitionSize(sysFlags) + getPartitionSize(gfxFlags)
+	return uint32(len(deflated)) == expected
+}
+
 func (res *Container) Deflate() error {
 	deflateReader := flate.NewReader(bytes.NewReader(res.Data[res.position:]))
 	deflated, err := ioutil.ReadAll(deflateReader)
@@ -134,24 +152,26 @@ func (res *Container) Unpack(data []byte, filename string, filesize uint32) erro
 	res.Data = data
 	res.size = int64(len(data))
 
-	keys, err := crypto.LoadKeys()
-	if err != nil {
-		panic(err)
-	}
+	/* Only scripts are encrypted, so don't require the keys for anything else.
+	   Scripts taken from unencrypted packages are left as they are */
+	if res.Header.Type() == ResourceScript && !res.isPlaintext() {
+		keys, err := crypto.LoadKeys()
+		if err != nil {
+			panic(err)
+		}
 
-	ctx := crypto.NewContext(keys)
+		ctx := crypto.NewContext(keys)
 
-	if res.Header.Type() == ResourceScript {
 		err = res.DecryptNG(ctx, filename, filesize)
 		//err = res.Decrypt(ctx)
 		if err != nil {
-			fmt.Printf("Decrypt failed: %v\n", err)
+			log.Printf("Decrypt failed: %v\n", err)
 		}
 	}
 
-	err = res.Deflate()
+	err := res.Deflate()
 	if err != nil {
-		fmt.Printf("Deflate failed: %v\n", err)
+		log.Printf("Deflate failed: %v\n", err)
 	}
 
 	return nil
@@ -0,0 +1,512 @@
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+const (
+	/* Code and strings are split into pages, and an instruction may not cross a page boundary */
+	pageSize = 0x4000
+
+	systemBase = 0x50000000
+)
+
+var (
+	/* a label definition, or the address prefix written by the disassembler */
+	labelRegexp = regexp.MustCompile(`^([A-Za-z_$][\w$.]*|[0-9a-fA-F]{8}):(\s|$)`)
+
+	addressRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}$`)
+)
+
+/* mnemonics maps each mnemonic and suffix to the opcodes which share it, lowest first */
+var mnemonics = map[string][]uint8{}
+
+func init() {
+	for opcode := 0; opcode <= 0xFF; opcode++ {
+		op, ok := OpType[uint8(opcode)]
+		if !ok {
+			continue
+		}
+
+		mn := OpMnemonic[op] + OpSuffix[uint8(opcode)]
+		mnemonics[mn] = append(mnemonics[mn], uint8(opcode))
+	}
+}
+
+type pagesInfo struct {
+	_          uint32
+	_          uint32
+	SysPages   uint8
+	GfxPages   uint8
+	_          uint16
+	_          uint32
+	SysPagePtr uint64
+	_          uint64
+}
+
+// Assembler builds a script from the syntax printed by the disassembler. Each line is an
+// instruction, optionally preceded by labels, which branches, calls and switches refer to.
+// The address prefixes printed by the disassembler are treated as labels, so its output can
+// be edited and reassembled directly. Lines starting with a '.' are directives which describe
+// the script's data:
+//
+//	.name "title"      the script's name
+//	.native 1234abcd   a native table entry, unmangled. Natives called by calln are added as needed
+//	.static 0          the initial value of the next static
+//	.string "text"     the next null terminated string in the string table
+//
+// Comments start with a ';'. Annotations in angle brackets are ignored, other than the name of
+// an enter instruction
+type Assembler struct {
+	Title string
+
+	natives []Native64
+	statics []uint64
+	strings bytes.Buffer
+
+	code   []*Instruction
+	labels map[string]int
+
+	sizing bool
+}
+
+func NewAssembler() *Assembler {
+	return &Assembler{
+		labels: make(map[string]int),
+	}
+}
+
+// Assemble builds a script resource from its assembly
+func Assemble(name string, src io.Reader) ([]byte, error) {
+	asm := NewAssembler()
+	asm.Title = name
+
+	if err := asm.Parse(name, src); err != nil {
+		return nil, err
+	}
+	return asm.Pack()
+}
+
+/* Parse reads the instructions and directives in src */
+func (asm *Assembler) Parse(name string, src io.Reader) error {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(nil, 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if err := asm.parseLine(scanner.Text()); err != nil {
+			return fmt.Errorf("%v:%v: %v", name, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (asm *Assembler) parseLine(line string) error {
+	line = strings.TrimSpace(stripComment(line))
+
+	for {
+		match := labelRegexp.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+
+		label := labelName(match[1])
+		if _, ok := asm.labels[label]; ok {
+			return fmt.Errorf("duplicate label %v", label)
+		}
+
+		asm.labels[label] = len(asm.code)
+		line = strings.TrimSpace(line[len(match[1])+1:])
+	}
+
+	if line == "" {
+		return nil
+	}
+
+	mn, args := line, ""
+	if idx := strings.IndexAny(line, " \t"); idx != -1 {
+		mn, args = line[:idx], strings.TrimSpace(line[idx+1:])
+	}
+
+	if strings.HasPrefix(mn, ".") {
+		return asm.parseDirective(mn, args)
+	}
+
+	opcodes, ok := mnemonics[mn]
+	if !ok {
+		return fmt.Errorf("unknown instruction %v", mn)
+	}
+
+	/* Several opcodes can share a mnemonic, so use the first whose operands match */
+	var err error
+	for _, opcode := range opcodes {
+		istr := &Instruction{
+			Opcode:    opcode,
+			Operation: OpType[opcode],
+			Operands:  &NoOperands{},
+		}
+
+		if operandFunc, ok := OperandFunc[opcode]; ok {
+			istr.Operands = operandFunc()
+		}
+
+		if err = istr.Operands.Parse(istr, args, asm); err == nil {
+			asm.code = append(asm.code, istr)
+			return nil
+		}
+	}
+	return fmt.Errorf("%v: %v", mn, err)
+}
+
+func (asm *Assembler) parseDirective(directive, args string) error {
+	switch directive {
+	case ".name":
+		title, err := strconv.Unquote(args)
+		if err != nil {
+			return fmt.Errorf("invalid name %v", args)
+		}
+		asm.Title = title
+
+	case ".native":
+		native, err := parseNative(args)
+		if err != nil {
+			return err
+		}
+		asm.nativeIndex(native)
+
+	case ".static":
+		vals, err := parseImmediates(args, 1, 64)
+		if err != nil {
+			return err
+		}
+		asm.statics = append(asm.statics, vals[0])
+
+	case ".string":
+		str, err := strconv.Unquote(args)
+		if err != nil {
+			return fmt.Errorf("invalid string %v", args)
+		}
+		asm.strings.WriteString(str)
+		asm.strings.WriteByte(0)
+
+	default:
+		return fmt.Errorf("unknown directive %v", directive)
+	}
+	return nil
+}
+
+/* nativeIndex returns the index of a native in the native table, adding it if it's not present */
+func (asm *Assembler) nativeIndex(native Native64) int {
+	for i, n := range asm.natives {
+		if n == native {
+			return i
+		}
+	}
+
+	asm.natives = append(asm.natives, native)
+	return len(asm.natives) - 1
+}
+
+/* relative returns the address of a label relative to from, checking that it's within [min, max] */
+func (asm *Assembler) relative(label string, from uint32, min, max int64) (int64, error) {
+	if asm.sizing {
+		return 0, nil
+	}
+
+	idx, ok := asm.labels[label]
+	if !ok {
+		return 0, fmt.Errorf("undefined label %v", label)
+	}
+
+	var addr uint32
+	if idx < len(asm.code) {
+		addr = asm.code[idx].Address
+	} else {
+		addr = asm.codeLength()
+	}
+
+	rel := int64(addr) - int64(from)
+	if rel < min || rel > max {
+		return 0, fmt.Errorf("%v is out of range (%v)", label, rel)
+	}
+	return rel, nil
+}
+
+func (asm *Assembler) codeLength() uint32 {
+	if len(asm.code) == 0 {
+		return 0
+	}
+
+	last := asm.code[len(asm.code)-1]
+	return last.Address + uint32(asm.size(last))
+}
+
+/* size returns the encoded size of an instruction */
+func (asm *Assembler) size(istr *Instruction) int {
+	var buf bytes.Buffer
+	asm.sizing = true
+	istr.Operands.Pack(istr, asm, &buf)
+	asm.sizing = false
+	return 1 + buf.Len()
+}
+
+/* layout assigns addresses to the instructions, moving any which would cross a page to the next one */
+func (asm *Assembler) layout() {
+	addr := uint32(0)
+	for _, istr := range asm.code {
+		size := uint32(asm.size(istr))
+		if addr%pageSize+size > pageSize {
+			addr += pageSize - addr%pageSize
+		}
+
+		istr.Address = addr
+		addr += size
+	}
+}
+
+/* assembleCode returns the code, with the space between pages filled with nops */
+func (asm *Assembler) assembleCode() ([]byte, error) {
+	asm.layout()
+
+	code := make([]byte, asm.codeLength())
+	for _, istr := range asm.code {
+		var buf bytes.Buffer
+		buf.WriteByte(istr.Opcode)
+		if err := istr.Operands.Pack(istr, asm, &buf); err != nil {
+			return nil, fmt.Errorf("%.8x: %v", istr.Address, err)
+		}
+		copy(code[istr.Address:], buf.Bytes())
+	}
+	return code, nil
+}
+
+/* Pack builds the script resource */
+func (asm *Assembler) Pack() ([]byte, error) {
+	code, err := asm.assembleCode()
+	if err != nil {
+		return nil, err
+	}
+
+	order := resource.ByteOrder()
+	var buf bytes.Buffer
+
+	ptr := func() types.Ptr32 {
+		return types.Ptr32(systemBase + buf.Len())
+	}
+
+	align := func(n int) {
+		for buf.Len()%n != 0 {
+			buf.WriteByte(0)
+		}
+	}
+
+	write := func(data interface{}) {
+		binary.Write(&buf, order, data)
+	}
+
+	/* Pointers in the tables are 64 bits wide */
+	writePages := func(data []byte) types.Ptr32 {
+		pages := make([]uint64, 0)
+		for offset := 0; offset < len(data); offset += pageSize {
+			end := offset + pageSize
+			if end > len(data) {
+				end = len(data)
+			}
+
+			align(16)
+			pages = append(pages, uint64(ptr()))
+			buf.Write(data[offset:end])
+		}
+
+		align(16)
+		addr := ptr()
+		write(pages)
+		return addr
+	}
+
+	var header ScriptHeader
+	buf.Write(make([]byte, binary.Size(header)))
+
+	/* Page map */
+	header.BlockMap = ptr()
+	write(pagesInfo{SysPages: 1, SysPagePtr: systemBase})
+
+	header.TitlePtr = ptr()
+	buf.WriteString(asm.Title)
+	buf.WriteByte(0)
+
+	header.CodeLength = uint32(len(code))
+	header.CodeMapPtr = writePages(code)
+
+	header.StringTableLen = uint32(asm.strings.Len())
+	header.StringTablePtr = writePages(asm.strings.Bytes())
+
+	align(16)
+	header.NativeCount = uint32(len(asm.natives))
+	header.NativeTable = ptr()
+	for i, native := range asm.natives {
+		write(native.mangle(header.CodeLength, i))
+	}
+
+	align(16)
+	header.StaticCount = uint32(len(asm.statics))
+	header.StaticTable = ptr()
+	write(asm.statics)
+
+	var headerBuf bytes.Buffer
+	binary.Write(&headerBuf, order, header)
+
+	sys := buf.Bytes()
+	copy(sys, headerBuf.Bytes())
+
+	return resource.Pack(resource.ResourceScript, sys, nil)
+}
+
+// Directives returns the directives describing the script's data, in the form read by the assembler
+func (script *Script) Directives() []string {
+	lines := make([]string, 0)
+	if script.Title != "" {
+		lines = append(lines, fmt.Sprintf(".name %v", strconv.Quote(script.Title)))
+	}
+
+	for _, native := range script.NativeTable {
+		lines = append(lines, fmt.Sprintf(".native %x", native))
+	}
+
+	for _, value := range script.StaticValues {
+		lines = append(lines, fmt.Sprintf(".static %v", value))
+	}
+
+	table := script.StringTable
+	for len(table) > 0 {
+		end := bytes.IndexByte(table, 0)
+		if end == -1 {
+			/* the last string is unterminated */
+			lines = append(lines, fmt.Sprintf(".string %v", strconv.Quote(string(table))))
+			break
+		}
+
+		lines = append(lines, fmt.Sprintf(".string %v", strconv.Quote(string(table[:end]))))
+		table = table[end+1:]
+	}
+
+	return lines
+}
+
+/* labelName returns the canonical name of a label, so that addresses match regardless of case */
+func labelName(s string) string {
+	if addressRegexp.MatchString(s) {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+/* stripComment removes a trailing ';' comment which isn't within a string */
+func stripComment(line string) string {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+/* splitOperands splits operands on whitespace and commas, separating out <annotations> */
+func splitOperands(args string) (fields []string, notes []string) {
+	for i := 0; i < len(args); {
+		switch c := args[i]; {
+		case c == ' ' || c == '\t' || c == ',':
+			i++
+
+		case c == '<':
+			end := strings.IndexByte(args[i:], '>')
+			if end == -1 {
+				end = len(args) - i
+			}
+			notes = append(notes, args[i+1:i+end])
+			i += end + 1
+
+		case c == '"':
+			end := i + 1
+			for end < len(args) && args[end] != '"' {
+				if args[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end > len(args)-1 {
+				end = len(args) - 1
+			}
+			fields = append(fields, args[i:end+1])
+			i = end + 1
+
+		default:
+			end := strings.IndexAny(args[i:], " \t,<")
+			if end == -1 {
+				end = len(args) - i
+			}
+			fields = append(fields, args[i:i+end])
+			i += end
+		}
+	}
+	return fields, notes
+}
+
+func expectFields(fields []string, n int) error {
+	if len(fields) != n {
+		return fmt.Errorf("expected %v operands, got %v", n, len(fields))
+	}
+	return nil
+}
+
+/* parseImmediates parses n integers of the given size. Negative values are stored as two's complement */
+func parseImmediates(args string, n int, bits uint) ([]uint64, error) {
+	fields, _ := splitOperands(args)
+	if err := expectFields(fields, n); err != nil {
+		return nil, err
+	}
+
+	vals := make([]uint64, n)
+	for i, field := range fields {
+		if strings.HasPrefix(field, "-") {
+			val, err := strconv.ParseInt(field, 0, int(bits))
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = uint64(val) & (1<<bits - 1)
+			continue
+		}
+
+		val, err := strconv.ParseUint(field, 0, int(bits))
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = val
+	}
+	return vals, nil
+}
+
+/* parseNative parses a native hash, which is written in hex with or without a 0x prefix */
+func parseNative(s string) (Native64, error) {
+	hash, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(s), "0x"), 16, 64)
+	return Native64(hash), err
+}
@@ -0,0 +1,111 @@
+package script
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tgascoigne/ragekit/resource"
+)
+
+const roundTripSource = `; round trip test
+.native 4edc1d3e
+.static 5
+.static -1
+.string "hello; world"
+.string "bye\x01"
+main:
+    enter 0 3 0 5 <main>
+    pushim 2
+    pushimf -1
+    pushb 1 2
+    pushi 305419896
+    pushf 1.5
+    pushs 1000
+    pusht 70000
+    dupv
+    dup
+    strcpy 16
+    getfieldpb 3
+    calln 0xdeadbeefcafe 2 1
+    calln 4edc1d3e 0 0
+    call helper
+loop:
+    getlocal 2
+    switch 1: case1, 2: case2
+    bz loop
+    b done
+case1:
+    pushstr ""
+    b loop
+case2:
+    nop
+done:
+    ret 0 0
+helper:
+    enter 0 2 0 0
+    pushim 7
+    ret 0 1
+`
+
+/* disassemble unpacks an assembled script and lists it the way the disassembler does */
+func disassemble(t *testing.T, name string, data []byte) string {
+	res := new(resource.Container)
+	if err := res.Unpack(data, name, uint32(len(data))); err != nil {
+		t.Fatalf("unpacking container: %v", err)
+	}
+
+	var buf bytes.Buffer
+	script := NewScript(name, uint32(len(data)))
+	err := script.Unpack(res, func(istr Instruction) {
+		fmt.Fprintln(&buf, istr.String())
+	})
+	if err != nil {
+		t.Fatalf("unpacking script: %v", err)
+	}
+
+	return strings.Join(script.Directives(), "\n") + "\n" + buf.String()
+}
+
+func TestAssemblerRoundTrip(t *testing.T) {
+	resource.SetArch(resource.ArchPC)
+
+	data, err := Assemble("test", strings.NewReader(roundTripSource))
+	if err != nil {
+		t.Fatalf("assembling source: %v", err)
+	}
+	first := disassemble(t, "test.ysc", data)
+
+	reassembled, err := Assemble("test", strings.NewReader(first))
+	if err != nil {
+		t.Fatalf("assembling disassembly: %v\n%v", err, first)
+	}
+	second := disassemble(t, "test.ysc", reassembled)
+
+	if first != second {
+		t.Errorf("disassembly changed after reassembling\nfirst:\n%v\nsecond:\n%v", first, second)
+	}
+
+	if !bytes.Equal(data, reassembled) {
+		t.Errorf("reassembled script differs from the original (%v and %v bytes)", len(reassembled), len(data))
+	}
+}
+
+func TestDirectivesUnterminatedString(t *testing.T) {
+	script := &Script{StringTable: []byte("first\x00second")}
+
+	directives := script.Directives()
+	want := []string{`.string "first"`, `.string "second"`}
+	if len(directives) < len(want) {
+		t.Fatalf("expected %v, got %v", want, directives)
+	}
+
+	got := directives[len(directives)-len(want):]
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+			break
+		}
+	}
+}
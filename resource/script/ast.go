@@ -25,11 +25,54 @@ const (
 	GotoToken   Token = "goto"
 	IfToken     Token = "if"
 	NotEqToken  Token = "!="
+	EqToken     Token = "=="
+	GtToken     Token = ">"
+	GeToken     Token = ">="
+	LtToken     Token = "<"
+	LeToken     Token = "<="
 	ReturnToken Token = "return"
 	ExternToken Token = "extern"
 	StaticToken Token = "static"
+
+	ElseToken     Token = "else"
+	WhileToken    Token = "while"
+	DoToken       Token = "do"
+	ForToken      Token = "for"
+	SwitchToken   Token = "switch"
+	CaseToken     Token = "case"
+	DefaultToken  Token = "default"
+	BreakToken    Token = "break"
+	ContinueToken Token = "continue"
 )
 
+// Binding strength of the binary operators, used to decide where parentheses are needed
+var precedence = map[Token]int{
+	MulToken:   10,
+	DivToken:   10,
+	ModToken:   10,
+	AddToken:   9,
+	SubToken:   9,
+	GtToken:    7,
+	GeToken:    7,
+	LtToken:    7,
+	LeToken:    7,
+	EqToken:    6,
+	NotEqToken: 6,
+	XorToken:   4,
+	AndToken:   3,
+	OrToken:    2,
+}
+
+// Comparisons which are true when the other is false
+var inverseComparisons = map[Token]Token{
+	EqToken:    NotEqToken,
+	NotEqToken: EqToken,
+	GtToken:    LeToken,
+	GeToken:    LtToken,
+	LtToken:    GeToken,
+	LeToken:    GtToken,
+}
+
 func (t Token) CString() string {
 	return string(t)
 }
@@ -44,15 +87,26 @@ type File struct {
 	Decls     Declarations
 	Functions []*Function
 	Nodes     []Node
+
+	/* GlobalNames names globals by index, as read from a globals header */
+	GlobalNames map[int]GlobalName
 }
 
 func (f *File) GlobalByIndex(index int) Node {
 	identifier := fmt.Sprintf("global_%v", index)
+	typ := UnknownType
+	if name, ok := f.GlobalNames[index]; ok {
+		identifier = name.Name
+		if t, ok := LookupType(name.Type); ok {
+			typ = t
+		}
+	}
+
 	if !f.Decls.HasVariable(identifier) {
 		decl := &VariableDeclaration{
 			Variable: &Variable{
 				Identifier: identifier,
-				Type:       UnknownType,
+				Type:       typ,
 			},
 			Scope: ExternToken,
 		}
@@ -70,7 +124,7 @@ func (f *File) FunctionByName(identifier string) *Function {
 		}
 	}
 
-	panic(fmt.Sprintf("unknown function: ", identifier))
+	panic(fmt.Sprintf("unknown function: %v", identifier))
 }
 
 func (f *File) FunctionByAddress(addr uint32) *Function {
@@ -83,9 +137,8 @@ func (f *File) FunctionByAddress(addr uint32) *Function {
 	panic(fmt.Sprintf("unknown function with address: %x", addr))
 }
 
-func (f *File) FunctionForNative(db *NativeDB, operands *CallNOperands) (fn *Function, generated bool) {
+func (f *File) FunctionForNative(spec *NativeSpec, operands *CallNOperands) (fn *Function, generated bool) {
 	native := operands.Native
-	spec := db.LookupNative(native)
 	if spec == nil {
 		// No spec for this native, create one using the calln operands
 		fmt.Printf("WARNING: unknown function with hash: %x\n", native)
@@ -129,7 +182,7 @@ func (f *File) FunctionForNative(db *NativeDB, operands *CallNOperands) (fn *Fun
 		})
 	}
 
-	return fn, false
+	return fn, generated
 }
 
 func (f *File) CString() string {
@@ -352,7 +405,35 @@ func (expr BinaryExpr) DataType() Type {
 }
 
 func (expr BinaryExpr) CString() string {
-	return fmt.Sprintf("%v %v %v", expr.Left.CString(), expr.Op.CString(), expr.Right.CString())
+	prec := precedence[expr.Op]
+	left := operandCString(expr.Left, prec, false)
+	right := operandCString(expr.Right, prec, true)
+	return fmt.Sprintf("%v %v %v", left, expr.Op.CString(), right)
+}
+
+/* exprPrecedence returns the precedence of a binary expression, or 0 for anything else */
+func exprPrecedence(n Node) int {
+	switch n := n.(type) {
+	case BinaryExpr:
+		return precedence[n.Op]
+	case AndCond:
+		return precedence[AndToken]
+	case OrCond:
+		return precedence[OrToken]
+	case XorCond:
+		return precedence[XorToken]
+	}
+	return 0
+}
+
+// operandCString parenthesizes an operand which binds less tightly than the operator it's used with.
+// Operators are left associative, so the right operand is also parenthesized at the same precedence
+func operandCString(n Node, prec int, right bool) string {
+	p := exprPrecedence(n)
+	if p != 0 && (p < prec || (right && p == prec)) {
+		return fmt.Sprintf("(%v)", n.CString())
+	}
+	return n.CString()
 }
 
 // A UnaryExpr performs an operation on one node
@@ -366,9 +447,29 @@ func (expr UnaryExpr) DataType() Type {
 }
 
 func (expr UnaryExpr) CString() string {
+	if exprPrecedence(expr.Node) != 0 {
+		return fmt.Sprintf("%v(%v)", expr.Op.CString(), expr.Node.CString())
+	}
 	return fmt.Sprintf("%v%v", expr.Op.CString(), expr.Node.CString())
 }
 
+// A CastExpr converts a node to another type
+type CastExpr struct {
+	Type Type
+	Node Node
+}
+
+func (expr CastExpr) DataType() Type {
+	return expr.Type
+}
+
+func (expr CastExpr) CString() string {
+	if exprPrecedence(expr.Node) != 0 {
+		return fmt.Sprintf("(%v)(%v)", expr.Type.CString(), expr.Node.CString())
+	}
+	return fmt.Sprintf("(%v)%v", expr.Type.CString(), expr.Node.CString())
+}
+
 type PtrNode struct {
 	Node Node
 }
@@ -500,7 +601,85 @@ func (s StructField) InferType(typ Type) {
 type Goto uint32
 
 func (g Goto) CString() string {
-	return fmt.Sprintf("%v %v", GotoToken, uint32(g))
+	return fmt.Sprintf("%v %v", GotoToken, gotoLabel(uint32(g)))
+}
+
+// A Label marks the target of a goto. It's only printed if something jumps to it
+type Label struct {
+	Address uint32
+	Used    bool
+}
+
+func (l *Label) CString() string {
+	if !l.Used {
+		return ""
+	}
+	return fmt.Sprintf("%v:", gotoLabel(l.Address))
+}
+
+func gotoLabel(addr uint32) string {
+	return fmt.Sprintf("label_%.8x", addr)
+}
+
+// A StmtList is a sequence of statements, printed one per line
+type StmtList []Node
+
+func (l StmtList) CString() string {
+	lines := make([]string, 0, len(l))
+	for _, stmt := range l {
+		str := stmt.CString()
+		if str == "" {
+			continue
+		}
+
+		if needsSemicolon(stmt) {
+			str = fmt.Sprintf("%v;", str)
+		}
+		lines = append(lines, str)
+	}
+	return strings.Join(lines, "\n")
+}
+
+/* printed returns the statements which print something */
+func (l StmtList) printed() StmtList {
+	result := make(StmtList, 0, len(l))
+	for _, stmt := range l {
+		if label, ok := stmt.(*Label); ok && !label.Used {
+			continue
+		}
+		result = append(result, stmt)
+	}
+	return result
+}
+
+func needsSemicolon(stmt Node) bool {
+	switch stmt.(type) {
+	case IfStmt, WhileStmt, DoWhileStmt, ForStmt, SwitchStmt, Comment, *Label, StmtList:
+		return false
+	}
+	return true
+}
+
+func isEmptyStmt(n Node) bool {
+	if n == nil {
+		return true
+	}
+
+	list, ok := n.(StmtList)
+	return ok && len(list) == 0
+}
+
+/* indent indents each line of s by a tab */
+func indent(s string) string {
+	if s == "" {
+		return s
+	}
+	return "\t" + strings.Replace(s, "\n", "\n\t", -1)
+}
+
+/* block prints a list of statements between braces */
+func block(n Node) string {
+	return fmt.Sprintf("{\n%v\n}", indent(n.CString()))
 }
 
 type IfStmt struct {
@@ -516,27 +695,125 @@ func (s IfStmt) CString() string {
 
 	// simplify empty 'then' blocks
 	// swap then with else, and invert cond
-	if then == nil {
+	if isEmptyStmt(then) {
 		cond = NotCond{cond}
 		then = els
 		els = nil
 	}
 
-	fmt.Fprintf(buf, "%v (%v) {\n%v\n}", IfToken, cond.CString(), then.CString())
-	if els != nil {
-		fmt.Fprintf(buf, " else {\n%v\n}", els.CString())
+	fmt.Fprintf(buf, "%v (%v) %v", IfToken, cond.CString(), block(then))
+	if !isEmptyStmt(els) {
+		// print a lone nested if as an else if
+		if list, ok := els.(StmtList); ok && len(list.printed()) == 1 {
+			if elseIf, ok := list.printed()[0].(IfStmt); ok {
+				fmt.Fprintf(buf, " %v %v", ElseToken, elseIf.CString())
+				return buf.String()
+			}
+		}
+
+		fmt.Fprintf(buf, " %v %v", ElseToken, block(els))
 	}
 	return buf.String()
 }
 
+// A BoolLiteral is a constant condition
+type BoolLiteral bool
+
+func (b BoolLiteral) CString() string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+type WhileStmt struct {
+	Cond Node
+	Body StmtList
+}
+
+func (s WhileStmt) CString() string {
+	return fmt.Sprintf("%v (%v) %v", WhileToken, s.Cond.CString(), block(s.Body))
+}
+
+type DoWhileStmt struct {
+	Body StmtList
+	Cond Node
+}
+
+func (s DoWhileStmt) CString() string {
+	return fmt.Sprintf("%v %v %v (%v);", DoToken, block(s.Body), WhileToken, s.Cond.CString())
+}
+
+type ForStmt struct {
+	Init Node
+	Cond Node
+	Step Node
+	Body StmtList
+}
+
+func (s ForStmt) CString() string {
+	return fmt.Sprintf("%v (%v; %v; %v) %v", ForToken, s.Init.CString(), s.Cond.CString(), s.Step.CString(), block(s.Body))
+}
+
+type SwitchStmt struct {
+	Value Node
+	Cases []SwitchCase
+}
+
+// A SwitchCase is a group of case labels sharing a body
+type SwitchCase struct {
+	Values  []uint32
+	Default bool
+	Body    StmtList
+}
+
+func (s SwitchStmt) CString() string {
+	lines := make([]string, 0)
+	for _, c := range s.Cases {
+		if c.Default {
+			lines = append(lines, fmt.Sprintf("%v:", DefaultToken))
+		}
+
+		for _, v := range c.Values {
+			lines = append(lines, fmt.Sprintf("%v %v:", CaseToken, int32(v)))
+		}
+
+		if body := c.Body.CString(); body != "" {
+			lines = append(lines, indent(body))
+		}
+	}
+
+	return fmt.Sprintf("%v (%v) {\n%v\n}", SwitchToken, s.Value.CString(), indent(strings.Join(lines, "\n")))
+}
+
+type BreakStmt struct{}
+
+func (BreakStmt) CString() string {
+	return BreakToken.CString()
+}
+
+type ContinueStmt struct{}
+
+func (ContinueStmt) CString() string {
+	return ContinueToken.CString()
+}
+
 type NotCond struct {
 	Node
 }
 
 func (c NotCond) CString() string {
-	if c, ok := c.Node.(NotCond); ok {
+	switch n := c.Node.(type) {
+	case NotCond:
 		// Simplify double negations
-		return c.Node.CString()
+		return n.Node.CString()
+	case BinaryExpr:
+		// Invert comparisons rather than negating them
+		if op, ok := inverseComparisons[n.Op]; ok {
+			return BinaryExpr{n.Left, op, n.Right}.CString()
+		}
+	case BoolLiteral:
+		return (!n).CString()
 	}
 
 	return UnaryExpr{
@@ -14,8 +14,12 @@ type Function struct {
 	Decls      Declarations
 	instrs     *Instructions
 
+	/* Body is the function's structured statements, built from its blocks */
+	Body StmtList
+
 	*BasicBlock
 	blocks        map[uint32]*BasicBlock
+	order         []*BasicBlock
 	blocksVisited map[uint32]bool
 }
 
@@ -38,7 +42,12 @@ func (fn *Function) CString() string {
 		args[i] = arg.CString()
 	}
 
-	return fmt.Sprintf("%v %v(%v) {\n%v\n%v}", fn.Out.Type.CString(), fn.Identifier, strings.Join(args, ", "), fn.Decls.CString(), fn.BasicBlock.CString())
+	body := fn.BasicBlock.CString()
+	if fn.Body != nil {
+		body = indent(fn.Body.CString()) + "\n"
+	}
+
+	return fmt.Sprintf("%v %v(%v) {\n%v\n%v}", fn.Out.Type.CString(), fn.Identifier, strings.Join(args, ", "), fn.Decls.CString(), body)
 }
 
 func (fn *Function) resetBlocksVisited() {
@@ -66,6 +75,24 @@ type BasicBlock struct {
 
 	nodeStack *link
 	Ins, Outs []*BasicBlock
+
+	// Cond is the condition of a block ending in a conditional branch. The block continues to
+	// Outs[0] if it's true, and Outs[1] otherwise
+	Cond Node
+
+	// SwitchValue is the value matched against the cases of a block ending in a switch
+	SwitchValue Node
+	cases       []switchCase
+	defaultCase *BasicBlock
+
+	// exit is set on the empty blocks standing in for branch targets outside of the function
+	exit    bool
+	address uint32
+}
+
+type switchCase struct {
+	Value  uint32
+	Target *BasicBlock
 }
 
 func newBlock(parent *Function) *BasicBlock {
@@ -85,7 +112,7 @@ func (b *BasicBlock) StartAddress() uint32 {
 	if len(b.instrs.code) > 0 {
 		return b.instrs.code[0].Address
 	}
-	return 0
+	return b.address
 }
 
 func (b *BasicBlock) VariableByName(identifier string) *Variable {
@@ -165,7 +192,7 @@ func (block *BasicBlock) CString() string {
 
 		stmt := s.CString()
 		if hasSemicolon {
-			fmt.Sprintf("%v;", stmt)
+			stmt = fmt.Sprintf("%v;", stmt)
 		}
 
 		stmts[i] = fmt.Sprintf("\t%v\n", stmt)
@@ -0,0 +1,365 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EdgeKind describes how control passes from one block to another
+type EdgeKind int
+
+const (
+	// EdgeFallthrough continues to the following block, including the untaken side of a conditional branch
+	EdgeFallthrough EdgeKind = iota
+	// EdgeJump is an unconditional branch
+	EdgeJump
+	// EdgeConditional is the taken side of a conditional branch
+	EdgeConditional
+	// EdgeSwitchCase is a case of a switch
+	EdgeSwitchCase
+)
+
+var edgeKindNames = map[EdgeKind]string{
+	EdgeFallthrough: "fallthrough",
+	EdgeJump:        "jump",
+	EdgeConditional: "conditional",
+	EdgeSwitchCase:  "case",
+}
+
+func (k EdgeKind) String() string {
+	return edgeKindNames[k]
+}
+
+func (k EdgeKind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+// FlowEdge is a path between two blocks of a FlowGraph
+type FlowEdge struct {
+	From uint32   `json:"from"`
+	To   uint32   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+
+	/* Case is the value a switch case matches. Only set for EdgeSwitchCase */
+	Case *uint32 `json:"case,omitempty"`
+}
+
+// FlowBlock is a run of instructions with a single entry and exit
+type FlowBlock struct {
+	Address      uint32
+	Instructions []Instruction
+
+	Ins, Outs []*FlowEdge
+}
+
+// End returns the address of the last instruction in the block
+func (b *FlowBlock) End() uint32 {
+	return b.Instructions[len(b.Instructions)-1].Address
+}
+
+func (b *FlowBlock) last() Instruction {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// FlowGraph is the control flow graph of a single function
+type FlowGraph struct {
+	Function string
+	Address  uint32
+	Blocks   []*FlowBlock
+	Edges    []*FlowEdge
+
+	blocks map[uint32]*FlowBlock
+}
+
+// Block returns the block starting at addr
+func (g *FlowGraph) Block(addr uint32) *FlowBlock {
+	return g.blocks[addr]
+}
+
+// Entry returns the block at the start of the function
+func (g *FlowGraph) Entry() *FlowBlock {
+	return g.Blocks[0]
+}
+
+// FlowGraphs splits a script's instructions into functions, and builds the control flow graph of each
+func FlowGraphs(code []Instruction) []*FlowGraph {
+	graphs := make([]*FlowGraph, 0)
+
+	start := -1
+	for i, istr := range code {
+		if istr.Operation != OpEnter {
+			continue
+		}
+
+		if start != -1 {
+			graphs = append(graphs, NewFlowGraph(code[start:i]))
+		}
+		start = i
+	}
+
+	if start != -1 {
+		graphs = append(graphs, NewFlowGraph(code[start:]))
+	}
+
+	return graphs
+}
+
+// NewFlowGraph builds the control flow graph of a function. code should start with the function's enter
+func NewFlowGraph(code []Instruction) *FlowGraph {
+	g := &FlowGraph{
+		Blocks: make([]*FlowBlock, 0),
+		Edges:  make([]*FlowEdge, 0),
+		blocks: make(map[uint32]*FlowBlock),
+	}
+
+	if len(code) == 0 {
+		return g
+	}
+
+	g.Address = code[0].Address
+	if op, ok := code[0].Operands.(*EnterOperands); ok {
+		g.Function = op.Name
+	}
+
+	/* Find the leaders. Blocks start at the function entry, branch and switch targets, and after any
+	instruction which transfers control */
+	leaders := map[uint32]bool{
+		code[0].Address: true,
+	}
+
+	for i, istr := range code {
+		for _, target := range branchTargets(istr) {
+			leaders[target] = true
+		}
+
+		if endsBlock(istr) && i+1 < len(code) {
+			leaders[code[i+1].Address] = true
+		}
+	}
+
+	var block *FlowBlock
+	for _, istr := range code {
+		if leaders[istr.Address] {
+			block = &FlowBlock{
+				Address:      istr.Address,
+				Instructions: make([]Instruction, 0),
+			}
+			g.Blocks = append(g.Blocks, block)
+			g.blocks[block.Address] = block
+		}
+
+		block.Instructions = append(block.Instructions, istr)
+	}
+
+	for i, block := range g.Blocks {
+		var next *FlowBlock
+		if i+1 < len(g.Blocks) {
+			next = g.Blocks[i+1]
+		}
+
+		istr := block.last()
+		switch {
+		case istr.Operation == OpBranch:
+			g.addEdge(block, istr.Operands.(*BranchOperands).AbsoluteAddr, EdgeJump, nil)
+
+		case istr.Operation > OpBranchStart && istr.Operation < OpBranchEnd:
+			if next != nil {
+				g.addEdge(block, next.Address, EdgeFallthrough, nil)
+			}
+			g.addEdge(block, istr.Operands.(*BranchOperands).AbsoluteAddr, EdgeConditional, nil)
+
+		case istr.Operation == OpSwitch:
+			op := istr.Operands.(*SwitchOperands)
+			for _, value := range op.Cases {
+				value := value
+				g.addEdge(block, op.JumpTableAbs[value], EdgeSwitchCase, &value)
+			}
+
+			/* A switch continues to the following instruction if no cases match */
+			if next != nil {
+				g.addEdge(block, next.Address, EdgeFallthrough, nil)
+			}
+
+		case istr.Operation == OpRet || istr.Operation == OpThrow:
+
+		default:
+			if next != nil {
+				g.addEdge(block, next.Address, EdgeFallthrough, nil)
+			}
+		}
+	}
+
+	return g
+}
+
+func (g *FlowGraph) addEdge(from *FlowBlock, to uint32, kind EdgeKind, value *uint32) {
+	edge := &FlowEdge{
+		From: from.Address,
+		To:   to,
+		Kind: kind,
+		Case: value,
+	}
+
+	g.Edges = append(g.Edges, edge)
+	from.Outs = append(from.Outs, edge)
+
+	/* Targets outside of the function are kept as edges, but have no block */
+	if target, ok := g.blocks[to]; ok {
+		target.Ins = append(target.Ins, edge)
+	}
+}
+
+/* branchTargets returns the addresses an instruction can transfer control to, other than the following one */
+func branchTargets(istr Instruction) []uint32 {
+	switch op := istr.Operands.(type) {
+	case *BranchOperands:
+		return []uint32{op.AbsoluteAddr}
+	case *SwitchOperands:
+		targets := make([]uint32, 0, len(op.Cases))
+		for _, value := range op.Cases {
+			targets = append(targets, op.JumpTableAbs[value])
+		}
+		return targets
+	}
+	return nil
+}
+
+/* endsBlock returns true if an instruction is the last of its block */
+func endsBlock(istr Instruction) bool {
+	op := istr.Operation
+	return (op > OpBranchStart && op < OpBranchEnd) || op == OpSwitch || op == OpRet || op == OpThrow
+}
+
+type flowBlockJSON struct {
+	Address      uint32   `json:"address"`
+	End          uint32   `json:"end"`
+	Instructions []string `json:"instructions"`
+}
+
+type flowGraphJSON struct {
+	Function string          `json:"function"`
+	Address  uint32          `json:"address"`
+	Blocks   []flowBlockJSON `json:"blocks"`
+	Edges    []*FlowEdge     `json:"edges"`
+}
+
+func (g *FlowGraph) toJSON() flowGraphJSON {
+	out := flowGraphJSON{
+		Function: g.Function,
+		Address:  g.Address,
+		Blocks:   make([]flowBlockJSON, len(g.Blocks)),
+		Edges:    g.Edges,
+	}
+
+	for i, block := range g.Blocks {
+		listing := make([]string, len(block.Instructions))
+		for j := range block.Instructions {
+			listing[j] = block.Instructions[j].String()
+		}
+
+		out.Blocks[i] = flowBlockJSON{
+			Address:      block.Address,
+			End:          block.End(),
+			Instructions: listing,
+		}
+	}
+
+	return out
+}
+
+// WriteFlowGraphs writes a set of control flow graphs in the given format, either "dot" or "json"
+func WriteFlowGraphs(w io.Writer, graphs []*FlowGraph, format string) error {
+	switch format {
+	case "dot":
+		return WriteFlowGraphsDOT(w, graphs)
+	case "json":
+		return WriteFlowGraphsJSON(w, graphs)
+	}
+	return fmt.Errorf("unknown graph format: %v", format)
+}
+
+// WriteFlowGraphsJSON writes a set of control flow graphs as a JSON array
+func WriteFlowGraphsJSON(w io.Writer, graphs []*FlowGraph) error {
+	out := make([]flowGraphJSON, len(graphs))
+	for i, g := range graphs {
+		out[i] = g.toJSON()
+	}
+
+	/* Instruction listings are full of angle brackets, which would otherwise be escaped */
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteFlowGraphsDOT writes a set of control flow graphs as a Graphviz digraph, with a cluster per function
+func WriteFlowGraphsDOT(w io.Writer, graphs []*FlowGraph) error {
+	var buf strings.Builder
+
+	buf.WriteString("digraph cfg {\n")
+	buf.WriteString("\tnode [shape=box fontname=\"monospace\"];\n")
+
+	for i, g := range graphs {
+		fmt.Fprintf(&buf, "\tsubgraph cluster_%v {\n", i)
+		fmt.Fprintf(&buf, "\t\tlabel=%v;\n", dotQuote(fmt.Sprintf("%v (%.8x)", g.Function, g.Address)))
+
+		for _, block := range g.Blocks {
+			lines := make([]string, len(block.Instructions))
+			for j := range block.Instructions {
+				lines[j] = dotEscape(block.Instructions[j].String())
+			}
+
+			/* \l left justifies each line */
+			fmt.Fprintf(&buf, "\t\t%v [label=\"%v\\l\"];\n", dotNode(block.Address), strings.Join(lines, "\\l"))
+		}
+
+		for _, edge := range g.Edges {
+			if g.Block(edge.To) == nil {
+				continue
+			}
+
+			fmt.Fprintf(&buf, "\t\t%v -> %v [%v];\n", dotNode(edge.From), dotNode(edge.To), dotEdgeAttrs(edge))
+		}
+
+		buf.WriteString("\t}\n")
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+/* Blocks are named by address, which is unique across the whole script */
+func dotNode(addr uint32) string {
+	return fmt.Sprintf("b_%.8x", addr)
+}
+
+func dotEdgeAttrs(edge *FlowEdge) string {
+	attrs := []string{
+		fmt.Sprintf("label=%v", dotQuote(edge.Kind.String())),
+	}
+
+	switch edge.Kind {
+	case EdgeConditional:
+		attrs = append(attrs, "color=green")
+	case EdgeFallthrough:
+		attrs = append(attrs, "style=dashed")
+	case EdgeSwitchCase:
+		attrs[0] = fmt.Sprintf("label=%v", dotQuote(fmt.Sprintf("case %v", *edge.Case)))
+		attrs = append(attrs, "color=blue")
+	}
+
+	return strings.Join(attrs, " ")
+}
+
+func dotEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	return strings.Replace(s, "\"", "\\\"", -1)
+}
+
+func dotQuote(s string) string {
+	return "\"" + dotEscape(s) + "\""
+}
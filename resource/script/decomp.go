@@ -6,6 +6,7 @@ type Machine struct {
 	file   *File
 	script *Script
 	instrs *Instructions
+	types  *typeSolver
 
 	identifierIdx int
 }
@@ -18,6 +19,7 @@ func NewMachine(script *Script, code []Instruction) *Machine {
 		},
 		script: script,
 		file:   &File{},
+		types:  newTypeSolver(),
 	}
 
 	for i := range code {
@@ -29,6 +31,11 @@ func NewMachine(script *Script, code []Instruction) *Machine {
 	return m
 }
 
+// NameGlobals sets the names and types given to globals, as read by ReadGlobalHeader
+func (m *Machine) NameGlobals(names map[int]GlobalName) {
+	m.file.GlobalNames = names
+}
+
 func (m *Machine) createStaticDecls() {
 	statics := m.script.StaticValues
 	for i, v := range statics {
@@ -71,6 +78,14 @@ func (m *Machine) Decompile() File {
 
 	for i := range m.file.Functions {
 		m.decompileFunction(m.file.Functions[i])
+	}
+
+	// Types flow between functions through calls, so they're only known once every function is decompiled
+	m.types.solve()
+	m.retypeStatics()
+
+	for i := range m.file.Functions {
+		m.structureFunction(m.file.Functions[i])
 		m.file.Nodes = append(m.file.Nodes, m.file.Functions[i])
 	}
 
@@ -113,23 +128,8 @@ func (m *Machine) scanFunction() *Function {
 	}
 
 	m.scanFuncBounds(function)
+	m.scanReturns(function)
 	m.scanBlocks(function)
-	m.scanFlow(function)
-
-	var currentBlock *BasicBlock
-	for !function.instrs.isEOF() {
-		nextIstr := function.instrs.peekInstruction()
-		if nextIstr.Operation == OpEnter {
-			break
-		}
-
-		if block, ok := function.blocks[nextIstr.Address]; ok {
-			currentBlock = block
-		}
-
-		currentBlock.instrs.append(nextIstr)
-		function.instrs.nextInstruction()
-	}
 
 	return function
 }
@@ -147,72 +147,71 @@ func (m *Machine) scanFuncBounds(fn *Function) {
 	}
 }
 
-func (m *Machine) scanBlocks(fn *Function) {
-	blockStartAddrs := make([]uint32, 0)
-	firstAddr := fn.instrs.peekInstruction().Address
-
-	blockStartAddrs = append(blockStartAddrs, firstAddr)
-	for !fn.instrs.isEOF() {
-		istr := fn.instrs.nextInstruction()
-
-		if istr.Operation > OpBranchStart && istr.Operation < OpBranchEnd {
-			branchAddr := istr.Operands.(*BranchOperands).AbsoluteAddr
-
-			if istr.Operation != OpBranch {
-				// conditional branch, mark addr following branch as a possible target
-				nextAddr := fn.instrs.peekInstruction().Address
-				blockStartAddrs = append(blockStartAddrs, nextAddr)
-			}
-			blockStartAddrs = append(blockStartAddrs, branchAddr)
-		}
-
-		if istr.Operation == OpSwitch {
-			operands := istr.Operands.(*SwitchOperands)
-			for _, addr := range operands.JumpTableAbs {
-				blockStartAddrs = append(blockStartAddrs, addr)
-			}
+// scanReturns sizes a function's return value from its ret instructions, so that calls to it can be
+// decompiled before the function itself
+func (m *Machine) scanReturns(fn *Function) {
+	for _, istr := range fn.instrs.code {
+		if op, ok := istr.Operands.(*RetOperands); ok {
+			fn.Out.Type = guessType(int(op.NumReturnVals))
+			return
 		}
 	}
+}
 
-	for _, addr := range blockStartAddrs {
-		fn.blocks[addr] = newBlock(fn)
+// scanBlocks splits a function into basic blocks and the paths between them, using its control flow graph
+func (m *Machine) scanBlocks(fn *Function) {
+	code := make([]Instruction, len(fn.instrs.code))
+	for i := range fn.instrs.code {
+		code[i] = fn.instrs.code[i].Instruction
 	}
 
-	fn.BasicBlock = fn.blocks[firstAddr]
-	fn.instrs.reset()
-}
-
-func (m *Machine) scanFlow(fn *Function) {
-	var currentBlock *BasicBlock
-	for !fn.instrs.isEOF() {
-		istr := fn.instrs.nextInstruction()
-		if block, ok := fn.blocks[istr.Address]; ok {
-			currentBlock = block
+	graph := NewFlowGraph(code)
+	for _, flow := range graph.Blocks {
+		block := newBlock(fn)
+		for _, istr := range flow.Instructions {
+			block.instrs.append(istr)
 		}
 
-		if istr.Operation > OpBranchStart && istr.Operation < OpBranchEnd {
-			if istr.Operation != OpBranch {
-				// conditional branch, mark addr following branch as a possible target
-				nextAddr := fn.instrs.peekInstruction().Address
-				nextBlock := fn.blocks[nextAddr]
-				defineFlowPath(currentBlock, nextBlock)
+		fn.blocks[flow.Address] = block
+		fn.order = append(fn.order, block)
+	}
+
+	for _, flow := range graph.Blocks {
+		from := fn.blocks[flow.Address]
+		for _, edge := range flow.Outs {
+			to, ok := fn.blocks[edge.To]
+			if !ok {
+				to = exitBlock(fn, edge.To)
 			}
 
-			branchAddr := istr.Operands.(*BranchOperands).AbsoluteAddr
-			branchBlock := fn.blocks[branchAddr]
-			defineFlowPath(currentBlock, branchBlock)
-		}
+			// conditional branches fall through to Outs[0], and switches list their cases before the default
+			defineFlowPath(from, to)
 
-		if istr.Operation == OpSwitch {
-			operands := istr.Operands.(*SwitchOperands)
-			for _, addr := range operands.JumpTableAbs {
-				branchBlock := fn.blocks[addr]
-				defineFlowPath(currentBlock, branchBlock)
+			switch {
+			case edge.Kind == EdgeSwitchCase:
+				from.cases = append(from.cases, switchCase{*edge.Case, to})
+			case edge.Kind == EdgeFallthrough && flow.last().Operation == OpSwitch:
+				from.defaultCase = to
 			}
 		}
 	}
 
-	fn.instrs.reset()
+	fn.BasicBlock = fn.blocks[graph.Address]
+}
+
+// exitBlock returns the empty block standing in for an address outside of the function. A branch
+// out of the function can't be structured, so it's left as a goto
+func exitBlock(fn *Function, address uint32) *BasicBlock {
+	if block, ok := fn.blocks[address]; ok {
+		return block
+	}
+
+	block := newBlock(fn)
+	block.exit = true
+	block.address = address
+	fn.blocks[address] = block
+	fn.order = append(fn.order, block)
+	return block
 }
 
 func defineFlowPath(from, to *BasicBlock) {
@@ -220,19 +219,19 @@ func defineFlowPath(from, to *BasicBlock) {
 	to.Ins = append(to.Ins, from)
 }
 
-func (m *Machine) scanBranch(fn *Function, istr Instruction, currentBlock *BasicBlock) {
-	// the address of the instruction immediately following the branch
-	nextAddress := m.instrs.peekInstruction().Address
-	fn.blocks[nextAddress] = newBlock(fn)
+/* removeFlowPath removes a single path between two blocks */
+func removeFlowPath(from, to *BasicBlock) {
+	from.Outs = removeBlock(from.Outs, to)
+	to.Ins = removeBlock(to.Ins, from)
+}
 
-	if istr.Operation != OpBranch {
-		// if the branch is conditional, then we need to create an entry point from istr to nextAddress
-		defineFlowPath(currentBlock, fn.blocks[nextAddress])
+func removeBlock(blocks []*BasicBlock, block *BasicBlock) []*BasicBlock {
+	for i := range blocks {
+		if blocks[i] == block {
+			return append(blocks[:i:i], blocks[i+1:]...)
+		}
 	}
-
-	branchAddress := istr.Operands.(*BranchOperands).AbsoluteAddr
-	fn.blocks[branchAddress] = newBlock(fn)
-	defineFlowPath(currentBlock, fn.blocks[branchAddress])
+	return blocks
 }
 
 func (m *Machine) decompileFunction(fn *Function) {
@@ -271,66 +270,6 @@ func (m *Machine) decompileFunction(fn *Function) {
 	}
 }
 
-func (m *Machine) structureLoops(fn *Function, lastLoopBlock *BasicBlock) {
-	if len(lastLoopBlock.Outs) != 1 {
-		// find unconditional branches
-		return
-	}
-
-	targetBlock := lastLoopBlock.Outs[0]
-
-	if targetBlock.StartAddress() > lastLoopBlock.StartAddress() {
-		// Can't be a loop if it's branching to a higher address
-		return
-	}
-
-	if len(targetBlock.Outs) != 2 {
-		// A loop's first block will be a conditional branch
-		return
-	}
-
-	then, els := targetBlock.Outs[0], targetBlock.Outs[1]
-
-	if els.StartAddress() < lastLoopBlock.StartAddress() {
-		// the else block must be located after the end of the loop
-		return
-	}
-
-}
-
-func (m *Machine) visitBlocks(fn *Function, visitFn func(*Function, *BasicBlock)) {
-	fn.resetBlocksVisited()
-
-	blocksStack := &link{
-		Node: fn.BasicBlock,
-	}
-
-	for blocksStack != nil {
-		// pop the next block
-		thisBlock := blocksStack.Node.(*BasicBlock)
-		blocksStack = blocksStack.next
-
-		if _, ok := fn.blocksVisited[thisBlock.StartAddress()]; ok {
-			// Dont revisit a block we've already visited
-			continue
-		}
-
-		visitFn(fn, thisBlock)
-
-		fn.blocksVisited[thisBlock.StartAddress()] = true
-
-		// push all of the outgoing blocks of this one to the stack
-		// copy the node stack at the end of decompilation into the outgoing blocks
-		for _, block := range thisBlock.Outs {
-			block.nodeStack = thisBlock.nodeStack
-			blocksStack = &link{
-				Node: block,
-				next: blocksStack,
-			}
-		}
-	}
-}
-
 func (m *Machine) decompileStatement(block *BasicBlock) {
 	istr := block.peekInstruction()
 	block.emitComment("\t\t\t\t\t\t\tasm(\"%v\")", istr.String())
@@ -398,14 +337,21 @@ func (m *Machine) decompileStatement(block *BasicBlock) {
 	case op == OpExplode:
 		m.decompileExplode(block)
 
+	/* conversions */
+	case op == OpItoF:
+		m.decompileCast(block, IntType, FloatType)
+	case op == OpFtoI:
+		m.decompileCast(block, FloatType, IntType)
+
 	/* control flow */
 	case op == OpCall:
 		m.decompileCall(block)
 	case op == OpCallN:
 		m.decompileCall(block)
-	//case op > OpBranchStart && op < OpBranchEnd:
-	case op == OpBranchZ || op == OpBranch:
+	case op > OpBranchStart && op < OpBranchEnd:
 		m.decompileBranch(block)
+	case op == OpSwitch:
+		m.decompileSwitch(block)
 
 	/* binary ops */
 	case op > OpMathStart && op < OpMathEnd:
@@ -413,6 +359,9 @@ func (m *Machine) decompileStatement(block *BasicBlock) {
 	/* bool ops */
 	case op > OpBoolStart && op < OpBoolEnd:
 		m.decompileBoolOp(block)
+	/* comparison ops */
+	case op > OpCmpStart && op < OpCmpEnd:
+		m.decompileCmpOp(block)
 
 	case op == OpRet:
 		m.decompileReturn(block)
@@ -430,56 +379,85 @@ func (m *Machine) decompileUnknownOp(block *BasicBlock) {
 	block.emitStatement(AsmStmt{istr.String()})
 }
 
+// The conditional branches are taken when their comparison fails, so each falls through when
+// the comparison is true
+var branchComparisons = map[uint8]Token{
+	OpBranchNe: EqToken,
+	OpBranchEq: NotEqToken,
+	OpBranchGt: GtToken,
+	OpBranchGe: GeToken,
+	OpBranchLt: LtToken,
+	OpBranchLe: LeToken,
+}
+
+var cmpTokens = map[uint8]Token{
+	OpCmpEq: EqToken,
+	OpCmpNe: NotEqToken,
+	OpCmpGt: GtToken,
+	OpCmpGe: GeToken,
+	OpCmpLt: LtToken,
+	OpCmpLe: LeToken,
+}
+
+// decompileBranch records the condition of a conditional branch on its block. The branch itself
+// is recovered from the block's outward paths when the function is structured
 func (m *Machine) decompileBranch(block *BasicBlock) {
 	istr := block.nextInstruction()
-	op := istr.Operands.(*BranchOperands)
 
 	if istr.Operation == OpBranch {
-		// unconditional branch
-		block.emitStatement(Goto(op.AbsoluteAddr))
 		return
 	}
 
-	if len(block.Outs) != 2 {
-		panic(fmt.Sprintf("expected two outward blocks on conditional branch, got %v", len(block.Outs)))
+	/* A conditional branch which ends the function falls through past its end */
+	if len(block.Outs) == 1 {
+		op := istr.Operands.(*BranchOperands)
+		next := exitBlock(block.ParentFunc, uint32(int64(op.AbsoluteAddr)-int64(op.RelativeAddr)))
+		block.Outs = append([]*BasicBlock{next}, block.Outs...)
+		next.Ins = append(next.Ins, block)
 	}
 
-	then, els := block.Outs[0].StartAddress(), block.Outs[1].StartAddress()
-
-	var cond Node
-	switch istr.Operation {
-	case OpBranchZ:
-		cond = NotCond{
-			Node: cond,
-		}
+	if istr.Operation == OpBranchZ {
+		block.Cond = block.popNode()
+		return
 	}
 
-	block.emitStatement(IfStmt{
-		Cond: cond,
-		Then: Goto(then),
-		Else: Goto(els),
-	})
+	b := block.popNode()
+	a := block.popNode()
+	m.inferOperands(istr, a, b)
+	block.Cond = BinaryExpr{a, branchComparisons[istr.Operation], b}
+}
+
+func (m *Machine) decompileSwitch(block *BasicBlock) {
+	_ = block.nextInstruction()
+	block.SwitchValue = block.popNode()
+	m.types.expect(block.SwitchValue, IntType, useEvidence)
+}
+
+func (m *Machine) decompileCmpOp(block *BasicBlock) {
+	op := block.nextInstruction()
+
+	b := block.popNode()
+	a := block.popNode()
+	m.inferOperands(op, a, b)
+	block.pushNode(BinaryExpr{a, cmpTokens[op.Operation], b})
 }
 
 func (m *Machine) decompileBoolOp(block *BasicBlock) {
 	op := block.nextInstruction()
-	cond := block.popNode()
 
 	switch op.Operation {
 	case OpNot:
-		block.pushNode(NotCond{cond})
+		a := block.popNode()
+		m.types.expect(a, typeMap["BOOL"], useEvidence)
+		block.pushNode(NotCond{a})
 		return
 	}
 
-	// The remaining ops are binary
-	var a, b Node
-	a = block.popNode()
-	if _, ok := op.Operands.(ImmediateIntOperands); ok {
-		// some math ops take an immediate operand in place of a stack operand
-		b = Immediate{op.Operands}
-	} else {
-		b = block.popNode()
-	}
+	// The remaining ops are binary. The right hand side is on top of the stack
+	b := block.popNode()
+	a := block.popNode()
+	m.types.expect(a, typeMap["BOOL"], useEvidence)
+	m.types.expect(b, typeMap["BOOL"], useEvidence)
 
 	var result Node
 	switch op.Operation {
@@ -517,20 +495,22 @@ func (m *Machine) decompileMathOp(block *BasicBlock) {
 			token = NegToken
 		}
 
+		m.types.expect(a, operandType(op), useEvidence)
 		unaryOp := UnaryExpr{token, a}
 		block.pushNode(unaryOp)
 		return
 	}
 
-	// The remaining math ops are binary
+	// The remaining math ops are binary. The right hand side is on top of the stack
 	var a, b Node
-	a = block.popNode()
 	if _, ok := op.Operands.(ImmediateIntOperands); ok {
 		// some math ops take an immediate operand in place of a stack operand
 		b = Immediate{op.Operands}
 	} else {
 		b = block.popNode()
 	}
+	a = block.popNode()
+	m.inferOperands(op, a, b)
 
 	switch op.Operation {
 	case OpAdd:
@@ -555,6 +535,25 @@ func (m *Machine) decompileMathOp(block *BasicBlock) {
 	block.pushNode(binaryOp)
 }
 
+// decompileCast converts the value on top of the stack from one type to another
+func (m *Machine) decompileCast(block *BasicBlock, from, to Type) {
+	_ = block.nextInstruction()
+
+	value := block.popNode()
+	m.types.expect(value, from, useEvidence)
+	block.pushNode(CastExpr{to, value})
+}
+
+// inferOperands records that the operands of an arithmetic or comparison op have the same type,
+// which is given by the op's suffix
+func (m *Machine) inferOperands(istr Instruction, a, b Node) {
+	m.types.relate(a, b, useEvidence)
+
+	typ := operandType(istr)
+	m.types.expect(a, typ, useEvidence)
+	m.types.expect(b, typ, useEvidence)
+}
+
 func (m *Machine) genTempIdentifier() string {
 	m.identifierIdx++
 	return fmt.Sprintf("temp_%v", m.identifierIdx)
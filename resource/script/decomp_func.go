@@ -6,12 +6,14 @@ func (m *Machine) decompileCall(block *BasicBlock) {
 	var node Node
 	var targetFn *Function
 	inferArgIdentifiers := false
+	isNative := false
 
 	switch op := istr.Operands.(type) {
 	case *CallOperands:
 		targetFn = m.file.FunctionByAddress(op.Val)
 	case *CallNOperands:
-		targetFn, inferArgIdentifiers = m.file.FunctionForNative(m.script.HashTable, op)
+		isNative = true
+		targetFn, inferArgIdentifiers = m.file.FunctionForNative(m.script.LookupNative(op.Native), op)
 		// FunctionForNative's second parameter is true if the native spec had to be generated
 		// (and the arg identifiers are useless)
 		inferArgIdentifiers = !inferArgIdentifiers
@@ -24,9 +26,12 @@ func (m *Machine) decompileCall(block *BasicBlock) {
 		argValue := block.popNode()
 		argVariable := targetFn.In.Vars[argIdx]
 
-		if inferrable, ok := argValue.(TypeInferrable); ok {
-			expectedType := argVariable.DataType()
-			inferrable.InferType(expectedType)
+		// Native parameters have fixed types, but a script function's arguments are inferred
+		// along with everything else
+		if isNative {
+			m.types.expect(argValue, argVariable.DataType(), useEvidence)
+		} else {
+			m.types.relate(argValue, argVariable, useEvidence)
 		}
 
 		if v, valIsVariable := argValue.(*Variable); inferArgIdentifiers && valIsVariable {
@@ -51,6 +56,12 @@ func (m *Machine) decompileCall(block *BasicBlock) {
 			Value: node,
 		}
 
+		if isNative {
+			m.types.expect(tempDecl.Variable, targetFn.Out.DataType(), defEvidence)
+		} else if outSize == 1 {
+			m.types.relate(tempDecl.Variable, targetFn.Out, defEvidence)
+		}
+
 		resultRef := tempDecl.Variable.Reference()
 		if outSize > 1 {
 			outType := targetFn.Out.DataType().(ComplexType)
@@ -75,11 +86,12 @@ func (m *Machine) decompileReturn(block *BasicBlock) {
 	retVar := block.ParentFunc.Out
 
 	if op.NumReturnVals == 0 {
-		retVar.InferType(VoidType)
 		block.emitStatement(ReturnStmt{nil})
 	} else {
 		retVal := block.peekNode()
-		retVar.InferType(retVal.(DataTypeable).DataType())
+		if op.NumReturnVals == 1 {
+			m.types.relate(retVar, retVal, defEvidence)
+		}
 
 		if v, ok := retVal.(*Variable); ok {
 			retVal = v.Reference()
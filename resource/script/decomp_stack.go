@@ -7,6 +7,8 @@ func (m *Machine) decompilePushImm(block *BasicBlock) {
 	switch op := op.(type) {
 	case ImmediateIntOperands:
 		block.pushNode(Immediate{op.(Operands)})
+	case *ImmediateF32Operands, *ImplicitFOperands:
+		block.pushNode(Immediate{op})
 	case *Immediate8x2Operands:
 		block.pushNode(Immediate{&Immediate8Operands{op.Val0}})
 		block.pushNode(Immediate{&Immediate8Operands{op.Val1}})
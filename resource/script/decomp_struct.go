@@ -0,0 +1,897 @@
+package script
+
+import (
+	"sort"
+	"strings"
+)
+
+// A loop is a natural loop, made up of its header and every block which can reach a back edge to it
+// without passing through the header
+type loop struct {
+	header  *BasicBlock
+	body    map[*BasicBlock]bool
+	latches []*BasicBlock
+	follow  *BasicBlock
+}
+
+/* structurer turns a function's blocks into structured statements */
+type structurer struct {
+	fn *Function
+
+	/* The reachable blocks in reverse postorder, and their immediate dominators and postdominators */
+	order []*BasicBlock
+	index map[*BasicBlock]int
+	idom  map[*BasicBlock]*BasicBlock
+	ipdom map[*BasicBlock]*BasicBlock
+
+	loops   map[*BasicBlock]*loop
+	active  map[*loop]bool
+	emitted map[*BasicBlock]bool
+	labels  map[*BasicBlock]*Label
+}
+
+/* A reserved block is the follow of an enclosing construct, which is emitted after it */
+type reserved struct {
+	block *BasicBlock
+	next  *reserved
+}
+
+/* structCtx describes the constructs enclosing the statements being emitted */
+type structCtx struct {
+	breakTarget    *BasicBlock
+	continueTarget *BasicBlock
+
+	/* nextCase is the case following the one being emitted, which it may fall through to */
+	nextCase    *BasicBlock
+	fellThrough *bool
+
+	reserved *reserved
+}
+
+func (ctx structCtx) reserve(block *BasicBlock) structCtx {
+	if block != nil {
+		ctx.reserved = &reserved{block, ctx.reserved}
+	}
+	return ctx
+}
+
+func (ctx structCtx) isReserved(block *BasicBlock) bool {
+	for r := ctx.reserved; r != nil; r = r.next {
+		if r.block == block {
+			return true
+		}
+	}
+	return false
+}
+
+// structureFunction recovers the control flow of a decompiled function as ifs, loops and switches,
+// falling back to gotos where the flow can't be structured
+func (m *Machine) structureFunction(fn *Function) {
+	s := &structurer{
+		fn:      fn,
+		loops:   make(map[*BasicBlock]*loop),
+		active:  make(map[*loop]bool),
+		emitted: make(map[*BasicBlock]bool),
+		labels:  make(map[*BasicBlock]*Label),
+	}
+
+	s.simplifyBranches()
+	s.mergeConditions()
+	s.findDominators()
+	s.findLoops()
+
+	fn.Body = forLoops(s.sequence(fn.BasicBlock, nil, structCtx{}))
+}
+
+// simplifyBranches removes blocks which only branch elsewhere, and turns conditional branches which
+// go to the same place either way into plain paths
+func (s *structurer) simplifyBranches() {
+	for _, b := range s.fn.order {
+		if b == s.fn.BasicBlock || hasStatements(b) || b.Cond != nil || b.SwitchValue != nil || len(b.Outs) != 1 {
+			continue
+		}
+
+		target := b.Outs[0]
+		if target == b {
+			continue
+		}
+
+		for len(b.Ins) > 0 {
+			redirectFlowPath(b.Ins[0], b, target)
+		}
+		removeFlowPath(b, target)
+	}
+
+	for _, b := range s.fn.order {
+		if b.Cond != nil && len(b.Outs) == 2 && b.Outs[0] == b.Outs[1] {
+			removeFlowPath(b, b.Outs[1])
+			b.Cond = nil
+		}
+	}
+}
+
+/* redirectFlowPath moves a path from one target to another, keeping its place in the source's paths */
+func redirectFlowPath(from, old, target *BasicBlock) {
+	for i := range from.Outs {
+		if from.Outs[i] == old {
+			from.Outs[i] = target
+			break
+		}
+	}
+
+	for i := range from.cases {
+		if from.cases[i].Target == old {
+			from.cases[i].Target = target
+		}
+	}
+
+	if from.defaultCase == old {
+		from.defaultCase = target
+	}
+
+	old.Ins = removeBlock(old.Ins, from)
+	target.Ins = append(target.Ins, from)
+}
+
+// mergeConditions combines chains of conditional branches into && and || conditions. A block
+// can be merged into its predecessor if it only evaluates a condition, and shares one of its
+// predecessor's targets
+func (s *structurer) mergeConditions() {
+	for changed := true; changed; {
+		changed = false
+		for _, x := range s.fn.order {
+			if x.Cond != nil && s.mergeCondition(x) {
+				changed = true
+			}
+		}
+	}
+}
+
+func (s *structurer) mergeCondition(x *BasicBlock) bool {
+	for i := range x.Outs {
+		y, z := x.Outs[i], x.Outs[1-i]
+		if y == x || y.Cond == nil || len(y.Ins) != 1 || hasStatements(y) {
+			continue
+		}
+
+		var cond Node
+		var then, els *BasicBlock
+		c1, c2 := x.Cond, y.Cond
+
+		switch {
+		case i == 0 && y.Outs[1] == z:
+			cond, then, els = AndCond{c1, c2}, y.Outs[0], z
+		case i == 0 && y.Outs[0] == z:
+			cond, then, els = AndCond{c1, NotCond{c2}}, y.Outs[1], z
+		case i == 1 && y.Outs[0] == z:
+			cond, then, els = OrCond{c1, c2}, z, y.Outs[1]
+		case i == 1 && y.Outs[1] == z:
+			cond, then, els = OrCond{c1, NotCond{c2}}, z, y.Outs[0]
+		default:
+			continue
+		}
+
+		if then == y || els == y {
+			continue
+		}
+
+		// y's paths now come from x. x already has a path to z
+		for len(y.Outs) > 0 {
+			removeFlowPath(y, y.Outs[0])
+		}
+		removeFlowPath(x, y)
+		if then == z {
+			defineFlowPath(x, els)
+		} else {
+			defineFlowPath(x, then)
+		}
+
+		x.Outs = []*BasicBlock{then, els}
+		x.Cond = cond
+		x.Statements = append(x.Statements, y.Statements...)
+		y.Cond = nil
+		y.Statements = nil
+		return true
+	}
+	return false
+}
+
+/* hasStatements returns true if a block does anything other than compute its condition */
+func hasStatements(block *BasicBlock) bool {
+	for _, stmt := range block.Statements {
+		if _, ok := stmt.(Comment); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// findDominators finds the immediate dominator and postdominator of each reachable block. Blocks
+// which can't reach a return have no postdominator
+func (s *structurer) findDominators() {
+	entry := s.fn.BasicBlock
+
+	/* Number the reachable blocks in reverse postorder */
+	visited := make(map[*BasicBlock]bool)
+	post := make([]*BasicBlock, 0)
+	var visit func(b *BasicBlock)
+	visit = func(b *BasicBlock) {
+		visited[b] = true
+		for _, out := range b.Outs {
+			if !visited[out] {
+				visit(out)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(entry)
+
+	s.order = make([]*BasicBlock, len(post))
+	s.index = make(map[*BasicBlock]int)
+	for i := range post {
+		b := post[len(post)-1-i]
+		s.order[i] = b
+		s.index[b] = i
+	}
+
+	n := len(s.order)
+	succs := make([][]int, n)
+	exits := make([]int, 0)
+	for i, b := range s.order {
+		for _, out := range b.Outs {
+			succs[i] = append(succs[i], s.index[out])
+		}
+
+		if len(b.Outs) == 0 {
+			exits = append(exits, i)
+		}
+	}
+
+	s.idom = make(map[*BasicBlock]*BasicBlock)
+	for i, d := range dominators(n, 0, succs) {
+		if d != -1 && d != i {
+			s.idom[s.order[i]] = s.order[d]
+		}
+	}
+
+	/* Postdominators are the dominators of the reversed graph, from a virtual exit node */
+	preds := make([][]int, n+1)
+	for i := range succs {
+		for _, j := range succs[i] {
+			preds[j] = append(preds[j], i)
+		}
+	}
+	preds[n] = exits
+
+	s.ipdom = make(map[*BasicBlock]*BasicBlock)
+	for i, d := range dominators(n+1, n, preds) {
+		if i < n && d != -1 && d != n && d != i {
+			s.ipdom[s.order[i]] = s.order[d]
+		}
+	}
+}
+
+// dominators returns the immediate dominator of each node of a graph, using the iterative algorithm
+// of Cooper, Harvey and Kennedy. The entry dominates itself, and unreachable nodes have no dominator (-1)
+func dominators(n, entry int, succs [][]int) []int {
+	/* Number the nodes in reverse postorder */
+	rpo := make([]int, 0, n)
+	number := make([]int, n)
+	for i := range number {
+		number[i] = -1
+	}
+
+	visited := make([]bool, n)
+	var visit func(i int)
+	visit = func(i int) {
+		visited[i] = true
+		for _, j := range succs[i] {
+			if !visited[j] {
+				visit(j)
+			}
+		}
+		rpo = append(rpo, i)
+	}
+	visit(entry)
+
+	for i, j := 0, len(rpo)-1; i < j; i, j = i+1, j-1 {
+		rpo[i], rpo[j] = rpo[j], rpo[i]
+	}
+	for i, node := range rpo {
+		number[node] = i
+	}
+
+	preds := make([][]int, len(rpo))
+	for _, node := range rpo {
+		for _, succ := range succs[node] {
+			preds[number[succ]] = append(preds[number[succ]], number[node])
+		}
+	}
+
+	idom := make([]int, len(rpo))
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[0] = 0
+
+	intersect := func(a, b int) int {
+		for a != b {
+			for a > b {
+				a = idom[a]
+			}
+			for b > a {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for i := 1; i < len(rpo); i++ {
+			newIdom := -1
+			for _, p := range preds[i] {
+				if idom[p] == -1 {
+					continue
+				}
+
+				if newIdom == -1 {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+
+			if newIdom != idom[i] {
+				idom[i] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	result := make([]int, n)
+	for node := range result {
+		result[node] = -1
+		if number[node] != -1 {
+			result[node] = rpo[idom[number[node]]]
+		}
+	}
+	return result
+}
+
+func (s *structurer) dominates(a, b *BasicBlock) bool {
+	for ; b != nil; b = s.idom[b] {
+		if a == b {
+			return true
+		}
+	}
+	return false
+}
+
+// findLoops finds natural loops from their back edges, which are paths to a block which dominates
+// their source. Back edges to the same header are combined into a single loop
+func (s *structurer) findLoops() {
+	for _, b := range s.order {
+		for _, out := range b.Outs {
+			if !s.dominates(out, b) {
+				continue
+			}
+
+			l, ok := s.loops[out]
+			if !ok {
+				l = &loop{
+					header: out,
+					body:   map[*BasicBlock]bool{out: true},
+				}
+				s.loops[out] = l
+			}
+			l.latches = append(l.latches, b)
+
+			/* Walk back from the latch to the header to find the body */
+			work := []*BasicBlock{b}
+			for len(work) > 0 {
+				next := work[len(work)-1]
+				work = work[:len(work)-1]
+				if l.body[next] {
+					continue
+				}
+
+				l.body[next] = true
+				for _, in := range next.Ins {
+					if _, reachable := s.index[in]; reachable {
+						work = append(work, in)
+					}
+				}
+			}
+		}
+	}
+
+	for _, l := range s.loops {
+		l.follow = s.loopFollow(l)
+	}
+}
+
+// loopFollow finds the block a loop exits to. This is the exit of its header for a while loop, or
+// its latch for a do while loop. Otherwise, the exit with the lowest address is used
+func (s *structurer) loopFollow(l *loop) *BasicBlock {
+	if exit := loopExit(l, l.header); exit != nil {
+		return exit
+	}
+
+	if len(l.latches) == 1 {
+		if exit := loopExit(l, l.latches[0]); exit != nil {
+			return exit
+		}
+	}
+
+	var follow *BasicBlock
+	for _, b := range s.order {
+		if !l.body[b] {
+			continue
+		}
+
+		for _, out := range b.Outs {
+			if !l.body[out] && (follow == nil || out.StartAddress() < follow.StartAddress()) {
+				follow = out
+			}
+		}
+	}
+	return follow
+}
+
+/* loopExit returns the outward path of a conditional branch which leaves a loop, if it has exactly one */
+func loopExit(l *loop, b *BasicBlock) *BasicBlock {
+	if b.Cond == nil {
+		return nil
+	}
+
+	in0, in1 := l.body[b.Outs[0]], l.body[b.Outs[1]]
+	switch {
+	case in0 && !in1:
+		return b.Outs[1]
+	case in1 && !in0:
+		return b.Outs[0]
+	}
+	return nil
+}
+
+/* label returns the label marking a block */
+func (s *structurer) label(b *BasicBlock) *Label {
+	label, ok := s.labels[b]
+	if !ok {
+		label = &Label{Address: b.StartAddress()}
+		s.labels[b] = label
+	}
+	return label
+}
+
+func (s *structurer) gotoBlock(b *BasicBlock) Node {
+	s.label(b).Used = true
+	return Goto(b.StartAddress())
+}
+
+// jumpTo returns the statement needed to transfer control to a block which has been or will be emitted
+// elsewhere, or nil if it should be emitted in place
+func (s *structurer) jumpTo(b, stop *BasicBlock, ctx structCtx) Node {
+	switch {
+	case b == nil || b == stop:
+		return nil
+	case b.exit:
+		return Goto(b.address)
+	case b == ctx.continueTarget:
+		return ContinueStmt{}
+	case b == ctx.breakTarget:
+		return BreakStmt{}
+	case b == ctx.nextCase:
+		*ctx.fellThrough = true
+		return StmtList{}
+	case s.emitted[b] || ctx.isReserved(b):
+		return s.gotoBlock(b)
+	}
+	return nil
+}
+
+/* branch emits the statements along one outward path of a block, up to stop */
+func (s *structurer) branch(b, stop *BasicBlock, ctx structCtx) StmtList {
+	if b == stop {
+		return StmtList{}
+	}
+
+	if jump := s.jumpTo(b, stop, ctx); jump != nil {
+		return StmtList{jump}
+	}
+	return s.sequence(b, stop, ctx)
+}
+
+// sequence emits the statements from b onwards, until it reaches stop or the flow leaves the
+// enclosing construct
+func (s *structurer) sequence(b, stop *BasicBlock, ctx structCtx) StmtList {
+	stmts := StmtList{}
+
+	for b != nil && b != stop {
+		if b.exit {
+			return append(stmts, Goto(b.address))
+		}
+
+		if l, ok := s.loops[b]; ok && !s.active[l] {
+			stmts = append(stmts, s.loopStmt(l, ctx)...)
+			b = l.follow
+		} else {
+			s.emitted[b] = true
+			stmts = append(stmts, s.label(b))
+			stmts = append(stmts, b.Statements...)
+
+			switch {
+			case b.Cond != nil:
+				follow := s.ipdom[b]
+
+				// a branch straight out of a loop reads better as a break or continue, followed by the rest
+				if follow != stop && (b.Outs[0] == follow || b.Outs[1] == follow) &&
+					(follow == ctx.breakTarget || follow == ctx.continueTarget) {
+					follow = nil
+				}
+
+				inner := ctx.reserve(follow)
+				inner.nextCase = nil
+				then := s.branch(b.Outs[0], follow, inner)
+				els := s.branch(b.Outs[1], follow, inner)
+				stmts = append(stmts, makeIf(b.Cond, then, els, follow == nil)...)
+				b = follow
+
+			case b.SwitchValue != nil:
+				follow := s.ipdom[b]
+				stmts = append(stmts, s.switchStmt(b, follow, ctx))
+				b = follow
+
+			case len(b.Outs) == 1:
+				b = b.Outs[0]
+
+			default:
+				return stmts
+			}
+		}
+
+		if jump := s.jumpTo(b, stop, ctx); jump != nil {
+			return append(stmts, jump)
+		}
+	}
+
+	return stmts
+}
+
+// makeIf builds an if statement. When the if has no follow, an arm which ends by leaving the
+// enclosing construct lets the other arm be emitted after the if, rather than in an else
+func makeIf(cond Node, then, els StmtList, flatten bool) StmtList {
+	switch {
+	case len(then) == 0 && len(els) == 0:
+		return StmtList{}
+	case len(then) == 0:
+		cond, then, els = NotCond{cond}, els, then
+	case flatten && isTerminal(then):
+		return append(StmtList{IfStmt{cond, then, nil}}, els...)
+	case flatten && isTerminal(els):
+		return append(StmtList{IfStmt{NotCond{cond}, els, nil}}, then...)
+	}
+
+	if len(els) == 0 {
+		return StmtList{IfStmt{cond, then, nil}}
+	}
+	return StmtList{IfStmt{cond, then, els}}
+}
+
+/* isTerminal returns true if a list of statements never continues on to the next statement */
+func isTerminal(stmts StmtList) bool {
+	last := lastStmt(stmts)
+	switch last := last.(type) {
+	case ReturnStmt, BreakStmt, ContinueStmt, Goto:
+		return true
+	case IfStmt:
+		then, thenOk := last.Then.(StmtList)
+		els, elsOk := last.Else.(StmtList)
+		return thenOk && elsOk && isTerminal(then) && isTerminal(els)
+	}
+	return false
+}
+
+/* lastStmt returns the last statement which isn't a comment or label */
+func lastStmt(stmts StmtList) Node {
+	if i := lastStmtIndex(stmts); i != -1 {
+		return stmts[i]
+	}
+	return nil
+}
+
+func lastStmtIndex(stmts StmtList) int {
+	for i := len(stmts) - 1; i >= 0; i-- {
+		switch stmts[i].(type) {
+		case Comment, *Label:
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// loopStmt emits a loop, as a while loop if its header decides whether to exit, as a do while loop
+// if its latch does, and otherwise as an infinite loop
+func (s *structurer) loopStmt(l *loop, ctx structCtx) StmtList {
+	s.active[l] = true
+
+	inner := ctx
+	inner.breakTarget = l.follow
+	inner.continueTarget = l.header
+	inner.nextCase = nil
+	inner = inner.reserve(l.follow)
+
+	h := l.header
+	stmts := StmtList{}
+
+	if exit := loopExit(l, h); exit != nil && exit == l.follow {
+		cond, next := h.Cond, h.Outs[0]
+		if h.Outs[0] == exit {
+			cond, next = NotCond{cond}, h.Outs[1]
+		}
+
+		s.emitted[h] = true
+		stmts = append(stmts, s.label(h))
+		body := StmtList{}
+		if hasStatements(h) && next == h {
+			// a loop of a single block tests its condition at the end
+			return append(stmts, DoWhileStmt{h.Statements, cond})
+		} else if hasStatements(h) {
+			// the condition depends on the header's statements, so they're run on each iteration
+			body = append(body, h.Statements...)
+			body = append(body, IfStmt{NotCond{cond}, StmtList{BreakStmt{}}, nil})
+			cond = BoolLiteral(true)
+		} else {
+			stmts = append(stmts, h.Statements...)
+		}
+
+		body = append(body, s.branch(next, nil, inner)...)
+		body = trimContinue(body)
+		return append(stmts, WhileStmt{cond, body})
+	}
+
+	body := trimContinue(s.sequence(h, nil, inner))
+
+	// A do while loop ends by continuing if its condition holds, or breaking otherwise
+	if i := lastStmtIndex(body); i > 0 {
+		if _, ok := body[i].(BreakStmt); ok {
+			if j := lastStmtIndex(body[:i]); j != -1 {
+				if cond, ok := continueIf(body[j]); ok && !containsContinue(body[:j]) {
+					return StmtList{DoWhileStmt{body[:j], cond}}
+				}
+			}
+		}
+	}
+
+	return StmtList{WhileStmt{BoolLiteral(true), body}}
+}
+
+/* continueIf returns the condition of an if statement which only continues */
+func continueIf(stmt Node) (Node, bool) {
+	ifStmt, ok := stmt.(IfStmt)
+	if !ok {
+		return nil, false
+	}
+
+	cond, then, els := ifStmt.Cond, ifStmt.Then, ifStmt.Else
+	if isEmptyStmt(then) {
+		cond, then, els = NotCond{cond}, els, nil
+	}
+
+	list, ok := then.(StmtList)
+	if !ok || !isEmptyStmt(els) {
+		return nil, false
+	}
+
+	if i := lastStmtIndex(list); i != -1 {
+		if _, ok := list[i].(ContinueStmt); ok && lastStmtIndex(list[:i]) == -1 {
+			return cond, true
+		}
+	}
+	return nil, false
+}
+
+/* trimContinue removes a continue at the end of a loop body, where it's implied */
+func trimContinue(body StmtList) StmtList {
+	if i := lastStmtIndex(body); i != -1 {
+		if _, ok := body[i].(ContinueStmt); ok {
+			return append(body[:i:i], body[i+1:]...)
+		}
+	}
+	return body
+}
+
+/* containsContinue returns true if a continue within stmts applies to the enclosing loop */
+func containsContinue(stmts StmtList) bool {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case ContinueStmt:
+			return true
+		case IfStmt:
+			for _, arm := range []Node{stmt.Then, stmt.Else} {
+				if list, ok := arm.(StmtList); ok && containsContinue(list) {
+					return true
+				}
+			}
+		case SwitchStmt:
+			for _, c := range stmt.Cases {
+				if containsContinue(c.Body) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// switchStmt emits a switch. Cases which share a target are grouped, and the cases are ordered by
+// address so that a case can fall through to the next
+func (s *structurer) switchStmt(b, follow *BasicBlock, ctx structCtx) Node {
+	stmt := SwitchStmt{
+		Value: b.SwitchValue,
+		Cases: make([]SwitchCase, 0),
+	}
+
+	values := make(map[*BasicBlock][]uint32)
+	targets := make([]*BasicBlock, 0)
+	for _, c := range b.cases {
+		if _, ok := values[c.Target]; !ok {
+			targets = append(targets, c.Target)
+		}
+		values[c.Target] = append(values[c.Target], c.Value)
+	}
+
+	/* A default which goes straight to the follow doesn't need a case */
+	if b.defaultCase != nil && b.defaultCase != follow {
+		if _, ok := values[b.defaultCase]; !ok {
+			targets = append(targets, b.defaultCase)
+		}
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].StartAddress() < targets[j].StartAddress()
+	})
+
+	inner := ctx
+	inner.breakTarget = follow
+	inner = inner.reserve(follow)
+
+	for i, target := range targets {
+		fellThrough := false
+		caseCtx := inner
+		caseCtx.nextCase = nil
+		if i+1 < len(targets) {
+			caseCtx.nextCase = targets[i+1]
+		}
+		caseCtx.fellThrough = &fellThrough
+
+		body := s.branch(target, follow, caseCtx)
+		if !fellThrough && !isTerminal(body) {
+			body = append(body, BreakStmt{})
+		}
+
+		stmt.Cases = append(stmt.Cases, SwitchCase{
+			Values:  values[target],
+			Default: target == b.defaultCase,
+			Body:    body,
+		})
+	}
+
+	return stmt
+}
+
+// forLoops turns while loops into for loops, where they're preceded by an assignment to a variable
+// which they test and assign at the end of their body
+func forLoops(stmts StmtList) StmtList {
+	result := make(StmtList, 0, len(stmts))
+	for _, stmt := range stmts {
+		switch st := stmt.(type) {
+		case IfStmt:
+			if then, ok := st.Then.(StmtList); ok {
+				st.Then = forLoops(then)
+			}
+			if els, ok := st.Else.(StmtList); ok {
+				st.Else = forLoops(els)
+			}
+			stmt = st
+
+		case WhileStmt:
+			st.Body = forLoops(st.Body)
+			stmt = st
+			if loop, i, ok := forLoop(result, st); ok {
+				result = append(result[:i:i], result[i+1:]...)
+				stmt = loop
+			}
+
+		case DoWhileStmt:
+			st.Body = forLoops(st.Body)
+			stmt = st
+
+		case SwitchStmt:
+			cases := make([]SwitchCase, len(st.Cases))
+			for i, c := range st.Cases {
+				c.Body = forLoops(c.Body)
+				cases[i] = c
+			}
+			st.Cases = cases
+			stmt = st
+		}
+
+		result = append(result, stmt)
+	}
+	return result
+}
+
+/* forLoop returns the for loop equivalent to a while loop, and the index of its initial assignment */
+func forLoop(preceding StmtList, loop WhileStmt) (ForStmt, int, bool) {
+	if _, ok := loop.Cond.(BoolLiteral); ok || containsContinue(loop.Body) {
+		return ForStmt{}, 0, false
+	}
+
+	i := lastStmtIndex(preceding)
+	j := lastStmtIndex(loop.Body)
+	if i == -1 || j == -1 || usedLabelBetween(preceding, i) || usedLabelBefore(loop.Body, j) {
+		return ForStmt{}, 0, false
+	}
+
+	init, ok := preceding[i].(AssignStmt)
+	if !ok {
+		return ForStmt{}, 0, false
+	}
+
+	step, ok := loop.Body[j].(AssignStmt)
+	if !ok {
+		return ForStmt{}, 0, false
+	}
+
+	identifier := init.Dest.CString()
+	if step.Dest.CString() != identifier || !containsIdentifier(loop.Cond, identifier) {
+		return ForStmt{}, 0, false
+	}
+
+	body := append(loop.Body[:j:j], loop.Body[j+1:]...)
+	return ForStmt{init, loop.Cond, step, body}, i, true
+}
+
+/* usedLabelBetween returns true if something jumps between stmts[i] and the end of stmts */
+func usedLabelBetween(stmts StmtList, i int) bool {
+	for _, stmt := range stmts[i+1:] {
+		if label, ok := stmt.(*Label); ok && label.Used {
+			return true
+		}
+	}
+	return false
+}
+
+/* usedLabelBefore returns true if something jumps directly to stmts[i] */
+func usedLabelBefore(stmts StmtList, i int) bool {
+	for i--; i >= 0; i-- {
+		switch stmt := stmts[i].(type) {
+		case Comment:
+			continue
+		case *Label:
+			if stmt.Used {
+				return true
+			}
+			continue
+		}
+		break
+	}
+	return false
+}
+
+/* containsIdentifier returns true if an expression refers to an identifier */
+func containsIdentifier(expr Node, identifier string) bool {
+	fields := strings.FieldsFunc(expr.CString(), func(r rune) bool {
+		return !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+
+	for _, field := range fields {
+		if field == identifier {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+package script
+
+import (
+	"math"
+	"sort"
+)
+
+// Evidence weights for a suggested type. A value assigned to a variable says more about its type
+// than a use of the variable does
+const (
+	useEvidence   = 1
+	defEvidence   = 2
+	fixedEvidence = 1 << 16
+)
+
+// typeSolver infers the types of variables from constraints gathered while decompiling. Variables
+// which must hold the same type are unified, and each set of unified variables is given the most
+// specific type suggested for any of its members once every function has been decompiled
+type typeSolver struct {
+	parent   map[*Variable]*Variable
+	evidence map[*Variable]map[string]int
+	types    map[string]Type
+	fixed    map[*Variable]bool
+}
+
+func newTypeSolver() *typeSolver {
+	return &typeSolver{
+		parent:   make(map[*Variable]*Variable),
+		evidence: make(map[*Variable]map[string]int),
+		types:    make(map[string]Type),
+		fixed:    make(map[*Variable]bool),
+	}
+}
+
+func (s *typeSolver) find(v *Variable) *Variable {
+	parent, ok := s.parent[v]
+	if !ok {
+		s.parent[v] = v
+		return v
+	}
+
+	if parent != v {
+		parent = s.find(parent)
+		s.parent[v] = parent
+	}
+	return parent
+}
+
+func (s *typeSolver) union(a, b *Variable) {
+	ra, rb := s.find(a), s.find(b)
+	if ra != rb {
+		s.parent[ra] = rb
+	}
+}
+
+// fix keeps a variable's current type, which is also suggested to any variables unified with it
+func (s *typeSolver) fix(v *Variable) {
+	if !s.fixed[v] {
+		s.fixed[v] = true
+		s.suggestVariable(v, v.Type, fixedEvidence)
+	}
+}
+
+// expect suggests the type of a node
+func (s *typeSolver) expect(n Node, typ Type, weight int) {
+	if typ == nil {
+		return
+	}
+
+	if v := typeVariable(n); v != nil {
+		s.suggestVariable(v, typ, weight)
+		return
+	}
+
+	/* Elements of an array give the array its type */
+	if idx, ok := n.(ArrayIndex); ok {
+		if v := typeVariable(idx.Array); v != nil {
+			s.suggestVariable(v, ArrayType{BaseType: typ}, weight)
+		}
+	}
+}
+
+func (s *typeSolver) suggestVariable(v *Variable, typ Type, weight int) {
+	s.find(v)
+
+	name := typ.CString()
+	if typeRank(name) < 0 {
+		return
+	}
+
+	if s.evidence[v] == nil {
+		s.evidence[v] = make(map[string]int)
+	}
+	s.evidence[v][name] += weight
+	s.types[name] = typ
+}
+
+// relate records that two nodes hold the same type
+func (s *typeSolver) relate(a, b Node, weight int) {
+	va, vb := typeVariable(a), typeVariable(b)
+	switch {
+	case va != nil && vb != nil:
+		s.union(va, vb)
+	case va != nil:
+		s.expect(va, exprType(b), weight)
+	case vb != nil:
+		s.expect(vb, exprType(a), weight)
+	default:
+		s.expect(a, exprType(b), weight)
+	}
+}
+
+// solve gives each set of unified variables its best supported type. Variables which span more
+// than one stack slot keep their type, as their size has already been relied upon
+func (s *typeSolver) solve() {
+	classes := make(map[*Variable][]*Variable)
+	for v := range s.parent {
+		root := s.find(v)
+		classes[root] = append(classes[root], v)
+	}
+
+	for _, vars := range classes {
+		votes := make(map[string]int)
+		for _, v := range vars {
+			for name, n := range s.evidence[v] {
+				votes[name] += n
+			}
+		}
+
+		typ := s.bestType(votes)
+		if typ == nil {
+			continue
+		}
+
+		for _, v := range vars {
+			if s.fixed[v] || v.Type == nil || v.Type.StackSize() != 1 {
+				continue
+			}
+			v.Type = typ
+		}
+	}
+}
+
+/* bestType picks the most specific type, then the one with the most evidence */
+func (s *typeSolver) bestType(votes map[string]int) Type {
+	names := make([]string, 0, len(votes))
+	for name := range votes {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if typeRank(a) != typeRank(b) {
+			return typeRank(a) > typeRank(b)
+		}
+		if votes[a] != votes[b] {
+			return votes[a] > votes[b]
+		}
+		return a < b
+	})
+
+	if len(names) == 0 {
+		return nil
+	}
+	return s.types[names[0]]
+}
+
+// typeRank orders types by how much they say about a value. Placeholder types say nothing, and
+// are never suggested. Any value could be an int, so anything more specific is preferred
+func typeRank(name string) int {
+	switch name {
+	case "void", "void*", "unknown32", "Any":
+		return -1
+	case "int":
+		return 0
+	}
+	return 1
+}
+
+// typeVariable returns the variable whose type is the type of a node, if any. Arithmetic has the
+// type of its operands
+func typeVariable(n Node) *Variable {
+	switch n := n.(type) {
+	case *Variable:
+		return n
+	case *VariableReference:
+		return n.Variable
+	case *VariableDeclaration:
+		return n.Variable
+	case UnaryExpr:
+		return typeVariable(n.Node)
+	case BinaryExpr:
+		if !isComparison(n.Op) {
+			if v := typeVariable(n.Left); v != nil {
+				return v
+			}
+			return typeVariable(n.Right)
+		}
+	}
+	return nil
+}
+
+// exprType returns the type of an expression which doesn't depend on any variables, or nil
+func exprType(n Node) Type {
+	switch n := n.(type) {
+	case Immediate:
+		if typed, ok := n.Value.(DataTypeable); ok {
+			return typed.DataType()
+		}
+	case CastExpr:
+		return n.Type
+	case BinaryExpr:
+		if isComparison(n.Op) {
+			return typeMap["BOOL"]
+		}
+		if typ := exprType(n.Left); typ != nil {
+			return typ
+		}
+		return exprType(n.Right)
+	case UnaryExpr:
+		return exprType(n.Node)
+	case NotCond, AndCond, OrCond, XorCond, BoolLiteral:
+		return typeMap["BOOL"]
+	}
+	return nil
+}
+
+func isComparison(op Token) bool {
+	switch op {
+	case EqToken, NotEqToken, GtToken, GeToken, LtToken, LeToken:
+		return true
+	}
+	return false
+}
+
+/* operandType is the type an instruction's suffix says its operands have */
+func operandType(istr Instruction) Type {
+	if isFloatOp(istr) {
+		return FloatType
+	} else if isIntOp(istr) {
+		return IntType
+	}
+	return nil
+}
+
+// retypeStatics reinterprets the initial values of statics which were found to be floats
+func (m *Machine) retypeStatics() {
+	for _, decl := range m.file.Decls.Vars {
+		if decl.Scope != StaticToken || decl.Type.CString() != FloatType.CString() {
+			continue
+		}
+
+		if bits, ok := decl.Value.(uint64); ok {
+			decl.Value = math.Float32frombits(uint32(bits))
+		}
+	}
+}
+
+// global returns a global, which keeps its type if one was given by a globals header
+func (m *Machine) global(index int) Node {
+	global := m.file.GlobalByIndex(index)
+	if name, ok := m.file.GlobalNames[index]; ok {
+		if _, known := LookupType(name.Type); known {
+			m.types.fix(global.(*Variable))
+		}
+	}
+	return global
+}
@@ -14,8 +14,9 @@ func (m *Machine) decompileSetP(block *BasicBlock, peek bool) {
 		value = block.popNode()
 	}
 
-	expectedtype := value.(DataTypeable).DataType()
-	dest.(TypeInferrable).InferType(expectedtype)
+	if ptr, ok := dest.(PtrNode); ok {
+		m.types.relate(ptr.DeRef(), value, defEvidence)
+	}
 
 	block.emitStatement(AssignStmt{dest, value})
 }
@@ -43,7 +44,7 @@ func (m *Machine) decompileAssignment(block *BasicBlock) {
 	var dest Node
 	switch istr.Operation {
 	case OpSetGlobal:
-		dest = m.file.GlobalByIndex(op.Int())
+		dest = m.global(op.Int())
 	case OpSetStatic:
 		dest = m.file.Decls.VariableByIndex(op.Int())
 	case OpSetLocal:
@@ -68,9 +69,7 @@ func (m *Machine) decompileAssignment(block *BasicBlock) {
 	}
 
 	value := block.popNode()
-
-	expectedtype := value.(DataTypeable).DataType()
-	dest.(TypeInferrable).InferType(expectedtype)
+	m.types.relate(dest, value, defEvidence)
 
 	block.emitStatement(AssignStmt{dest, value})
 }
@@ -92,7 +91,7 @@ func (m *Machine) decompileVarAccess(block *BasicBlock) {
 		isPtr = true
 		fallthrough
 	case OpGetGlobal:
-		src = m.file.GlobalByIndex(index)
+		src = m.global(index)
 
 	case OpGetStaticP:
 		isPtr = true
@@ -0,0 +1,20 @@
+package script
+
+import (
+	"path"
+
+	"github.com/tgascoigne/ragekit/resource"
+)
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		script := NewScript(path.Base(name), size)
+		err := script.Unpack(res, func(istr Instruction) {
+			script.Code = append(script.Code, &istr)
+		})
+		return script, err
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "script", Extension: ".ysc", Type: resource.ResourceScript, Arch: resource.ArchPC, Unpack: unpack})
+	resource.RegisterFormat(resource.Format{Name: "script", Extension: ".xsc", Type: resource.ResourceScript, Arch: resource.Arch360, Unpack: unpack})
+}
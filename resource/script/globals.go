@@ -0,0 +1,365 @@
+package script
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A GlobalRef is a function which uses a global
+type GlobalRef struct {
+	Script   string `json:"script"`
+	Function string `json:"function"`
+}
+
+// GlobalInfo describes how a global is used across a set of scripts
+type GlobalInfo struct {
+	Index int `json:"index"`
+
+	Readers    []GlobalRef `json:"readers,omitempty"`
+	Writers    []GlobalRef `json:"writers,omitempty"`
+	References []GlobalRef `json:"references,omitempty"`
+
+	/* Types counts the uses which suggest the global's type, by type name */
+	Types map[string]int `json:"types,omitempty"`
+
+	/* Fields are the struct offsets accessed through a pointer to the global */
+	Fields []int `json:"fields,omitempty"`
+
+	/* ElementSize and ElementFields describe the elements of a global used as an array of structs */
+	ElementSize   int   `json:"elementSize,omitempty"`
+	ElementFields []int `json:"elementFields,omitempty"`
+
+	refs          map[GlobalRef]int
+	fields        map[int]bool
+	elementFields map[int]bool
+}
+
+// GlobalTable collects the uses of globals from a set of scripts
+type GlobalTable struct {
+	Globals map[int]*GlobalInfo
+	Scripts []string
+}
+
+// GlobalName is the name and type given to a global by a header
+type GlobalName struct {
+	Name string
+	Type string
+}
+
+const (
+	globalRead = iota
+	globalWrite
+	globalReference
+)
+
+var globalHeaderRegexp = regexp.MustCompile(`^\s*extern\s+([\w*]+)\s+(\w+)\s*;\s*//\s*global\s+(\d+)`)
+
+func NewGlobalTable() *GlobalTable {
+	return &GlobalTable{
+		Globals: make(map[int]*GlobalInfo),
+		Scripts: make([]string, 0),
+	}
+}
+
+// Scan records the globals used by a script's instructions
+func (t *GlobalTable) Scan(name string, code []Instruction) {
+	t.Scripts = append(t.Scripts, name)
+
+	function := ""
+	for i, istr := range code {
+		if op, ok := istr.Operands.(*EnterOperands); ok {
+			function = op.Name
+			continue
+		}
+
+		var kind int
+		switch istr.Operation {
+		case OpGetGlobal:
+			kind = globalRead
+		case OpSetGlobal:
+			kind = globalWrite
+		case OpGetGlobalP:
+			kind = globalReference
+		default:
+			continue
+		}
+
+		op, ok := istr.Operands.(ImmediateIntOperands)
+		if !ok {
+			continue
+		}
+
+		global := t.global(op.Int())
+		global.addRef(kind, GlobalRef{name, function})
+
+		switch kind {
+		case globalRead:
+			if i+1 < len(code) {
+				global.addType(readType(code[i+1]))
+			}
+		case globalWrite:
+			if i > 0 {
+				global.addType(writeType(code[i-1]))
+			}
+		case globalReference:
+			if i+1 < len(code) {
+				global.addField(code[i+1])
+			}
+
+			if i+2 < len(code) && code[i+1].Operation == OpGetArrayP {
+				global.addElementField(code[i+1], code[i+2])
+			}
+		}
+	}
+}
+
+func (t *GlobalTable) global(index int) *GlobalInfo {
+	global, ok := t.Globals[index]
+	if !ok {
+		global = &GlobalInfo{
+			Index:         index,
+			Types:         make(map[string]int),
+			refs:          make(map[GlobalRef]int),
+			fields:        make(map[int]bool),
+			elementFields: make(map[int]bool),
+		}
+		t.Globals[index] = global
+	}
+	return global
+}
+
+func (g *GlobalInfo) addRef(kind int, ref GlobalRef) {
+	/* Only the first use of each kind by a function is recorded */
+	if g.refs[ref]&(1<<uint(kind)) != 0 {
+		return
+	}
+	g.refs[ref] |= 1 << uint(kind)
+
+	switch kind {
+	case globalRead:
+		g.Readers = append(g.Readers, ref)
+	case globalWrite:
+		g.Writers = append(g.Writers, ref)
+	case globalReference:
+		g.References = append(g.References, ref)
+	}
+}
+
+func (g *GlobalInfo) addType(typ Type) {
+	if typ != nil {
+		g.Types[typ.CString()]++
+	}
+}
+
+/* addField records the struct field accessed by the instruction following a reference to the global */
+func (g *GlobalInfo) addField(next Instruction) {
+	g.Fields = recordField(g.Fields, g.fields, next)
+}
+
+/* addElementField records the struct field accessed in an element of the global, indexed by array */
+func (g *GlobalInfo) addElementField(array, next Instruction) {
+	if op, ok := array.Operands.(ImmediateIntOperands); ok {
+		g.ElementSize = op.Int()
+	}
+	g.ElementFields = recordField(g.ElementFields, g.elementFields, next)
+}
+
+/* recordField adds the offset accessed by a field instruction to a sorted list of offsets */
+func recordField(fields []int, seen map[int]bool, istr Instruction) []int {
+	switch istr.Operation {
+	case OpGetFieldP, OpGetField, OpSetField:
+	default:
+		return fields
+	}
+
+	if op, ok := istr.Operands.(ImmediateIntOperands); ok && !seen[op.Int()] {
+		seen[op.Int()] = true
+		fields = append(fields, op.Int())
+		sort.Ints(fields)
+	}
+	return fields
+}
+
+// Type returns the type most often suggested by the global's uses, or UnknownType
+func (g *GlobalInfo) Type() Type {
+	best, count := "", 0
+	for name, n := range g.Types {
+		if n > count || (n == count && name < best) {
+			best, count = name, n
+		}
+	}
+
+	if typ, ok := typeMap[best]; ok {
+		return typ
+	}
+	return UnknownType
+}
+
+func isFloatOp(istr Instruction) bool {
+	suffix := OpSuffix[istr.Opcode]
+	return suffix == "f" || suffix == "imf"
+}
+
+func isIntOp(istr Instruction) bool {
+	switch OpSuffix[istr.Opcode] {
+	case "i", "im", "imb", "ims", "b", "s", "t":
+		return true
+	}
+	return false
+}
+
+/* writeType guesses the type of a value from the instruction which produced it */
+func writeType(prev Instruction) Type {
+	op := prev.Operation
+	switch {
+	case op == OpItoF:
+		return FloatType
+	case op == OpFtoI || op == OpPushStrN:
+		return IntType
+	case op == OpPushStr:
+		return StringType
+	case op > OpCmpStart && op < OpCmpEnd, op > OpBoolStart && op < OpBoolEnd:
+		return typeMap["BOOL"]
+	case op == OpPush || (op > OpMathStart && op < OpMathEnd):
+		if isFloatOp(prev) {
+			return FloatType
+		} else if isIntOp(prev) {
+			return IntType
+		}
+	}
+	return nil
+}
+
+/* readType guesses the type of a value from the instruction which uses it */
+func readType(next Instruction) Type {
+	op := next.Operation
+	switch {
+	case op == OpFtoI:
+		return FloatType
+	case op == OpItoF:
+		return IntType
+	case op == OpBranchZ || op == OpNot:
+		return typeMap["BOOL"]
+	case (op > OpMathStart && op < OpMathEnd) || (op > OpCmpStart && op < OpCmpEnd):
+		if isFloatOp(next) {
+			return FloatType
+		} else if isIntOp(next) {
+			return IntType
+		}
+	}
+	return nil
+}
+
+// Sorted returns the globals ordered by index
+func (t *GlobalTable) Sorted() []*GlobalInfo {
+	globals := make([]*GlobalInfo, 0, len(t.Globals))
+	for _, g := range t.Globals {
+		globals = append(globals, g)
+	}
+
+	sort.Slice(globals, func(i, j int) bool {
+		return globals[i].Index < globals[j].Index
+	})
+	return globals
+}
+
+// WriteJSON writes the table as a JSON array of globals
+func (t *GlobalTable) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t.Sorted())
+}
+
+// WriteHeader writes the table as a C header declaring each global. Globals can be renamed in the
+// header, which is read back with ReadGlobalHeader to name them when decompiling
+func (t *GlobalTable) WriteHeader(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+
+	fmt.Fprintf(buf, "/* %v globals used by %v scripts */\n\n", len(t.Globals), len(t.Scripts))
+
+	for _, g := range t.Sorted() {
+		notes := make([]string, 0)
+		if len(g.Readers) > 0 {
+			notes = append(notes, fmt.Sprintf("read by %v", describeRefs(g.Readers)))
+		}
+
+		if len(g.Writers) > 0 {
+			notes = append(notes, fmt.Sprintf("written by %v", describeRefs(g.Writers)))
+		}
+
+		if len(g.References) > 0 {
+			notes = append(notes, fmt.Sprintf("referenced by %v", describeRefs(g.References)))
+		}
+
+		if len(g.Fields) > 0 {
+			notes = append(notes, fmt.Sprintf("fields %v", joinInts(g.Fields)))
+		}
+
+		if len(g.ElementFields) > 0 {
+			notes = append(notes, fmt.Sprintf("array of %v-slot elements with fields %v", g.ElementSize, joinInts(g.ElementFields)))
+		}
+
+		fmt.Fprintf(buf, "extern %v global_%v; // global %v: %v\n", g.Type().CString(), g.Index, g.Index, strings.Join(notes, "; "))
+	}
+
+	return buf.Flush()
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.Itoa(v)
+	}
+	return strings.Join(strs, " ")
+}
+
+/* describeRefs summarizes a list of references by the scripts they're from */
+func describeRefs(refs []GlobalRef) string {
+	scripts := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		if !seen[ref.Script] {
+			seen[ref.Script] = true
+			scripts = append(scripts, ref.Script)
+		}
+	}
+	sort.Strings(scripts)
+
+	const maxScripts = 4
+	if len(scripts) > maxScripts {
+		scripts = append(scripts[:maxScripts], "...")
+	}
+
+	return fmt.Sprintf("%v functions in %v scripts (%v)", len(refs), len(seen), strings.Join(scripts, ", "))
+}
+
+// ReadGlobalHeader reads the names and types of globals from a header written by WriteHeader
+func ReadGlobalHeader(r io.Reader) (map[int]GlobalName, error) {
+	names := make(map[int]GlobalName)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		match := globalHeaderRegexp.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		index, err := strconv.Atoi(match[3])
+		if err != nil {
+			return nil, err
+		}
+
+		names[index] = GlobalName{
+			Name: match[2],
+			Type: match[1],
+		}
+	}
+
+	return names, scanner.Err()
+}
@@ -0,0 +1,175 @@
+package script
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ScriptInfo summarizes the data and functions of a script
+type ScriptInfo struct {
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	Platform string `json:"platform"`
+	CodeSize uint32 `json:"code_size"`
+
+	Strings   []StringInfo   `json:"strings"`
+	Statics   []StaticInfo   `json:"statics"`
+	Natives   []NativeInfo   `json:"natives"`
+	Functions []FunctionInfo `json:"functions"`
+}
+
+// StringInfo is an entry in the string table
+type StringInfo struct {
+	Offset int    `json:"offset"`
+	Value  string `json:"value"`
+}
+
+// StaticInfo is the initial value of a static
+type StaticInfo struct {
+	Index int    `json:"index"`
+	Value uint64 `json:"value"`
+}
+
+// NativeInfo is an entry in the native table. Name is empty if the native isn't in the native DB
+type NativeInfo struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+	Name  string `json:"name"`
+	Calls int    `json:"calls"`
+}
+
+// FunctionInfo describes a function. Locals is the size of its frame, which includes the arguments
+type FunctionInfo struct {
+	Name    string `json:"name"`
+	Address uint32 `json:"address"`
+	Size    uint32 `json:"size"`
+	Args    int    `json:"args"`
+	Locals  int    `json:"locals"`
+}
+
+// InfoTables are the tables which can be written as CSV
+var InfoTables = []string{"strings", "statics", "natives", "functions"}
+
+// NewScriptInfo summarizes an unpacked script and its instructions
+func NewScriptInfo(name string, script *Script, code []Instruction) *ScriptInfo {
+	info := &ScriptInfo{
+		Name:      name,
+		Title:     script.Title,
+		Platform:  script.platform().Name,
+		CodeSize:  script.Header.CodeLength,
+		Strings:   make([]StringInfo, 0),
+		Statics:   make([]StaticInfo, 0, len(script.StaticValues)),
+		Natives:   make([]NativeInfo, 0, len(script.NativeTable)),
+		Functions: make([]FunctionInfo, 0),
+	}
+
+	for offset := 0; offset < len(script.StringTable); {
+		end := bytes.IndexByte(script.StringTable[offset:], 0)
+		if end == -1 {
+			end = len(script.StringTable) - offset
+		}
+
+		info.Strings = append(info.Strings, StringInfo{offset, string(script.StringTable[offset : offset+end])})
+		offset += end + 1
+	}
+
+	for i, value := range script.StaticValues {
+		info.Statics = append(info.Statics, StaticInfo{i, value})
+	}
+
+	calls := make(map[Native64]int)
+	for _, istr := range code {
+		switch op := istr.Operands.(type) {
+		case *CallNOperands:
+			calls[op.Native]++
+		case *EnterOperands:
+			if n := len(info.Functions); n > 0 {
+				info.Functions[n-1].Size = istr.Address - info.Functions[n-1].Address
+			}
+
+			info.Functions = append(info.Functions, FunctionInfo{
+				Name:    op.Name,
+				Address: istr.Address,
+				Args:    int(op.NumArgs),
+				Locals:  op.FrameSize(),
+			})
+		}
+	}
+
+	if n := len(info.Functions); n > 0 {
+		info.Functions[n-1].Size = info.CodeSize - info.Functions[n-1].Address
+	}
+
+	for i, native := range script.NativeTable {
+		entry := NativeInfo{
+			Index: i,
+			Hash:  fmt.Sprintf("%x", uint64(native)),
+			Calls: calls[native],
+		}
+
+		if spec := script.LookupNative(native); spec != nil {
+			entry.Name = spec.Name
+		}
+
+		info.Natives = append(info.Natives, entry)
+	}
+
+	return info
+}
+
+// WriteScriptInfoJSON writes a set of script summaries as a JSON array
+func WriteScriptInfoJSON(w io.Writer, infos []*ScriptInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(infos)
+}
+
+// WriteScriptInfoCSV writes one of the InfoTables from a set of script summaries as CSV, with a
+// row per entry, each starting with the name of its script
+func WriteScriptInfoCSV(w io.Writer, infos []*ScriptInfo, table string) error {
+	out := csv.NewWriter(w)
+
+	var header []string
+	switch table {
+	case "strings":
+		header = []string{"script", "offset", "value"}
+	case "statics":
+		header = []string{"script", "index", "value"}
+	case "natives":
+		header = []string{"script", "index", "hash", "name", "calls"}
+	case "functions":
+		header = []string{"script", "name", "address", "size", "args", "locals"}
+	default:
+		return fmt.Errorf("unknown table: %v", table)
+	}
+	out.Write(header)
+
+	for _, info := range infos {
+		switch table {
+		case "strings":
+			for _, s := range info.Strings {
+				out.Write([]string{info.Name, strconv.Itoa(s.Offset), s.Value})
+			}
+		case "statics":
+			for _, s := range info.Statics {
+				out.Write([]string{info.Name, strconv.Itoa(s.Index), strconv.FormatUint(s.Value, 10)})
+			}
+		case "natives":
+			for _, n := range info.Natives {
+				out.Write([]string{info.Name, strconv.Itoa(n.Index), n.Hash, n.Name, strconv.Itoa(n.Calls)})
+			}
+		case "functions":
+			for _, f := range info.Functions {
+				out.Write([]string{info.Name, f.Name, fmt.Sprintf("%.8x", f.Address), strconv.Itoa(int(f.Size)), strconv.Itoa(f.Args), strconv.Itoa(f.Locals)})
+			}
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
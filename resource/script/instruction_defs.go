@@ -393,6 +393,7 @@ var OpSuffix = map[uint8]string{
 	28:  "v",
 	29:  "v",
 	30:  "v",
+	36:  "v", /* distinguishes it from dup (42), which has the same operation */
 	37:  "b",
 	38:  "b",
 	39:  "b",
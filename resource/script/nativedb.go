@@ -5,7 +5,10 @@ import (
 	"compress/flate"
 	"encoding/json"
 	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -17,6 +20,12 @@ func (n Native64) unmangle(codesize uint32, index int) Native64 {
 	return (n << rotateN) | (n >> uint64(64-rotateN))
 }
 
+/* mangle is the inverse of unmangle, giving the value stored in the native table */
+func (n Native64) mangle(codesize uint32, index int) Native64 {
+	rotateN := (uint64(codesize) + uint64(index)) % 64
+	return (n >> rotateN) | (n << uint64(64-rotateN))
+}
+
 type Native32 uint32
 
 type nativeTable map[string]nativeCategory
@@ -29,17 +38,26 @@ type NativeParam struct {
 	TypeString string `json:"type"`
 }
 
+// NativeSpec describes a native. Older databases give the result type as results, and newer ones
+// as return_type, along with the build the native was added in and any names it used to have
 type NativeSpec struct {
 	Jhash         string        `json:"jhash"`
 	Name          string        `json:"name"`
 	Params        []NativeParam `json:"params"`
 	Results       Type          `json:"-"`
 	ResultsString string        `json:"results"`
+	ReturnType    string        `json:"return_type"`
+	Build         string        `json:"build,omitempty"`
+	OldNames      []string      `json:"old_names,omitempty"`
+	Comment       string        `json:"comment,omitempty"`
 }
 
 type NativeDB struct {
 	jsonTable nativeTable
 	table     map[Native64]NativeSpec
+
+	/* table32 indexes natives by jhash, the 32 bit hash used by console scripts */
+	table32 map[Native32]NativeSpec
 }
 
 func LoadNatives(path string) (*NativeDB, error) {
@@ -55,38 +73,117 @@ func LoadNatives(path string) (*NativeDB, error) {
 	}
 
 	table.table = make(map[Native64]NativeSpec)
+	table.table32 = make(map[Native32]NativeSpec)
 
 	for _, category := range table.jsonTable {
 		for hashStr, entry := range category {
-			hash, err := strconv.ParseUint(hashStr[2:], 16, 64)
+			hash, err := parseNativeHash(hashStr)
 			if err != nil {
 				return nil, err
 			}
 
-			entry.Results = GetType(entry.ResultsString)
+			results := entry.ResultsString
+			if results == "" {
+				results = entry.ReturnType
+			}
+
+			entry.Results = nativeType(results, VoidType)
 			for i := range entry.Params {
-				entry.Params[i].Type = GetType(entry.Params[i].TypeString)
+				entry.Params[i].Type = nativeType(entry.Params[i].TypeString, GetType("Any"))
 			}
 
 			table.table[Native64(hash)] = entry
+
+			if jhash, err := parseNativeHash(entry.Jhash); err == nil && entry.Jhash != "" {
+				table.table32[Native32(jhash)] = entry
+			}
 		}
 	}
 
 	return table, nil
 }
 
-func (t *NativeDB) LoadTranslations(path string) error {
+// LookupNative32 finds a native by its 32 bit hash
+func (t *NativeDB) LookupNative32(hash Native32) *NativeSpec {
+	if t == nil {
+		return nil
+	}
+
+	if entry, ok := t.table32[hash]; ok {
+		return &entry
+	}
+
+	return nil
+}
+
+func parseNativeHash(s string) (uint64, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// nativeType parses a type from a native database. Types which aren't known are given their own
+// engine type, so they're still named correctly. An empty type is taken to be def
+func nativeType(s string, def Type) Type {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "const "))
+	if s == "" {
+		return def
+	}
+
+	if t, ok := LookupType(s); ok {
+		return t
+	}
+
+	if Token(s[len(s)-1]) == DeRefToken {
+		return PtrType{
+			BaseType: nativeType(s[:len(s)-1], def),
+		}
+	}
+
+	return EngineType{SimpleType{
+		Type: s,
+		Size: 1,
+	}}
+}
+
+// LoadTranslations loads tables of native hashes from one game build to the next, applying each in
+// order, so that translations between several builds can be chained. Files ending in .dat are
+// compressed native_translation.dat tables, and anything else is read as a crossmap
+func (t *NativeDB) LoadTranslations(paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		var err error
+		if filepath.Ext(path) == ".dat" {
+			err = t.loadTranslationTable(path)
+		} else {
+			err = t.LoadCrossmap(path)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* loadTranslationTable loads a deflate compressed list of new:old hashes */
+func (t *NativeDB) loadTranslationTable(path string) error {
 	file, err := os.Open(path)
-	defer file.Close()
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 
 	flateReader := flate.NewReader(file)
 	scanner := bufio.NewScanner(flateReader)
 	for scanner.Scan() {
 		line := scanner.Text()
 		parts := strings.Split(line, ":")
+		if len(parts) != 2 {
+			continue
+		}
 
 		newHash, err := strconv.ParseUint(parts[0], 16, 64)
 		if err != nil {
@@ -98,18 +195,77 @@ func (t *NativeDB) LoadTranslations(path string) error {
 			return err
 		}
 
-		// copy spec at oldHash into newHash
-		if entry, ok := t.table[Native64(oldHash)]; ok {
-			t.table[Native64(newHash)] = entry
+		t.translate(Native64(oldHash), Native64(newHash))
+	}
+
+	return scanner.Err()
+}
+
+var crossmapRegexp = regexp.MustCompile(`\b(?:0[xX])?[0-9a-fA-F]{1,16}\b`)
+
+// LoadCrossmap loads a plain text crossmap, with a pair of hashes on each line. Separators and
+// anything else around the hashes are ignored, so both lists of pairs and C arrays of
+// { 0x..., 0x... } entries can be read. The hash which is already known is translated to the other,
+// whichever order they're in. Lines with hashes which don't form a pair are reported and skipped
+func (t *NativeDB) LoadCrossmap(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := crossmapRegexp.FindAllString(line, -1)
+		if len(fields) != 2 {
+			if len(fields) != 0 {
+				log.Printf("%v:%v: skipping line with %v hashes\n", path, lineNum, len(fields))
+			}
+			continue
+		}
+
+		a, err := parseNativeHash(fields[0])
+		if err != nil {
+			return err
+		}
+
+		b, err := parseNativeHash(fields[1])
+		if err != nil {
+			return err
+		}
+
+		if _, ok := t.table[Native64(a)]; ok {
+			t.translate(Native64(a), Native64(b))
 		} else {
-			//fmt.Printf("Missing source native for translation %x -> %x\n", oldHash, newHash)
+			t.translate(Native64(b), Native64(a))
 		}
 	}
 
-	return nil
+	return scanner.Err()
+}
+
+/* translate copies the spec of a native to its hash in another build */
+func (t *NativeDB) translate(from, to Native64) {
+	if entry, ok := t.table[from]; ok {
+		if _, exists := t.table[to]; !exists {
+			t.table[to] = entry
+		}
+	}
 }
 
 func (t *NativeDB) LookupNative(hash Native64) *NativeSpec {
+	if t == nil {
+		return nil
+	}
+
 	if entry, ok := t.table[hash]; ok {
 		return &entry
 	}
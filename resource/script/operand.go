@@ -1,7 +1,11 @@
 package script
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/tgascoigne/ragekit/resource"
@@ -10,6 +14,10 @@ import (
 type Operands interface {
 	String() string /* first is the operand string, second is a mnemonic suffix */
 	Unpack(*Instruction, *Script, *resource.Container)
+
+	/* Parse and Pack are the inverse of String and Unpack, and are used by the assembler */
+	Parse(istr *Instruction, args string, asm *Assembler) error
+	Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error
 }
 
 type ImmediateIntOperands interface {
@@ -24,6 +32,15 @@ func (op *NoOperands) String() string {
 
 func (op *NoOperands) Unpack(istr *Instruction, script *Script, res *resource.Container) {}
 
+func (op *NoOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	return expectFields(fields, 0)
+}
+
+func (op *NoOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return nil
+}
+
 type Immediate8Operands struct {
 	Val uint8
 }
@@ -44,6 +61,20 @@ func (op *Immediate8Operands) Unpack(istr *Instruction, script *Script, res *res
 	res.Parse(&op.Val)
 }
 
+func (op *Immediate8Operands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	vals, err := parseImmediates(args, 1, 8)
+	if err != nil {
+		return err
+	}
+
+	op.Val = uint8(vals[0])
+	return nil
+}
+
+func (op *Immediate8Operands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return buf.WriteByte(op.Val)
+}
+
 type Immediate8x2Operands struct {
 	Val0, Val1 uint8
 }
@@ -61,6 +92,21 @@ func (op *Immediate8x2Operands) Unpack(istr *Instruction, script *Script, res *r
 	res.Parse(&op.Val1)
 }
 
+func (op *Immediate8x2Operands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	vals, err := parseImmediates(args, 2, 8)
+	if err != nil {
+		return err
+	}
+
+	op.Val0, op.Val1 = uint8(vals[0]), uint8(vals[1])
+	return nil
+}
+
+func (op *Immediate8x2Operands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	_, err := buf.Write([]byte{op.Val0, op.Val1})
+	return err
+}
+
 type Immediate8x3Operands struct {
 	Val0, Val1, Val2 uint8
 }
@@ -79,6 +125,21 @@ func (op *Immediate8x3Operands) Unpack(istr *Instruction, script *Script, res *r
 	res.Parse(&op.Val2)
 }
 
+func (op *Immediate8x3Operands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	vals, err := parseImmediates(args, 3, 8)
+	if err != nil {
+		return err
+	}
+
+	op.Val0, op.Val1, op.Val2 = uint8(vals[0]), uint8(vals[1]), uint8(vals[2])
+	return nil
+}
+
+func (op *Immediate8x3Operands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	_, err := buf.Write([]byte{op.Val0, op.Val1, op.Val2})
+	return err
+}
+
 type Immediate24Operands struct {
 	Val uint32
 }
@@ -107,6 +168,21 @@ func (op *Immediate24Operands) Unpack(istr *Instruction, script *Script, res *re
 	op.Val += uint32(val0)
 }
 
+func (op *Immediate24Operands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	vals, err := parseImmediates(args, 1, 24)
+	if err != nil {
+		return err
+	}
+
+	op.Val = uint32(vals[0])
+	return nil
+}
+
+func (op *Immediate24Operands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	_, err := buf.Write([]byte{byte(op.Val), byte(op.Val >> 8), byte(op.Val >> 16)})
+	return err
+}
+
 type Immediate16Operands struct {
 	Val uint16
 }
@@ -127,6 +203,20 @@ func (op *Immediate16Operands) Unpack(istr *Instruction, script *Script, res *re
 	res.Parse(&op.Val)
 }
 
+func (op *Immediate16Operands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	vals, err := parseImmediates(args, 1, 16)
+	if err != nil {
+		return err
+	}
+
+	op.Val = uint16(vals[0])
+	return nil
+}
+
+func (op *Immediate16Operands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return binary.Write(buf, resource.ByteOrder(), op.Val)
+}
+
 type Immediate32Operands struct {
 	Val      uint32
 	HashStrs []string
@@ -151,8 +241,6 @@ func (op *Immediate32Operands) String() string {
 func (op *Immediate32Operands) Unpack(istr *Instruction, script *Script, res *resource.Container) {
 	res.Parse(&op.Val)
 
-	fmt.Printf("FIXME: Immediate32 operand!\n")
-
 	/*if hashStrs, ok := script.HashLookup(op.Val); ok {
 		op.HashStrs = hashStrs
 	} else {
@@ -160,6 +248,20 @@ func (op *Immediate32Operands) Unpack(istr *Instruction, script *Script, res *re
 	}*/
 }
 
+func (op *Immediate32Operands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	vals, err := parseImmediates(args, 1, 32)
+	if err != nil {
+		return err
+	}
+
+	op.Val = uint32(vals[0])
+	return nil
+}
+
+func (op *Immediate32Operands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return binary.Write(buf, resource.ByteOrder(), op.Val)
+}
+
 type ImmediateF32Operands struct {
 	Val float32
 }
@@ -176,9 +278,29 @@ func (op *ImmediateF32Operands) Unpack(istr *Instruction, script *Script, res *r
 	res.Parse(&op.Val)
 }
 
+func (op *ImmediateF32Operands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	if err := expectFields(fields, 1); err != nil {
+		return err
+	}
+
+	val, err := strconv.ParseFloat(fields[0], 32)
+	if err != nil {
+		return err
+	}
+
+	op.Val = float32(val)
+	return nil
+}
+
+func (op *ImmediateF32Operands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return binary.Write(buf, resource.ByteOrder(), op.Val)
+}
+
 type BranchOperands struct {
 	RelativeAddr int16
 	AbsoluteAddr uint32
+	Label        string
 }
 
 func (op *BranchOperands) String() string {
@@ -191,6 +313,27 @@ func (op *BranchOperands) Unpack(istr *Instruction, script *Script, res *resourc
 	op.AbsoluteAddr = uint32(int32(istr.Address) + int32(op.RelativeAddr) + 3)
 }
 
+func (op *BranchOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	if err := expectFields(fields, 1); err != nil {
+		return err
+	}
+
+	op.Label = labelName(fields[0])
+	return nil
+}
+
+func (op *BranchOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	rel, err := asm.relative(op.Label, istr.Address+3, math.MinInt16, math.MaxInt16)
+	if err != nil {
+		return err
+	}
+
+	op.RelativeAddr = int16(rel)
+	op.AbsoluteAddr = uint32(int64(istr.Address) + rel + 3)
+	return binary.Write(buf, resource.ByteOrder(), op.RelativeAddr)
+}
+
 type CallNOperands struct {
 	InSize     uint8
 	OutSize    uint8
@@ -219,8 +362,40 @@ func (op *CallNOperands) Unpack(istr *Instruction, script *Script, res *resource
 	op.OutSize = nativeOperand & 0x3
 }
 
+func (op *CallNOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	if err := expectFields(fields, 3); err != nil {
+		return err
+	}
+
+	native, err := parseNative(fields[0])
+	if err != nil {
+		return err
+	}
+
+	sizes, err := parseImmediates(strings.Join(fields[1:], " "), 2, 8)
+	if err != nil {
+		return err
+	}
+
+	if sizes[0] > 0x3F || sizes[1] > 0x3 {
+		return fmt.Errorf("native argument sizes out of range: %v %v", sizes[0], sizes[1])
+	}
+
+	op.Native = native
+	op.InSize, op.OutSize = uint8(sizes[0]), uint8(sizes[1])
+	asm.nativeIndex(native)
+	return nil
+}
+
+func (op *CallNOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	buf.WriteByte(op.InSize<<2 | op.OutSize)
+	return binary.Write(buf, binary.BigEndian, uint16(asm.nativeIndex(op.Native)))
+}
+
 type CallOperands struct {
-	Val uint32
+	Val   uint32
+	Label string
 }
 
 func (op *CallOperands) String() string {
@@ -239,6 +414,27 @@ func (op *CallOperands) Unpack(istr *Instruction, script *Script, res *resource.
 	op.Val += uint32(val0)
 }
 
+func (op *CallOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	if err := expectFields(fields, 1); err != nil {
+		return err
+	}
+
+	op.Label = labelName(fields[0])
+	return nil
+}
+
+func (op *CallOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	addr, err := asm.relative(op.Label, 0, 0, 0xFFFFFF)
+	if err != nil {
+		return err
+	}
+
+	op.Val = uint32(addr)
+	_, err = buf.Write([]byte{byte(op.Val), byte(op.Val >> 8), byte(op.Val >> 16)})
+	return err
+}
+
 type EnterOperands struct {
 	NumArgs    uint8
 	NumLocals  uint8
@@ -247,6 +443,12 @@ type EnterOperands struct {
 	Name       string
 }
 
+// FrameSize returns the number of slots in the function's frame, including its arguments. Frames
+// larger than 255 slots hold the high byte of their size in Unknown2
+func (op *EnterOperands) FrameSize() int {
+	return int(op.NumLocals) | int(op.Unknown2)<<8
+}
+
 func (op *EnterOperands) String() string {
 	return fmt.Sprintf("%v %v %v %v <%v>", op.NumArgs, op.NumLocals, op.Unknown2, op.NameLength, op.Name)
 }
@@ -257,12 +459,51 @@ func (op *EnterOperands) Unpack(istr *Instruction, script *Script, res *resource
 	res.Parse(&op.Unknown2)
 	res.Parse(&op.NameLength)
 	if op.NameLength > 0 {
-		res.Parse(&op.Name)
+		/* The name occupies exactly NameLength bytes, including its terminator */
+		name := make([]byte, op.NameLength)
+		res.Parse(name)
+		if end := bytes.IndexByte(name, 0); end != -1 {
+			name = name[:end]
+		}
+		op.Name = string(name)
 	} else {
 		op.Name = fmt.Sprintf("anonymous_%x", istr.Address)
 	}
 }
 
+func (op *EnterOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, notes := splitOperands(args)
+	vals, err := parseImmediates(strings.Join(fields, " "), 4, 8)
+	if err != nil {
+		return err
+	}
+
+	op.NumArgs, op.NumLocals, op.Unknown2, op.NameLength = uint8(vals[0]), uint8(vals[1]), uint8(vals[2]), uint8(vals[3])
+	if op.NameLength == 0 {
+		return nil
+	}
+
+	if len(notes) != 1 {
+		return fmt.Errorf("enter with a name length of %v needs a <name>", op.NameLength)
+	}
+
+	op.Name = notes[0]
+	if len(op.Name) > int(op.NameLength) {
+		return fmt.Errorf("function name %q is longer than its name length %v", op.Name, op.NameLength)
+	}
+	return nil
+}
+
+func (op *EnterOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	buf.Write([]byte{op.NumArgs, op.NumLocals, op.Unknown2, op.NameLength})
+	if op.NameLength > 0 {
+		name := make([]byte, op.NameLength)
+		copy(name, op.Name)
+		buf.Write(name)
+	}
+	return nil
+}
+
 type RetOperands struct {
 	NumParams     uint8
 	NumReturnVals uint8
@@ -277,6 +518,21 @@ func (op *RetOperands) Unpack(istr *Instruction, script *Script, res *resource.C
 	res.Parse(&op.NumReturnVals)
 }
 
+func (op *RetOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	vals, err := parseImmediates(args, 2, 8)
+	if err != nil {
+		return err
+	}
+
+	op.NumParams, op.NumReturnVals = uint8(vals[0]), uint8(vals[1])
+	return nil
+}
+
+func (op *RetOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	_, err := buf.Write([]byte{op.NumParams, op.NumReturnVals})
+	return err
+}
+
 type ImplicitOperands struct {
 	offset int
 	Val    int
@@ -294,6 +550,30 @@ func (op *ImplicitOperands) Unpack(istr *Instruction, script *Script, res *resou
 	op.Val = int(istr.Opcode) - op.offset
 }
 
+/* Parse fails unless the value is the one encoded by the instruction's opcode */
+func (op *ImplicitOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	if err := expectFields(fields, 1); err != nil {
+		return err
+	}
+
+	val, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return err
+	}
+
+	if val != int(istr.Opcode)-op.offset {
+		return fmt.Errorf("opcode %v can't encode %v", istr.Opcode, val)
+	}
+
+	op.Val = val
+	return nil
+}
+
+func (op *ImplicitOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return nil
+}
+
 func (op *ImplicitOperands) Int() int {
 	return op.Val
 }
@@ -315,16 +595,42 @@ func (op *ImplicitFOperands) Unpack(istr *Instruction, script *Script, res *reso
 	op.Val = float32(int(istr.Opcode) - op.offset)
 }
 
+/* Parse fails unless the value is the one encoded by the instruction's opcode */
+func (op *ImplicitFOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	if err := expectFields(fields, 1); err != nil {
+		return err
+	}
+
+	val, err := strconv.ParseFloat(fields[0], 32)
+	if err != nil {
+		return err
+	}
+
+	if float32(val) != float32(int(istr.Opcode)-op.offset) {
+		return fmt.Errorf("opcode %v can't encode %v", istr.Opcode, val)
+	}
+
+	op.Val = float32(val)
+	return nil
+}
+
+func (op *ImplicitFOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return nil
+}
+
 type SwitchOperands struct {
+	Cases        []uint32
 	JumpTableRel map[uint32]uint16
 	JumpTableAbs map[uint32]uint32
 	HashStrs     map[uint32]string
+	Labels       map[uint32]string
 }
 
 func (op *SwitchOperands) String() string {
 	targets := make([]string, 0)
-	for cond, addr := range op.JumpTableAbs {
-		targets = append(targets, fmt.Sprintf("%v%v: %.8x", cond, op.HashStrs[cond], addr))
+	for _, cond := range op.Cases {
+		targets = append(targets, fmt.Sprintf("%v%v: %.8x", cond, op.HashStrs[cond], op.JumpTableAbs[cond]))
 	}
 
 	return strings.Join(targets, ", ")
@@ -342,6 +648,7 @@ func (op *SwitchOperands) Unpack(istr *Instruction, script *Script, res *resourc
 		res.Parse(&value)
 		res.Parse(&relAddr)
 		curAddrVirt := istr.Address + uint32(2+((i+1)*6))
+		op.Cases = append(op.Cases, value)
 		op.JumpTableRel[value] = relAddr
 		op.JumpTableAbs[value] = curAddrVirt + uint32(relAddr)
 
@@ -355,6 +662,68 @@ func (op *SwitchOperands) Unpack(istr *Instruction, script *Script, res *resourc
 	}
 }
 
+/* Parse reads a comma separated list of value: target pairs */
+func (op *SwitchOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	op.Cases = make([]uint32, 0)
+	op.JumpTableRel = make(map[uint32]uint16)
+	op.JumpTableAbs = make(map[uint32]uint32)
+	op.HashStrs = make(map[uint32]string)
+	op.Labels = make(map[uint32]string)
+
+	if strings.TrimSpace(args) == "" {
+		return nil
+	}
+
+	for _, c := range strings.Split(args, ",") {
+		sep := strings.LastIndex(c, ":")
+		if sep == -1 {
+			return fmt.Errorf("switch case %q has no target", strings.TrimSpace(c))
+		}
+
+		vals, err := parseImmediates(c[:sep], 1, 32)
+		if err != nil {
+			return err
+		}
+
+		target, _ := splitOperands(c[sep+1:])
+		if err := expectFields(target, 1); err != nil {
+			return err
+		}
+
+		value := uint32(vals[0])
+		if _, ok := op.Labels[value]; ok {
+			return fmt.Errorf("duplicate switch case %v", value)
+		}
+
+		op.Cases = append(op.Cases, value)
+		op.Labels[value] = labelName(target[0])
+		op.HashStrs[value] = " <unknown>"
+	}
+
+	if len(op.Cases) > 0xFF {
+		return fmt.Errorf("too many switch cases (%v)", len(op.Cases))
+	}
+	return nil
+}
+
+func (op *SwitchOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	buf.WriteByte(uint8(len(op.Cases)))
+	for i, value := range op.Cases {
+		/* relative to the end of this case */
+		caseEnd := istr.Address + uint32(2+((i+1)*6))
+		rel, err := asm.relative(op.Labels[value], caseEnd, 0, math.MaxUint16)
+		if err != nil {
+			return err
+		}
+
+		op.JumpTableRel[value] = uint16(rel)
+		op.JumpTableAbs[value] = caseEnd + uint32(rel)
+		binary.Write(buf, resource.ByteOrder(), value)
+		binary.Write(buf, resource.ByteOrder(), uint16(rel))
+	}
+	return nil
+}
+
 type StringOperands struct {
 	Val string
 }
@@ -370,3 +739,16 @@ func (op *StringOperands) String() string {
 func (op *StringOperands) Unpack(istr *Instruction, script *Script, res *resource.Container) {
 
 }
+
+/* The string is taken from the string table at runtime, so any text is ignored */
+func (op *StringOperands) Parse(istr *Instruction, args string, asm *Assembler) error {
+	fields, _ := splitOperands(args)
+	if len(fields) > 1 {
+		return expectFields(fields, 1)
+	}
+	return nil
+}
+
+func (op *StringOperands) Pack(istr *Instruction, asm *Assembler, buf *bytes.Buffer) error {
+	return nil
+}
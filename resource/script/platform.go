@@ -0,0 +1,129 @@
+package script
+
+import (
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+// A Platform describes how scripts are laid out for an architecture
+type Platform struct {
+	Name string
+
+	// WordSize is the size in bytes of the header's pointers, the static initializers and the
+	// natives. Console scripts use 32 bit words, with 32 bit native hashes which aren't mangled
+	WordSize int
+
+	// Opcodes maps the platform's opcodes to the PC numbering used everywhere else. A nil table
+	// means the platform numbers its opcodes the same way as PC
+	Opcodes map[uint8]uint8
+
+	// Operands overrides how the operands of an opcode (PC numbered) are decoded, where the
+	// platform encodes them differently
+	Operands map[uint8]InitOperandFunc
+}
+
+// No opcode or operand tables have been sourced for console scripts, so they're read with the PC
+// numbering and encodings, as they were before platforms were split out. Only their 32 bit layout
+// and natives differ. Tables for them belong in Opcodes and Operands once they're known
+var (
+	PlatformPC = &Platform{
+		Name:     "pc",
+		WordSize: 8,
+	}
+
+	Platform360 = &Platform{
+		Name:     "360",
+		WordSize: 4,
+	}
+
+	PlatformPS3 = &Platform{
+		Name:     "ps3",
+		WordSize: 4,
+	}
+)
+
+// PlatformForArch returns the script platform of an architecture
+func PlatformForArch(arch resource.Arch) *Platform {
+	switch arch {
+	case resource.Arch360:
+		return Platform360
+	case resource.ArchPS3:
+		return PlatformPS3
+	}
+	return PlatformPC
+}
+
+/* opcode translates one of the platform's opcodes to its PC equivalent */
+func (p *Platform) opcode(op uint8) uint8 {
+	if p.Opcodes == nil {
+		return op
+	}
+
+	if pcOp, ok := p.Opcodes[op]; ok {
+		return pcOp
+	}
+	return op
+}
+
+/* operandFunc returns the constructor for the operands of a PC numbered opcode */
+func (p *Platform) operandFunc(op uint8) (InitOperandFunc, bool) {
+	if fn, ok := p.Operands[op]; ok {
+		return fn, true
+	}
+
+	fn, ok := OperandFunc[op]
+	return fn, ok
+}
+
+// LookupNative finds the spec of a native called by one of the platform's scripts. Console
+// scripts identify natives by the 32 bit hash given as jhash in the native DB
+func (p *Platform) LookupNative(db *NativeDB, hash Native64) *NativeSpec {
+	if p.WordSize == 4 {
+		return db.LookupNative32(Native32(hash))
+	}
+	return db.LookupNative(hash)
+}
+
+// scriptHeader32 is the header of a console script. It holds the same fields as ScriptHeader,
+// without the upper halves of the pointers
+type scriptHeader32 struct {
+	_          uint32
+	BlockMap   types.Ptr32
+	CodeMapPtr types.Ptr32
+	_          uint32
+
+	CodeLength  uint32
+	_           uint32
+	StaticCount uint32
+	_           uint32
+
+	NativeCount uint32
+	StaticTable types.Ptr32
+	_           types.Ptr32
+	NativeTable types.Ptr32
+
+	_ uint32
+	_ uint32
+	_ uint32
+	_ uint32
+
+	TitlePtr       types.Ptr32
+	StringTablePtr types.Ptr32
+	StringTableLen uint32
+	_              uint32
+}
+
+func (h scriptHeader32) header() ScriptHeader {
+	return ScriptHeader{
+		BlockMap:       h.BlockMap,
+		CodeMapPtr:     h.CodeMapPtr,
+		CodeLength:     h.CodeLength,
+		StaticCount:    h.StaticCount,
+		NativeCount:    h.NativeCount,
+		StaticTable:    h.StaticTable,
+		NativeTable:    h.NativeTable,
+		TitlePtr:       h.TitlePtr,
+		StringTablePtr: h.StringTablePtr,
+		StringTableLen: h.StringTableLen,
+	}
+}
@@ -64,12 +64,16 @@ type ScriptHeader struct {
 type Script struct {
 	FileName     string
 	FileSize     uint32
+	Title        string
 	Header       ScriptHeader
 	NativeTable  []Native64
 	StaticValues []uint64
 	StringTable  []byte
 	Code         []*Instruction
 	HashTable    *NativeDB
+
+	/* Platform is the layout of the script, which is taken from the architecture when unpacking if unset */
+	Platform *Platform
 }
 
 func NewScript(filename string, filesize uint32) *Script {
@@ -79,12 +83,20 @@ func NewScript(filename string, filesize uint32) *Script {
 	}
 }
 
-func (script *Script) NativeLookup(hash Native64) ([]string, bool) {
-	if script.HashTable == nil {
-		return nil, false
+func (script *Script) platform() *Platform {
+	if script.Platform == nil {
+		return PlatformPC
 	}
+	return script.Platform
+}
 
-	entry := script.HashTable.LookupNative(hash)
+// LookupNative finds the spec of a native called by the script, or returns nil
+func (script *Script) LookupNative(hash Native64) *NativeSpec {
+	return script.platform().LookupNative(script.HashTable, hash)
+}
+
+func (script *Script) NativeLookup(hash Native64) ([]string, bool) {
+	entry := script.LookupNative(hash)
 	if entry == nil {
 		return nil, false
 	}
@@ -92,14 +104,15 @@ func (script *Script) NativeLookup(hash Native64) ([]string, bool) {
 	return []string{entry.Name}, true
 }
 
-func (script *Script) LoadNativeDB(dictPath, xlatePath string) error {
+// LoadNativeDB loads a native database, and any number of translations to apply to it
+func (script *Script) LoadNativeDB(dictPath string, xlatePaths ...string) error {
 	var err error
 	script.HashTable, err = LoadNatives(dictPath)
 	if err != nil {
 		return err
 	}
 
-	err = script.HashTable.LoadTranslations(xlatePath)
+	err = script.HashTable.LoadTranslations(xlatePaths...)
 	if err != nil {
 		return err
 	}
@@ -111,14 +124,31 @@ func (script *Script) LoadNativeDB(dictPath, xlatePath string) error {
 type EmitFunc func(Instruction)
 
 func (script *Script) Unpack(res *resource.Container, emitFn EmitFunc) (err error) {
-	res.Parse(&script.Header)
+	if script.Platform == nil {
+		script.Platform = PlatformForArch(resource.CurrentArch())
+	}
+	wordSize := script.Platform.WordSize
+
+	if wordSize == 4 {
+		var header scriptHeader32
+		res.Parse(&header)
+		script.Header = header.header()
+	} else {
+		res.Parse(&script.Header)
+	}
 
 	/* parse the static initializers */
 	err = res.Detour(script.Header.StaticTable, func() error {
 		count := script.Header.StaticCount
 		script.StaticValues = make([]uint64, count)
 		for i := 0; i < int(count); i++ {
-			res.Parse(&script.StaticValues[i])
+			if wordSize == 4 {
+				var value uint32
+				res.Parse(&value)
+				script.StaticValues[i] = uint64(value)
+			} else {
+				res.Parse(&script.StaticValues[i])
+			}
 		}
 		return nil
 	})
@@ -131,6 +161,13 @@ func (script *Script) Unpack(res *resource.Container, emitFn EmitFunc) (err erro
 		count := script.Header.NativeCount
 		script.NativeTable = make([]Native64, count)
 		for i := 0; i < int(count); i++ {
+			if wordSize == 4 {
+				var native Native32
+				res.Parse(&native)
+				script.NativeTable[i] = Native64(native)
+				continue
+			}
+
 			var mangledNative Native64
 			res.Parse(&mangledNative)
 			script.NativeTable[i] = mangledNative.unmangle(script.Header.CodeLength, i)
@@ -142,26 +179,41 @@ func (script *Script) Unpack(res *resource.Container, emitFn EmitFunc) (err erro
 		fmt.Printf("Couldn't parse native table: %v\n", err)
 	}
 
+	/* parse the title */
+	res.Detour(script.Header.TitlePtr, func() error {
+		res.Parse(&script.Title)
+		return nil
+	})
+
 	/* parse the string table */
 	err = res.Detour(script.Header.StringTablePtr, func() error {
 		var blockAddr types.Ptr32
 		toRead := int(script.Header.StringTableLen)
 		script.StringTable = make([]byte, script.Header.StringTableLen)
-		for i := 0; i < 4; i++ {
-			/* get the next block */
+
+		/* each block has a pointer of one word, so a table without enough valid pointers is corrupt */
+		slots := (toRead + 0x3FFF) / 0x4000 * wordSize / 4
+		for slot := 0; toRead > 0; slot++ {
+			if slot >= slots {
+				return fmt.Errorf("string table is missing %v bytes", toRead)
+			}
+
+			/* get the next block, skipping the upper half of each pointer */
 			res.Parse(&blockAddr)
 			if !blockAddr.Valid() {
-				return nil
+				continue
 			}
 
 			/* parse it */
-			res.Detour(blockAddr, func() error {
+			if err := res.Detour(blockAddr, func() error {
 				offset := int(script.Header.StringTableLen) - toRead
 				length := int(math.Min(float64(0x4000), float64(toRead)))
 				res.Parse(script.StringTable[offset : offset+length])
 				toRead -= length
 				return nil
-			})
+			}); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
@@ -220,13 +272,14 @@ func (script *Script) disassembleBlock(base uint32, res *resource.Container, emi
 			return
 		}
 
-		if (curAddrReal - startAddrReal) > toRead {
+		if (curAddrReal - startAddrReal) >= toRead {
 			/* end of code */
 			return
 		}
 
 		istr := &Instruction{Address: curAddrVirt}
 		res.Parse(&istr.Opcode)
+		istr.Opcode = script.Platform.opcode(istr.Opcode)
 		istr.Operation = OpType[istr.Opcode]
 
 		/* lame way to check for end of code section */
@@ -240,7 +293,7 @@ func (script *Script) disassembleBlock(base uint32, res *resource.Container, emi
 		}
 
 		/* Unpack operands */
-		if operandFunc, ok := OperandFunc[istr.Opcode]; ok {
+		if operandFunc, ok := script.Platform.operandFunc(istr.Opcode); ok {
 			istr.Operands = operandFunc()
 			istr.Operands.Unpack(istr, script, res)
 		} else {
@@ -263,17 +263,31 @@ var (
 )
 
 func GetType(s string) Type {
-	if t, ok := typeMap[s]; ok {
+	if t, ok := LookupType(s); ok {
 		return t
 	}
 
-	if Token(s[len(s)-1]) == DeRefToken {
-		return PtrType{
-			BaseType: GetType(s[:len(s)-1]),
+	panic(fmt.Sprintf("no such type: %v", s))
+}
+
+// LookupType is GetType for names which may not be known
+func LookupType(s string) (Type, bool) {
+	if t, ok := typeMap[s]; ok {
+		return t, true
+	}
+
+	if len(s) > 1 && Token(s[len(s)-1]) == DeRefToken {
+		base, ok := LookupType(s[:len(s)-1])
+		if !ok {
+			return nil, false
 		}
+
+		return PtrType{
+			BaseType: base,
+		}, true
 	}
 
-	panic(fmt.Sprintf("no such type: %v", s))
+	return nil, false
 }
 
 func guessType(stackSize int) Type {
@@ -0,0 +1,744 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/jenkins"
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+/* immediate returns the value of an index, size or offset operand */
+func immediate(istr *script.Instruction) (uint64, error) {
+	switch op := istr.Operands.(type) {
+	case *script.Immediate8Operands:
+		return uint64(op.Val), nil
+	case *script.Immediate16Operands:
+		return uint64(op.Val), nil
+	case *script.Immediate24Operands:
+		return uint64(op.Val), nil
+	}
+	return 0, fmt.Errorf("unexpected operands %T", istr.Operands)
+}
+
+/* signedImmediate is the same as immediate, but 16 bit operands are sign extended */
+func signedImmediate(istr *script.Instruction) (uint64, error) {
+	if op, ok := istr.Operands.(*script.Immediate16Operands); ok {
+		return uint64(int64(int16(op.Val))), nil
+	}
+	return immediate(istr)
+}
+
+/* pushValues returns the values pushed by a push instruction */
+func pushValues(istr *script.Instruction) ([]uint64, error) {
+	switch op := istr.Operands.(type) {
+	case *script.Immediate8Operands:
+		return []uint64{uint64(op.Val)}, nil
+	case *script.Immediate8x2Operands:
+		return []uint64{uint64(op.Val0), uint64(op.Val1)}, nil
+	case *script.Immediate8x3Operands:
+		return []uint64{uint64(op.Val0), uint64(op.Val1), uint64(op.Val2)}, nil
+	case *script.Immediate16Operands:
+		return []uint64{uint64(int64(int16(op.Val)))}, nil
+	case *script.Immediate24Operands:
+		return []uint64{uint64(op.Val)}, nil
+	case *script.Immediate32Operands:
+		return []uint64{FromInt(int32(op.Val))}, nil
+	case *script.ImmediateF32Operands:
+		return []uint64{FromFloat(op.Val)}, nil
+	case *script.ImplicitOperands:
+		return []uint64{FromInt(int32(op.Val))}, nil
+	case *script.ImplicitFOperands:
+		return []uint64{FromFloat(op.Val)}, nil
+	}
+	return nil, fmt.Errorf("unexpected operands %T", istr.Operands)
+}
+
+/* pop2 pops the operands of a binary operation */
+func (vm *VM) pop2() (uint64, uint64, error) {
+	b, err := vm.pop()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	a, err := vm.pop()
+	return a, b, err
+}
+
+func (vm *VM) exec(istr *script.Instruction) error {
+	suffix := script.OpSuffix[istr.Opcode]
+
+	switch op := istr.Operation; {
+	case op == script.OpNop:
+		return nil
+
+	case op > script.OpMathStart && op < script.OpMathEnd:
+		return vm.execMath(istr, suffix)
+
+	case op > script.OpCmpStart && op < script.OpCmpEnd:
+		return vm.execCompare(istr, suffix)
+
+	case op > script.OpBoolStart && op < script.OpBoolEnd:
+		return vm.execBool(istr)
+
+	case op == script.OpItoF:
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.push(FromFloat(float32(Int(a))))
+
+	case op == script.OpFtoI:
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.push(FromInt(int32(Float(a))))
+
+	case op == script.OpPush:
+		values, err := pushValues(istr)
+		if err != nil {
+			return err
+		}
+		return vm.push(values...)
+
+	case op == script.OpDup:
+		a, err := vm.peek()
+		if err != nil {
+			return err
+		}
+
+		/* dupv converts a float to a vector */
+		if suffix == "v" {
+			return vm.push(a, a)
+		}
+		return vm.push(a)
+
+	case op == script.OpDrop:
+		_, err := vm.pop()
+		return err
+
+	case op >= script.OpFlowStart && op <= script.OpFlowEnd:
+		return vm.execFlow(istr)
+
+	case op > script.OpVarStart && op < script.OpVarEnd:
+		return vm.execVar(istr)
+
+	case op == script.OpPushStr:
+		offset, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.push(Pointer(RegionStrings, offset))
+
+	case op == script.OpPushStrN:
+		/* hashes a string, as GET_HASH_KEY does */
+		ptr, err := vm.pop()
+		if err != nil {
+			return err
+		}
+
+		s, err := vm.ReadString(ptr)
+		if err != nil {
+			return err
+		}
+
+		hash := jenkins.New()
+		hash.UpdateArray([]byte(strings.ToLower(s)))
+		return vm.push(FromInt(int32(hash.Hash())))
+
+	case op == script.OpStrCpy, op == script.OpItoS, op == script.OpAppendStr, op == script.OpAppendInt:
+		return vm.execString(istr)
+	}
+
+	return fmt.Errorf("unsupported instruction")
+}
+
+func (vm *VM) execMath(istr *script.Instruction, suffix string) error {
+	op := istr.Operation
+
+	switch suffix {
+	case "v":
+		return vm.execVector(op)
+
+	case "f":
+		if op == script.OpNeg {
+			a, err := vm.pop()
+			if err != nil {
+				return err
+			}
+			return vm.push(FromFloat(-Float(a)))
+		}
+
+		a, b, err := vm.pop2()
+		if err != nil {
+			return err
+		}
+
+		result, err := floatOp(op, Float(a), Float(b))
+		if err != nil {
+			return err
+		}
+		return vm.push(FromFloat(result))
+	}
+
+	if op == script.OpNeg {
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.push(uint64(int64(-int32(a))))
+	}
+
+	var a, b uint64
+	var err error
+	if suffix == "i" {
+		a, b, err = vm.pop2()
+	} else {
+		/* the second operand is an immediate */
+		if b, err = signedImmediate(istr); err == nil {
+			a, err = vm.pop()
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	result, err := intOp(op, int64(a), int64(b))
+	if err != nil {
+		return err
+	}
+
+	/* Arithmetic on ints wraps at 32 bits, but pointers are left intact */
+	if isInt(a) && isInt(b) {
+		result = int64(int32(result))
+	}
+	return vm.push(uint64(result))
+}
+
+func isInt(value uint64) bool {
+	return int64(int32(value)) == int64(value)
+}
+
+func intOp(op uint8, a, b int64) (int64, error) {
+	switch op {
+	case script.OpAdd:
+		return a + b, nil
+	case script.OpSub:
+		return a - b, nil
+	case script.OpMul:
+		return a * b, nil
+	case script.OpDiv:
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	case script.OpMod:
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a % b, nil
+	}
+	return 0, fmt.Errorf("unknown int operation %v", op)
+}
+
+func floatOp(op uint8, a, b float32) (float32, error) {
+	switch op {
+	case script.OpAdd:
+		return a + b, nil
+	case script.OpSub:
+		return a - b, nil
+	case script.OpMul:
+		return a * b, nil
+	case script.OpDiv:
+		return a / b, nil
+	case script.OpMod:
+		return float32(math.Mod(float64(a), float64(b))), nil
+	}
+	return 0, fmt.Errorf("unknown float operation %v", op)
+}
+
+/* execVector operates on vectors of three floats */
+func (vm *VM) execVector(op uint8) error {
+	if op == script.OpNeg {
+		a, err := vm.popN(3)
+		if err != nil {
+			return err
+		}
+
+		for i := range a {
+			a[i] = FromFloat(-Float(a[i]))
+		}
+		return vm.push(a...)
+	}
+
+	b, err := vm.popN(3)
+	if err != nil {
+		return err
+	}
+
+	a, err := vm.popN(3)
+	if err != nil {
+		return err
+	}
+
+	for i := range a {
+		result, err := floatOp(op, Float(a[i]), Float(b[i]))
+		if err != nil {
+			return err
+		}
+		a[i] = FromFloat(result)
+	}
+	return vm.push(a...)
+}
+
+func compare(op uint8, cmp int) (bool, error) {
+	switch op {
+	case script.OpCmpEq, script.OpBranchNe:
+		return cmp == 0, nil
+	case script.OpCmpNe, script.OpBranchEq:
+		return cmp != 0, nil
+	case script.OpCmpGt, script.OpBranchGt:
+		return cmp > 0, nil
+	case script.OpCmpGe, script.OpBranchGe:
+		return cmp >= 0, nil
+	case script.OpCmpLt, script.OpBranchLt:
+		return cmp < 0, nil
+	case script.OpCmpLe, script.OpBranchLe:
+		return cmp <= 0, nil
+	}
+	return false, fmt.Errorf("unknown comparison %v", op)
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float32) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	case a == b:
+		return 0
+	}
+	/* NaN compares false to everything, other than with != */
+	return 2
+}
+
+func (vm *VM) execCompare(istr *script.Instruction, suffix string) error {
+	a, b, err := vm.pop2()
+	if err != nil {
+		return err
+	}
+
+	cmp := compareInts(int64(a), int64(b))
+	if suffix == "f" {
+		cmp = compareFloats(Float(a), Float(b))
+		if cmp == 2 {
+			return vm.push(FromBool(istr.Operation == script.OpCmpNe))
+		}
+	}
+
+	result, err := compare(istr.Operation, cmp)
+	if err != nil {
+		return err
+	}
+	return vm.push(FromBool(result))
+}
+
+func (vm *VM) execBool(istr *script.Instruction) error {
+	if istr.Operation == script.OpNot {
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.push(FromBool(a == 0))
+	}
+
+	a, b, err := vm.pop2()
+	if err != nil {
+		return err
+	}
+
+	switch istr.Operation {
+	case script.OpAnd:
+		return vm.push(a & b)
+	case script.OpOr:
+		return vm.push(a | b)
+	case script.OpXor:
+		return vm.push(a ^ b)
+	}
+	return fmt.Errorf("unknown bool operation %v", istr.Operation)
+}
+
+func (vm *VM) execFlow(istr *script.Instruction) error {
+	switch op := istr.Operands.(type) {
+	case *script.CallNOperands:
+		return vm.callNative(op)
+
+	case *script.EnterOperands:
+		/* The arguments are the first locals */
+		argc := uint64(op.NumArgs)
+		frameSize := uint64(op.FrameSize())
+		if vm.sp < argc {
+			return errors.New("stack underflow")
+		}
+
+		if len(vm.frames) == 0 {
+			vm.frames = append(vm.frames, frame{returnPC: -1})
+		}
+
+		top := &vm.frames[len(vm.frames)-1]
+		top.fp = vm.sp - argc
+		top.argc = argc
+		for i := argc; i < frameSize; i++ {
+			if err := vm.push(0); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *script.RetOperands:
+		results, err := vm.popN(uint64(op.NumReturnVals))
+		if err != nil {
+			return err
+		}
+
+		if len(vm.frames) == 0 {
+			return errors.New("return without a frame")
+		}
+
+		top := vm.frames[len(vm.frames)-1]
+		vm.frames = vm.frames[:len(vm.frames)-1]
+		vm.sp = top.fp
+		vm.pc = top.returnPC
+
+		if top.returnPC == -1 {
+			vm.results = results
+			return nil
+		}
+		return vm.push(results...)
+
+	case *script.CallOperands:
+		return vm.call(op.Val)
+
+	case *script.BranchOperands:
+		return vm.branch(istr, op)
+
+	case *script.SwitchOperands:
+		value, err := vm.pop()
+		if err != nil {
+			return err
+		}
+
+		if target, ok := op.JumpTableAbs[uint32(value)]; ok {
+			return vm.jump(target)
+		}
+		return nil
+	}
+
+	switch istr.Operation {
+	case script.OpCallP:
+		addr, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.call(uint32(addr))
+	}
+
+	return fmt.Errorf("unsupported instruction")
+}
+
+func (vm *VM) call(addr uint32) error {
+	returnPC := vm.pc
+	if err := vm.jump(addr); err != nil {
+		return err
+	}
+
+	vm.frames = append(vm.frames, frame{returnPC: returnPC, fp: vm.sp})
+	return nil
+}
+
+// branch follows the game's semantics for conditional branches, which compare the top two values
+// and branch if the comparison is false. For bne and be this matches the mnemonic, but bgt, bge,
+// blt and ble branch when their comparison fails
+func (vm *VM) branch(istr *script.Instruction, op *script.BranchOperands) error {
+	taken := true
+	switch istr.Operation {
+	case script.OpBranch:
+
+	case script.OpBranchZ:
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		taken = Int(a) == 0
+
+	default:
+		a, b, err := vm.pop2()
+		if err != nil {
+			return err
+		}
+
+		result, err := compare(istr.Operation, compareInts(int64(a), int64(b)))
+		if err != nil {
+			return err
+		}
+		taken = !result
+	}
+
+	if taken {
+		return vm.jump(op.AbsoluteAddr)
+	}
+	return nil
+}
+
+/* address returns a pointer to the variable accessed by a variable instruction */
+func (vm *VM) address(istr *script.Instruction) (uint64, error) {
+	switch istr.Operation {
+	case script.OpGetLocalP, script.OpGetLocal, script.OpSetLocal:
+		index, err := immediate(istr)
+		return vm.Local(index), err
+
+	case script.OpGetStaticP, script.OpGetStatic, script.OpSetStatic:
+		index, err := immediate(istr)
+		return vm.Static(index), err
+
+	case script.OpGetGlobalP, script.OpGetGlobal, script.OpSetGlobal:
+		index, err := immediate(istr)
+		return vm.Global(index), err
+
+	case script.OpGetFieldP, script.OpGetField, script.OpSetField:
+		var offset uint64
+		var err error
+		if _, ok := istr.Operands.(*script.NoOperands); ok {
+			offset, err = vm.pop()
+		} else {
+			offset, err = signedImmediate(istr)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		ptr, err := vm.pop()
+		return ptr + offset*slotSize, err
+
+	case script.OpGetArrayP, script.OpGetArray, script.OpSetArray:
+		/* Arrays start with their length, followed by the items */
+		itemSize, err := immediate(istr)
+		if err != nil {
+			return 0, err
+		}
+
+		ptr, index, err := vm.popArray()
+		if err != nil {
+			return 0, err
+		}
+
+		length, err := vm.Load(ptr)
+		if err != nil {
+			return 0, err
+		}
+
+		if index >= length {
+			return 0, fmt.Errorf("array index %v out of range (length %v)", int64(index), length)
+		}
+		return ptr + slotSize + index*itemSize*slotSize, nil
+	}
+
+	return 0, fmt.Errorf("not a variable access")
+}
+
+func (vm *VM) popArray() (ptr, index uint64, err error) {
+	if ptr, err = vm.pop(); err != nil {
+		return
+	}
+	index, err = vm.pop()
+	return
+}
+
+func (vm *VM) execVar(istr *script.Instruction) error {
+	switch op := istr.Operation; op {
+	case script.OpGetP:
+		ptr, err := vm.pop()
+		if err != nil {
+			return err
+		}
+
+		value, err := vm.Load(ptr)
+		if err != nil {
+			return err
+		}
+		return vm.push(value)
+
+	case script.OpSetP:
+		ptr, value, err := vm.popPair()
+		if err != nil {
+			return err
+		}
+		return vm.Store(ptr, value)
+
+	case script.OpSetPPeek:
+		value, err := vm.pop()
+		if err != nil {
+			return err
+		}
+
+		ptr, err := vm.peek()
+		if err != nil {
+			return err
+		}
+		return vm.Store(ptr, value)
+
+	case script.OpExplode:
+		ptr, count, err := vm.popPair()
+		if err != nil {
+			return err
+		}
+
+		for i := uint64(0); i < count; i++ {
+			value, err := vm.Load(ptr + i*slotSize)
+			if err != nil {
+				return err
+			}
+
+			if err := vm.push(value); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case script.OpImplode:
+		ptr, count, err := vm.popPair()
+		if err != nil {
+			return err
+		}
+
+		values, err := vm.popN(count)
+		if err != nil {
+			return err
+		}
+
+		for i, value := range values {
+			if err := vm.Store(ptr+uint64(i)*slotSize, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ptr, err := vm.address(istr)
+	if err != nil {
+		return err
+	}
+
+	switch istr.Operation {
+	case script.OpGetLocalP, script.OpGetStaticP, script.OpGetGlobalP, script.OpGetFieldP, script.OpGetArrayP:
+		return vm.push(ptr)
+
+	case script.OpGetLocal, script.OpGetStatic, script.OpGetGlobal, script.OpGetField, script.OpGetArray:
+		value, err := vm.Load(ptr)
+		if err != nil {
+			return err
+		}
+		return vm.push(value)
+	}
+
+	value, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	return vm.Store(ptr, value)
+}
+
+/* popPair pops a pointer, followed by the value beneath it */
+func (vm *VM) popPair() (ptr, value uint64, err error) {
+	if ptr, err = vm.pop(); err != nil {
+		return
+	}
+	value, err = vm.pop()
+	return
+}
+
+/* execString handles the fixed size string buffer operations */
+func (vm *VM) execString(istr *script.Instruction) error {
+	if _, ok := istr.Operands.(*script.NoOperands); ok {
+		return vm.copyBuffer()
+	}
+
+	size, err := immediate(istr)
+	if err != nil {
+		return err
+	}
+
+	dest, src, err := vm.popPair()
+	if err != nil {
+		return err
+	}
+
+	var s string
+	switch istr.Operation {
+	case script.OpStrCpy, script.OpAppendStr:
+		if s, err = vm.ReadString(src); err != nil {
+			return err
+		}
+	case script.OpItoS, script.OpAppendInt:
+		s = strconv.Itoa(int(Int(src)))
+	}
+
+	if istr.Operation == script.OpAppendStr || istr.Operation == script.OpAppendInt {
+		existing, err := vm.ReadString(dest)
+		if err != nil {
+			return err
+		}
+		s = existing + s
+	}
+
+	return vm.WriteString(dest, s, int(size))
+}
+
+/* copyBuffer copies values from the stack into a buffer, terminating the last slot */
+func (vm *VM) copyBuffer() error {
+	dest, err := vm.pop()
+	if err != nil {
+		return err
+	}
+
+	size, count, err := vm.popPair()
+	if err != nil {
+		return err
+	}
+
+	values, err := vm.popN(count)
+	if err != nil {
+		return err
+	}
+
+	for i := uint64(0); i < count && i < size; i++ {
+		if err := vm.Store(dest+i*slotSize, values[i]); err != nil {
+			return err
+		}
+	}
+
+	if size == 0 {
+		return nil
+	}
+
+	mem, offset, err := vm.region(dest)
+	if err != nil {
+		return err
+	}
+	return mem.write(offset+size*slotSize-1, []byte{0})
+}
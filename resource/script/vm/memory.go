@@ -0,0 +1,151 @@
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	memoryPageSize = 0x1000
+	slotSize       = 8
+
+	/* Pointers carry the region they point into in their upper bits */
+	regionShift = 40
+	offsetMask  = 1<<regionShift - 1
+)
+
+// Memory regions which pointers can refer to
+const (
+	RegionStack = iota + 1
+	RegionStatics
+	RegionGlobals
+	RegionStrings
+)
+
+/* memory is a sparse, byte addressable region. Unwritten memory reads as zero */
+type memory struct {
+	name     string
+	pages    map[uint64][]byte
+	readOnly bool
+}
+
+func newMemory(name string) *memory {
+	return &memory{
+		name:  name,
+		pages: make(map[uint64][]byte),
+	}
+}
+
+func (m *memory) read(offset uint64, p []byte) {
+	for i := range p {
+		addr := offset + uint64(i)
+		if page, ok := m.pages[addr/memoryPageSize]; ok {
+			p[i] = page[addr%memoryPageSize]
+		} else {
+			p[i] = 0
+		}
+	}
+}
+
+func (m *memory) write(offset uint64, p []byte) error {
+	if m.readOnly {
+		return fmt.Errorf("write to read only memory (%v+%#x)", m.name, offset)
+	}
+
+	for i, b := range p {
+		addr := offset + uint64(i)
+		page, ok := m.pages[addr/memoryPageSize]
+		if !ok {
+			page = make([]byte, memoryPageSize)
+			m.pages[addr/memoryPageSize] = page
+		}
+		page[addr%memoryPageSize] = b
+	}
+	return nil
+}
+
+func (m *memory) load(offset uint64) uint64 {
+	var buf [slotSize]byte
+	m.read(offset, buf[:])
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+func (m *memory) store(offset uint64, value uint64) error {
+	var buf [slotSize]byte
+	binary.LittleEndian.PutUint64(buf[:], value)
+	return m.write(offset, buf[:])
+}
+
+/* Pointer returns a pointer to offset bytes into a region */
+func Pointer(region int, offset uint64) uint64 {
+	return uint64(region)<<regionShift | offset&offsetMask
+}
+
+/* region returns the memory a pointer refers to, and the offset within it */
+func (vm *VM) region(ptr uint64) (*memory, uint64, error) {
+	offset := ptr & offsetMask
+	switch ptr >> regionShift {
+	case RegionStack:
+		return vm.stack, offset, nil
+	case RegionStatics:
+		return vm.statics, offset, nil
+	case RegionGlobals:
+		return vm.globals, offset, nil
+	case RegionStrings:
+		return vm.strings, offset, nil
+	}
+	return nil, 0, fmt.Errorf("invalid pointer %#x", ptr)
+}
+
+// Load reads the value pointed to by ptr
+func (vm *VM) Load(ptr uint64) (uint64, error) {
+	mem, offset, err := vm.region(ptr)
+	if err != nil {
+		return 0, err
+	}
+	return mem.load(offset), nil
+}
+
+// Store writes a value to the slot pointed to by ptr
+func (vm *VM) Store(ptr uint64, value uint64) error {
+	mem, offset, err := vm.region(ptr)
+	if err != nil {
+		return err
+	}
+	return mem.store(offset, value)
+}
+
+// ReadString reads the null terminated string pointed to by ptr
+func (vm *VM) ReadString(ptr uint64) (string, error) {
+	mem, offset, err := vm.region(ptr)
+	if err != nil {
+		return "", err
+	}
+
+	result := make([]byte, 0)
+	var c [1]byte
+	for {
+		mem.read(offset+uint64(len(result)), c[:])
+		if c[0] == 0 {
+			return string(result), nil
+		}
+		result = append(result, c[0])
+	}
+}
+
+// WriteString writes a null terminated string to a buffer of size bytes, truncating it if needed
+func (vm *VM) WriteString(ptr uint64, s string, size int) error {
+	mem, offset, err := vm.region(ptr)
+	if err != nil {
+		return err
+	}
+
+	if size <= 0 {
+		return nil
+	}
+
+	if len(s) > size-1 {
+		s = s[:size-1]
+	}
+	return mem.write(offset, append([]byte(s), 0))
+}
@@ -0,0 +1,109 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+// NativeFunc handles a call to a native. Results are returned with Return
+type NativeFunc func(call *NativeCall) error
+
+// NativeCall is a call to a native
+type NativeCall struct {
+	VM     *VM
+	Native script.Native64
+	Name   string
+	Args   []uint64
+
+	results []uint64
+}
+
+// RegisterNative sets the handler for the native with the given name, as found in the native DB
+func (vm *VM) RegisterNative(name string, fn NativeFunc) {
+	vm.NativesByName[name] = fn
+}
+
+// RegisterNativeHash sets the handler for the native with the given hash
+func (vm *VM) RegisterNativeHash(hash script.Native64, fn NativeFunc) {
+	vm.NativesByHash[hash] = fn
+}
+
+/* Int returns an argument as an int */
+func (call *NativeCall) Int(i int) int32 {
+	return Int(call.Args[i])
+}
+
+/* Float returns an argument as a float */
+func (call *NativeCall) Float(i int) float32 {
+	return Float(call.Args[i])
+}
+
+/* Bool returns an argument as a bool */
+func (call *NativeCall) Bool(i int) bool {
+	return Int(call.Args[i]) != 0
+}
+
+/* String returns the string pointed to by an argument */
+func (call *NativeCall) String(i int) (string, error) {
+	return call.VM.ReadString(call.Args[i])
+}
+
+/* Return sets the values returned by the native */
+func (call *NativeCall) Return(values ...uint64) {
+	call.results = values
+}
+
+// LogNative is the default native handler. It logs the call and returns zeroes
+func LogNative(call *NativeCall) error {
+	args := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = fmt.Sprintf("%#x", arg)
+	}
+
+	log.Printf("native %v (%x)(%v)\n", call.Name, call.Native, strings.Join(args, ", "))
+	return nil
+}
+
+func (vm *VM) callNative(op *script.CallNOperands) error {
+	args, err := vm.popN(uint64(op.InSize))
+	if err != nil {
+		return err
+	}
+
+	call := &NativeCall{
+		VM:     vm,
+		Native: op.Native,
+		Name:   "unknown",
+		Args:   args,
+	}
+
+	if vm.Script.HashTable != nil {
+		if spec := vm.Script.LookupNative(op.Native); spec != nil {
+			call.Name = spec.Name
+		}
+	}
+
+	handler, ok := vm.NativesByHash[op.Native]
+	if !ok {
+		handler, ok = vm.NativesByName[call.Name]
+	}
+	if !ok {
+		handler = vm.Default
+	}
+
+	/* Results are pushed before a yield is passed on, so resuming continues after the call */
+	err = handler(call)
+	if err != nil && err != ErrYield {
+		return fmt.Errorf("native %v: %v", call.Name, err)
+	}
+
+	results := make([]uint64, op.OutSize)
+	copy(results, call.results)
+	if pushErr := vm.push(results...); pushErr != nil {
+		return pushErr
+	}
+	return err
+}
@@ -0,0 +1,295 @@
+// Package vm executes decoded script instructions. Values are held in 8 byte slots, as on PC.
+// Ints are sign extended 32 bit values and floats are stored as 32 bit floats in the low half
+// of a slot. Locals, statics, globals and the string table live in separate regions of memory,
+// and pointers into them can be loaded, stored and offset like any other value
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+var (
+	// ErrYield can be returned by a native handler to pause execution. Resume continues from the
+	// following instruction
+	ErrYield = errors.New("script yielded")
+
+	ErrStepLimit     = errors.New("step limit reached")
+	ErrStackOverflow = errors.New("stack overflow")
+	ErrFinished      = errors.New("script has finished")
+)
+
+const DefaultStackSize = 0x10000
+
+/* frame is a function activation. The return address is kept outside of the script's stack */
+type frame struct {
+	returnPC int
+	fp       uint64
+	argc     uint64
+}
+
+type VM struct {
+	Script *script.Script
+
+	/* Native handlers, looked up by hash before name. Unhandled natives are passed to Default */
+	NativesByName map[string]NativeFunc
+	NativesByHash map[script.Native64]NativeFunc
+	Default       NativeFunc
+
+	/* Trace is called before each instruction is executed */
+	Trace func(vm *VM, istr *script.Instruction)
+
+	/* MaxSteps limits the number of instructions executed by each call, if it's non-zero */
+	MaxSteps int
+
+	/* StackSize is the size of the stack in slots */
+	StackSize uint64
+
+	code  []script.Instruction
+	index map[uint32]int
+
+	stack   *memory
+	statics *memory
+	globals *memory
+	strings *memory
+
+	pc     int
+	sp     uint64
+	frames []frame
+	steps  int
+
+	results []uint64
+}
+
+// New creates a VM to run the instructions of a script, with its statics initialised
+func New(s *script.Script, code []script.Instruction) *VM {
+	vm := &VM{
+		Script:        s,
+		NativesByName: make(map[string]NativeFunc),
+		NativesByHash: make(map[script.Native64]NativeFunc),
+		Default:       LogNative,
+		StackSize:     DefaultStackSize,
+		code:          code,
+		index:         make(map[uint32]int),
+		stack:         newMemory("stack"),
+		statics:       newMemory("statics"),
+		globals:       newMemory("globals"),
+		strings:       newMemory("strings"),
+	}
+
+	for i := range code {
+		vm.index[code[i].Address] = i
+	}
+
+	for i, value := range s.StaticValues {
+		vm.statics.store(uint64(i)*slotSize, value)
+	}
+
+	vm.strings.write(0, s.StringTable)
+	vm.strings.readOnly = true
+
+	return vm
+}
+
+// Function returns the address of the function with the given name
+func (vm *VM) Function(name string) (uint32, bool) {
+	for _, istr := range vm.code {
+		if op, ok := istr.Operands.(*script.EnterOperands); ok && op.Name == name {
+			return istr.Address, true
+		}
+	}
+	return 0, false
+}
+
+// Run executes the script from its entry point, which is the first instruction
+func (vm *VM) Run(args ...uint64) ([]uint64, error) {
+	if len(vm.code) == 0 {
+		return nil, ErrFinished
+	}
+	return vm.Call(vm.code[0].Address, args...)
+}
+
+// Call executes the function at addr until it returns, or until a native yields
+func (vm *VM) Call(addr uint32, args ...uint64) ([]uint64, error) {
+	pc, ok := vm.index[addr]
+	if !ok {
+		return nil, fmt.Errorf("no instruction at %.8x", addr)
+	}
+
+	for _, arg := range args {
+		if err := vm.push(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	/* The function returns to -1, which ends the call */
+	vm.frames = append(vm.frames, frame{returnPC: -1, fp: vm.sp})
+	vm.pc = pc
+	return vm.Resume()
+}
+
+// Resume continues execution after a native has yielded
+func (vm *VM) Resume() ([]uint64, error) {
+	vm.steps = 0
+	for {
+		if vm.pc == -1 {
+			return vm.results, nil
+		}
+
+		if err := vm.Step(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Step executes a single instruction
+func (vm *VM) Step() error {
+	if vm.pc < 0 || vm.pc >= len(vm.code) {
+		return ErrFinished
+	}
+
+	if vm.MaxSteps != 0 && vm.steps >= vm.MaxSteps {
+		return ErrStepLimit
+	}
+	vm.steps++
+
+	istr := &vm.code[vm.pc]
+	if vm.Trace != nil {
+		vm.Trace(vm, istr)
+	}
+
+	vm.pc++
+	if err := vm.exec(istr); err != nil {
+		if err == ErrYield {
+			return err
+		}
+		return fmt.Errorf("%v: %v", istr.String(), err)
+	}
+	return nil
+}
+
+// PC returns the address of the next instruction to be executed
+func (vm *VM) PC() uint32 {
+	if vm.pc < 0 || vm.pc >= len(vm.code) {
+		return 0
+	}
+	return vm.code[vm.pc].Address
+}
+
+// Stack returns the contents of the stack, from the bottom up
+func (vm *VM) Stack() []uint64 {
+	result := make([]uint64, vm.sp)
+	for i := range result {
+		result[i] = vm.stack.load(uint64(i) * slotSize)
+	}
+	return result
+}
+
+// Local returns a pointer to a local of the current function
+func (vm *VM) Local(index uint64) uint64 {
+	return Pointer(RegionStack, (vm.fp()+index)*slotSize)
+}
+
+// Static returns a pointer to a static
+func (vm *VM) Static(index uint64) uint64 {
+	return Pointer(RegionStatics, index*slotSize)
+}
+
+// Global returns a pointer to a global
+func (vm *VM) Global(index uint64) uint64 {
+	return Pointer(RegionGlobals, index*slotSize)
+}
+
+func (vm *VM) fp() uint64 {
+	if len(vm.frames) == 0 {
+		return 0
+	}
+	return vm.frames[len(vm.frames)-1].fp
+}
+
+func (vm *VM) push(values ...uint64) error {
+	for _, value := range values {
+		if vm.sp >= vm.StackSize {
+			return ErrStackOverflow
+		}
+
+		vm.stack.store(vm.sp*slotSize, value)
+		vm.sp++
+	}
+	return nil
+}
+
+func (vm *VM) pop() (uint64, error) {
+	if vm.sp == 0 {
+		return 0, errors.New("stack underflow")
+	}
+
+	vm.sp--
+	return vm.stack.load(vm.sp * slotSize), nil
+}
+
+/* popN pops n values, returning them in the order they were pushed */
+func (vm *VM) popN(n uint64) ([]uint64, error) {
+	/* check before allocating, as n may come from a malformed operand */
+	if n > vm.sp {
+		return nil, errors.New("stack underflow")
+	}
+
+	values := make([]uint64, n)
+	for i := int(n) - 1; i >= 0; i-- {
+		value, err := vm.pop()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (vm *VM) peek() (uint64, error) {
+	if vm.sp == 0 {
+		return 0, errors.New("stack underflow")
+	}
+	return vm.stack.load((vm.sp - 1) * slotSize), nil
+}
+
+func (vm *VM) jump(addr uint32) error {
+	pc, ok := vm.index[addr]
+	if !ok {
+		return fmt.Errorf("no instruction at %.8x", addr)
+	}
+
+	vm.pc = pc
+	return nil
+}
+
+// Int returns a value as an int
+func Int(value uint64) int32 {
+	return int32(value)
+}
+
+// Float returns a value as a float
+func Float(value uint64) float32 {
+	return math.Float32frombits(uint32(value))
+}
+
+// FromInt returns the value of an int
+func FromInt(i int32) uint64 {
+	return uint64(int64(i))
+}
+
+// FromFloat returns the value of a float
+func FromFloat(f float32) uint64 {
+	return uint64(math.Float32bits(f))
+}
+
+// FromBool returns the value of a bool
+func FromBool(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package vm
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/script"
+)
+
+const testSource = `
+sum:
+    enter 1 5 0 4 <sum>
+    pushim 0
+    setlocal 3
+    pushim 1
+    setlocal 4
+loop:
+    getlocal 4
+    getlocal 0
+    ble done
+    getlocal 3
+    getlocal 4
+    addi
+    setlocal 3
+    getlocal 4
+    pushim 1
+    addi
+    setlocal 4
+    b loop
+done:
+    getlocal 3
+    ret 1 1
+fact:
+    enter 1 3 0 5 <fact>
+    getlocal 0
+    pushim 1
+    ble recurse
+    pushim 1
+    ret 1 1
+recurse:
+    getlocal 0
+    getlocal 0
+    pushim 1
+    subi
+    call fact
+    muli
+    ret 1 1
+neg:
+    enter 1 3 0 4 <neg>
+    getlocal 0
+    negi
+    ret 1 1
+`
+
+/* load assembles the test source and creates a VM to run it */
+func load(t *testing.T) *VM {
+	resource.SetArch(resource.ArchPC)
+
+	data, err := script.Assemble("test", strings.NewReader(testSource))
+	if err != nil {
+		t.Fatalf("assembling: %v", err)
+	}
+
+	res := new(resource.Container)
+	if err := res.Unpack(data, "test.ysc", uint32(len(data))); err != nil {
+		t.Fatalf("unpacking container: %v", err)
+	}
+
+	code := make([]script.Instruction, 0)
+	s := script.NewScript("test.ysc", uint32(len(data)))
+	err = s.Unpack(res, func(istr script.Instruction) {
+		code = append(code, istr)
+	})
+	if err != nil {
+		t.Fatalf("unpacking script: %v", err)
+	}
+
+	vm := New(s, code)
+	vm.MaxSteps = 10000
+	return vm
+}
+
+/* call runs one of the test functions, which each return a single value */
+func call(t *testing.T, vm *VM, name string, args ...uint64) (uint64, error) {
+	addr, ok := vm.Function(name)
+	if !ok {
+		t.Fatalf("no function %v", name)
+	}
+
+	results, err := vm.Call(addr, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("%v: expected 1 result, got %v", name, results)
+	}
+	return results[0], nil
+}
+
+func TestLoop(t *testing.T) {
+	result, err := call(t, load(t), "sum", FromInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Int(result) != 55 {
+		t.Errorf("expected 55, got %v", Int(result))
+	}
+}
+
+func TestRecursion(t *testing.T) {
+	result, err := call(t, load(t), "fact", FromInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Int(result) != 120 {
+		t.Errorf("expected 120, got %v", Int(result))
+	}
+}
+
+func TestNegate(t *testing.T) {
+	for _, value := range []int32{5, -7, math.MinInt32} {
+		result, err := call(t, load(t), "neg", FromInt(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if Int(result) != -value {
+			t.Errorf("negating %v: expected %v, got %v", value, -value, Int(result))
+		}
+	}
+}
+
+func TestStack(t *testing.T) {
+	vm := load(t)
+	if err := vm.push(1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := vm.popN(4); err == nil {
+		t.Error("expected popping 4 of 3 values to underflow")
+	}
+
+	values, err := vm.popN(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != 2 || values[0] != 2 || values[1] != 3 {
+		t.Errorf("expected [2 3], got %v", values)
+	}
+
+	if _, err := vm.popN(math.MaxUint64); err == nil {
+		t.Error("expected popping a huge count to underflow")
+	}
+}
@@ -0,0 +1,299 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// An Xref is a use of a function, native, string or static by an instruction
+type Xref struct {
+	Script   string `json:"script"`
+	Function string `json:"function"`
+	Address  uint32 `json:"address"`
+
+	/* Access is set for statics, and is one of "read", "write" or "reference" */
+	Access string `json:"access,omitempty"`
+}
+
+func (x Xref) String() string {
+	s := fmt.Sprintf("%v:%v @ %.8x", x.Script, x.Function, x.Address)
+	if x.Access != "" {
+		s += " (" + x.Access + ")"
+	}
+	return s
+}
+
+// FunctionRef identifies a function within a set of scripts
+type FunctionRef struct {
+	Script   string `json:"script"`
+	Function string `json:"function"`
+}
+
+func (f FunctionRef) String() string {
+	return f.Script + ":" + f.Function
+}
+
+// StaticRef identifies a static variable within a set of scripts
+type StaticRef struct {
+	Script string `json:"script"`
+	Index  int    `json:"index"`
+}
+
+// XrefIndex is a cross reference database over a set of scripts
+type XrefIndex struct {
+	/* Natives resolves native hashes to names. Unresolved natives are indexed by hash */
+	Natives *NativeDB
+
+	Scripts   []string
+	Functions []FunctionRef
+
+	Callers map[FunctionRef][]Xref
+	Native  map[string][]Xref
+	Strings map[string][]Xref
+	Statics map[StaticRef][]Xref
+}
+
+func NewXrefIndex(natives *NativeDB) *XrefIndex {
+	return &XrefIndex{
+		Natives:   natives,
+		Scripts:   make([]string, 0),
+		Functions: make([]FunctionRef, 0),
+		Callers:   make(map[FunctionRef][]Xref),
+		Native:    make(map[string][]Xref),
+		Strings:   make(map[string][]Xref),
+		Statics:   make(map[StaticRef][]Xref),
+	}
+}
+
+// Scan records the cross references made by a script's instructions
+func (x *XrefIndex) Scan(name string, script *Script, code []Instruction) {
+	x.Scripts = append(x.Scripts, name)
+
+	/* Calls are by address, so find the function at each address first */
+	functions := make(map[uint32]string)
+	for _, istr := range code {
+		if op, ok := istr.Operands.(*EnterOperands); ok {
+			functions[istr.Address] = op.Name
+			x.Functions = append(x.Functions, FunctionRef{name, op.Name})
+		}
+	}
+
+	function := ""
+	for i, istr := range code {
+		if op, ok := istr.Operands.(*EnterOperands); ok {
+			function = op.Name
+			continue
+		}
+
+		ref := Xref{
+			Script:   name,
+			Function: function,
+			Address:  istr.Address,
+		}
+
+		switch istr.Operation {
+		case OpCall:
+			op := istr.Operands.(*CallOperands)
+			callee, ok := functions[op.Val]
+			if !ok {
+				callee = fmt.Sprintf("sub_%.8x", op.Val)
+			}
+			x.Callers[FunctionRef{name, callee}] = append(x.Callers[FunctionRef{name, callee}], ref)
+
+		case OpCallN:
+			native := x.nativeName(script, istr.Operands.(*CallNOperands).Native)
+			x.Native[native] = append(x.Native[native], ref)
+
+		case OpPushStr:
+			/* The string table offset is pushed by the previous instruction */
+			if i == 0 || script == nil {
+				continue
+			}
+
+			offset, ok := code[i-1].Operands.(ImmediateIntOperands)
+			if !ok || code[i-1].Operation != OpPush || offset.Int() < 0 || offset.Int() >= len(script.StringTable) {
+				continue
+			}
+
+			str := script.StringTableEntry(offset.Int())
+			x.Strings[str] = append(x.Strings[str], ref)
+
+		case OpGetStatic, OpSetStatic, OpGetStaticP:
+			op, ok := istr.Operands.(ImmediateIntOperands)
+			if !ok {
+				continue
+			}
+
+			ref.Access = staticAccess[istr.Operation]
+			static := StaticRef{name, op.Int()}
+			x.Statics[static] = append(x.Statics[static], ref)
+		}
+	}
+}
+
+var staticAccess = map[uint8]string{
+	OpGetStatic:  "read",
+	OpSetStatic:  "write",
+	OpGetStaticP: "reference",
+}
+
+func (x *XrefIndex) nativeName(script *Script, hash Native64) string {
+	platform := PlatformPC
+	if script != nil {
+		platform = script.platform()
+	}
+
+	if spec := platform.LookupNative(x.Natives, hash); spec != nil && spec.Name != "" {
+		return spec.Name
+	}
+	return fmt.Sprintf("%x", uint64(hash))
+}
+
+// CallersOf returns the calls to a function. function is either a bare function name, which matches
+// in any script, or script:function
+func (x *XrefIndex) CallersOf(function string) []Xref {
+	script := ""
+	if i := strings.LastIndex(function, ":"); i != -1 {
+		script, function = function[:i], function[i+1:]
+	}
+
+	refs := make([]Xref, 0)
+	for callee, callers := range x.Callers {
+		if callee.Function == function && (script == "" || callee.Script == script) {
+			refs = append(refs, callers...)
+		}
+	}
+	return sortXrefs(refs)
+}
+
+// NativeCallers returns the calls to a native, by name or hash. Names are case insensitive
+func (x *XrefIndex) NativeCallers(native string) []Xref {
+	refs := make([]Xref, 0)
+	for name, callers := range x.Native {
+		if strings.EqualFold(name, native) || strings.EqualFold(strings.TrimPrefix(native, "0x"), name) {
+			refs = append(refs, callers...)
+		}
+	}
+	return sortXrefs(refs)
+}
+
+// StringUses returns the places a string from the string table is pushed
+func (x *XrefIndex) StringUses(str string) []Xref {
+	return sortXrefs(append([]Xref(nil), x.Strings[str]...))
+}
+
+// StaticUses returns the uses of a static. If script is empty, the static is matched in every script
+func (x *XrefIndex) StaticUses(script string, index int) []Xref {
+	refs := make([]Xref, 0)
+	for static, uses := range x.Statics {
+		if static.Index == index && (script == "" || static.Script == script) {
+			refs = append(refs, uses...)
+		}
+	}
+	return sortXrefs(refs)
+}
+
+func sortXrefs(refs []Xref) []Xref {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Script != refs[j].Script {
+			return refs[i].Script < refs[j].Script
+		}
+		return refs[i].Address < refs[j].Address
+	})
+	return refs
+}
+
+// WriteXrefs writes a list of cross references, one per line
+func WriteXrefs(w io.Writer, refs []Xref) error {
+	for _, ref := range refs {
+		if _, err := fmt.Fprintln(w, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteXrefsJSON writes a list of cross references as a JSON array
+func WriteXrefsJSON(w io.Writer, refs []Xref) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(refs)
+}
+
+// WriteCallGraphDOT writes the call graph as a Graphviz digraph, with a cluster per script. Calls to
+// natives are included if natives is set
+func (x *XrefIndex) WriteCallGraphDOT(w io.Writer, natives bool) error {
+	var buf strings.Builder
+
+	buf.WriteString("digraph calls {\n")
+	buf.WriteString("\tnode [shape=box fontname=\"monospace\"];\n")
+
+	functions := append([]FunctionRef(nil), x.Functions...)
+	nodes := make(map[FunctionRef]bool)
+	for _, f := range functions {
+		nodes[f] = true
+	}
+
+	/* Calls to addresses without a function still need a node */
+	for callee := range x.Callers {
+		if !nodes[callee] {
+			nodes[callee] = true
+			functions = append(functions, callee)
+		}
+	}
+
+	for i, script := range x.Scripts {
+		fmt.Fprintf(&buf, "\tsubgraph cluster_%v {\n", i)
+		fmt.Fprintf(&buf, "\t\tlabel=%v;\n", dotQuote(script))
+		for _, f := range functions {
+			if f.Script == script {
+				fmt.Fprintf(&buf, "\t\t%v [label=%v];\n", dotQuote(f.String()), dotQuote(f.Function))
+			}
+		}
+		buf.WriteString("\t}\n")
+	}
+
+	/* Multiple calls between the same pair of functions are drawn as a single edge */
+	edges := make(map[[2]string]int)
+	for callee, callers := range x.Callers {
+		for _, caller := range callers {
+			edges[[2]string{FunctionRef{caller.Script, caller.Function}.String(), callee.String()}]++
+		}
+	}
+
+	if natives {
+		for native, callers := range x.Native {
+			fmt.Fprintf(&buf, "\t%v [label=%v shape=ellipse];\n", dotQuote("native:"+native), dotQuote(native))
+			for _, caller := range callers {
+				edges[[2]string{FunctionRef{caller.Script, caller.Function}.String(), "native:" + native}]++
+			}
+		}
+	}
+
+	keys := make([][2]string, 0, len(edges))
+	for edge := range edges {
+		keys = append(keys, edge)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	for _, edge := range keys {
+		attrs := ""
+		if n := edges[edge]; n > 1 {
+			attrs = fmt.Sprintf(" [label=\"%v\"]", n)
+		}
+		fmt.Fprintf(&buf, "\t%v -> %v%v;\n", dotQuote(edge[0]), dotQuote(edge[1]), attrs)
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
@@ -0,0 +1,13 @@
+package texture
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		texture := new(Texture)
+		return texture, texture.Unpack(res)
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "texture dictionary", Extension: ".ytd", Type: resource.ResourceTexture, Arch: resource.ArchPC, Unpack: unpack})
+	resource.RegisterFormat(resource.Format{Name: "texture dictionary", Extension: ".xtd", Type: resource.ResourceTexture, Arch: resource.Arch360, Unpack: unpack})
+}
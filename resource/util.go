@@ -2,6 +2,7 @@ package resource
 
 import (
 	"encoding/binary"
+	"log"
 
 	"github.com/tgascoigne/ragekit/resource/types"
 )
@@ -11,19 +12,47 @@ type Arch int
 const (
 	ArchPC Arch = iota
 	Arch360
+	ArchPS3
 )
 
 var nativeEndian binary.ByteOrder
+var currentArch Arch
 
 func SetArch(a Arch) {
 	switch a {
 	case ArchPC:
 		nativeEndian = binary.LittleEndian
-	case Arch360:
+	case Arch360, ArchPS3:
 		nativeEndian = binary.BigEndian
 	default:
 		panic("unknown architecture")
 	}
+	currentArch = a
+}
+
+/* CurrentArch returns the architecture set by SetArch */
+func CurrentArch() Arch {
+	if nativeEndian == nil {
+		panic("architecture not set")
+	}
+	return currentArch
+}
+
+/* ByteOrder returns the byte order of the current architecture */
+func ByteOrder() binary.ByteOrder {
+	if nativeEndian == nil {
+		panic("architecture not set")
+	}
+	return nativeEndian
+}
+
+/* Verbose enables tracing of the package and container internals */
+var Verbose = false
+
+func debugf(format string, args ...interface{}) {
+	if Verbose {
+		log.Printf(format, args...)
+	}
 }
 
 func parseStruct(res *Container, data interface{}) error {
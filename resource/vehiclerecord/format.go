@@ -0,0 +1,12 @@
+package vehiclerecord
+
+import "github.com/tgascoigne/ragekit/resource"
+
+func init() {
+	unpack := func(res *resource.Container, name string, size uint32) (interface{}, error) {
+		rec := new(Record)
+		return rec, rec.Unpack(res)
+	}
+
+	resource.RegisterFormat(resource.Format{Name: "vehicle recording", Extension: ".yvr", Type: resource.ResourceVR, Arch: resource.ArchPC, Unpack: unpack})
+}
@@ -0,0 +1,137 @@
+package vehiclerecord
+
+import (
+	"github.com/Jragonmiris/mathgl"
+
+	"github.com/tgascoigne/ragekit/cmd/rage-model-export/export"
+	"github.com/tgascoigne/ragekit/resource"
+	"github.com/tgascoigne/ragekit/resource/types"
+)
+
+const (
+	velocityScale = 1.0 / 273.0
+	axisScale     = 1.0 / 127.0
+	pedalScale    = 1.0 / 100.0
+)
+
+type RecordHeader struct {
+	_        uint32 /* vtable */
+	_        uint32
+	BlockMap types.Ptr32
+	_        uint32
+	Entries  resource.Collection64
+}
+
+/* Entry is a single packed sample of a recording */
+type Entry struct {
+	Time      uint32
+	Velocity  [3]int16
+	Right     [3]int8
+	Top       [3]int8
+	Steering  int8
+	Gas       int8
+	Brake     int8
+	Handbrake uint8
+	Position  [3]float32
+}
+
+/* Sample is an unpacked Entry */
+type Sample struct {
+	Time      float32
+	Position  mathgl.Vec3f
+	Velocity  mathgl.Vec3f
+	Right     mathgl.Vec3f
+	Forward   mathgl.Vec3f
+	Top       mathgl.Vec3f
+	Steering  float32
+	Gas       float32
+	Brake     float32
+	Handbrake bool
+}
+
+type Record struct {
+	Header  RecordHeader
+	Samples []Sample
+}
+
+func (rec *Record) Unpack(res *resource.Container) error {
+	res.Parse(&rec.Header)
+
+	rec.Samples = make([]Sample, rec.Header.Entries.Count)
+	return rec.Header.Entries.For(res, func(i int) error {
+		var entry Entry
+		res.Parse(&entry)
+		rec.Samples[i] = entry.Sample()
+		return nil
+	})
+}
+
+func (e Entry) Sample() Sample {
+	vec := func(v [3]int8) mathgl.Vec3f {
+		return mathgl.Vec3f{float32(v[0]) * axisScale, float32(v[1]) * axisScale, float32(v[2]) * axisScale}
+	}
+
+	sample := Sample{
+		Time:      float32(e.Time) / 1000,
+		Position:  mathgl.Vec3f(e.Position),
+		Right:     vec(e.Right),
+		Top:       vec(e.Top),
+		Steering:  float32(e.Steering) * axisScale,
+		Gas:       float32(e.Gas) * pedalScale,
+		Brake:     float32(e.Brake) * pedalScale,
+		Handbrake: e.Handbrake != 0,
+	}
+
+	for i, v := range e.Velocity {
+		sample.Velocity[i] = float32(v) * velocityScale
+	}
+
+	/* The forward axis isn't stored, as it's implied by the other two */
+	sample.Forward = sample.Top.Cross(sample.Right)
+	return sample
+}
+
+/* Duration returns the time of the final sample in seconds */
+func (rec *Record) Duration() float32 {
+	if len(rec.Samples) == 0 {
+		return 0
+	}
+	return rec.Samples[len(rec.Samples)-1].Time
+}
+
+/* Export builds a model containing the recorded route as a polyline */
+func (rec *Record) Export(name string) *export.Model {
+	model := export.NewModel()
+	model.Name = name
+
+	mesh := export.NewMesh()
+	mesh.Format = export.VertXYZ
+
+	addPoint := func(line export.Polyline, pos mathgl.Vec3f) export.Polyline {
+		line = append(line, mesh.Rel(0))
+		mesh.AddVert4f(mathgl.Vec4f{pos[0], pos[1], pos[2], 1})
+		return line
+	}
+
+	line := make(export.Polyline, 0, len(rec.Samples))
+	for i, sample := range rec.Samples {
+		if len(mesh.Vertices) >= 0xFFFF {
+			/* Split long routes, as vertex indices are only 16 bits. Each segment starts at the
+			   last sample of the previous one, so the route has no gaps */
+			mesh.AddPolyline(line)
+			model.AddMesh(mesh)
+			mesh = export.NewMesh()
+			mesh.Format = export.VertXYZ
+			line = addPoint(make(export.Polyline, 0), rec.Samples[i-1].Position)
+		}
+
+		line = addPoint(line, sample.Position)
+	}
+
+	if len(line) > 1 {
+		mesh.AddPolyline(line)
+	}
+	model.AddMesh(mesh)
+
+	return model
+}
@@ -0,0 +1,78 @@
+package resource
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrPartitionTooLarge error = errors.New("partition too large")
+
+// Pack builds a resource of the given type from the contents of its system and graphics partitions.
+// The partitions are expected to be addressed from 0x50000000 and 0x60000000 respectively
+func Pack(resType uint8, sys []byte, gfx []byte) ([]byte, error) {
+	sysFlags, sysSize, err := encodePartitionSize(uint32(len(sys)))
+	if err != nil {
+		return nil, err
+	}
+
+	gfxFlags, gfxSize, err := encodePartitionSize(uint32(len(gfx)))
+	if err != nil {
+		return nil, err
+	}
+
+	/* The resource type is also stored in the upper nibbles of the partition flags */
+	header := ContainerHeader{
+		Magic:    resMagic1,
+		Version:  uint32(resType),
+		SysFlags: sysFlags | (uint32(resType>>4)&0xF)<<28,
+		GfxFlags: gfxFlags | (uint32(resType)&0xF)<<28,
+	}
+
+	var body bytes.Buffer
+	body.Write(sys)
+	body.Write(make([]byte, sysSize-uint32(len(sys))))
+	body.Write(gfx)
+	body.Write(make([]byte, gfxSize-uint32(len(gfx))))
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, header.Magic)
+	binary.Write(&out, ByteOrder(), header.Version)
+	binary.Write(&out, ByteOrder(), header.SysFlags)
+	binary.Write(&out, ByteOrder(), header.GfxFlags)
+
+	compressor, err := flate.NewWriter(&out, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := compressor.Write(body.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// encodePartitionSize returns the smallest set of flags describing a partition of at least size bytes,
+// along with the size which they describe. Only the single page count field is used
+func encodePartitionSize(size uint32) (uint32, uint32, error) {
+	if size == 0 {
+		return 0, 0, nil
+	}
+
+	for shift := uint32(0); shift < 0x10; shift++ {
+		base := uint32(baseSize) << shift
+		pages := (size + base - 1) / base
+		if pages <= 0x7F {
+			flags := shift | pages<<17
+			return flags, getPartitionSize(flags), nil
+		}
+	}
+
+	return 0, 0, ErrPartitionTooLarge
+}